@@ -0,0 +1,69 @@
+// Command intdash-mock serves the intdash API endpoints used by intdash-webhook-app with the data of fixture files,
+// so that the handler can be run and tested without a live intdash environment.
+//
+//	go run ./cmd/intdash-mock -addr :8081 -fixtures testdata/intdash-mock.json
+//
+// Then run the handler with INTDASH_API_URL=http://localhost:8081.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	var (
+		addr     = flag.String("addr", ":8081", "address to listen on")
+		fixtures = flag.String("fixtures", "", "comma-separated fixture files of the measurements and the data points")
+		token    = flag.String("token", "", "API token or access token required in requests. Any token is accepted if empty")
+	)
+	flag.Parse()
+
+	if err := run(*addr, *fixtures, *token); err != nil {
+		slog.Error("Failed to run intdash mock", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(addr, fixtures, token string) error {
+	if fixtures == "" {
+		return errors.New("fixtures is not set")
+	}
+	s := newMockServer(token)
+	if err := s.loadFixtures(fixtures); err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", addr, "measurements", len(s.measurements))
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fixture is a fixture file: the measurements served by the mock.
+//
+//	{"measurements": [{"uuid": "...", "name": "...", "basetime": "2024-01-01T00:00:00Z",
+//	  "data_points": [{"data_id": "float64:speed", "elapsed": 0.5, "value": 100}]}]}
+type fixture struct {
+	Measurements []*fixtureMeasurement `json:"measurements"`
+}
+
+// fixtureMeasurement is a measurement of a fixture.
+// The max_elapsed_time of the measurement is the elapsed time of the last data point.
+type fixtureMeasurement struct {
+	UUID       string             `json:"uuid"`
+	Name       string             `json:"name"`
+	EdgeUUID   string             `json:"edge_uuid"`
+	Basetime   time.Time          `json:"basetime"`
+	Tags       map[string]string  `json:"tags"`
+	DataPoints []fixtureDataPoint `json:"data_points"`
+	Markers    []json.RawMessage  `json:"-"`
+}
+
+// fixtureDataPoint is a float64 data point of a fixture.
+// elapsed is the elapsed time in seconds from the basetime of the measurement.
+type fixtureDataPoint struct {
+	DataID  string  `json:"data_id"`
+	Elapsed float64 `json:"elapsed"`
+	Value   float64 `json:"value"`
+}
+
+// mockServer is an http.Handler of the intdash API endpoints used by the handler:
+//
+//   - POST /api/auth/oauth2/token
+//   - GET /api/auth/users/me
+//   - GET and PUT /api/v1/measurements/{uuid}
+//   - POST /api/v1/measurements/{uuid}/markers
+//   - GET /api/v1/data
+type mockServer struct {
+	token string
+
+	mu           sync.Mutex
+	measurements map[string]*fixtureMeasurement
+}
+
+func newMockServer(token string) *mockServer {
+	return &mockServer{
+		token:        token,
+		measurements: map[string]*fixtureMeasurement{},
+	}
+}
+
+// loadFixtures loads the comma-separated fixture files. A measurement replaces the one of the same UUID.
+func (s *mockServer) loadFixtures(paths string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, path := range strings.Split(paths, ",") {
+		b, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			return fmt.Errorf("read fixture: %w", err)
+		}
+		var f fixture
+		if err := json.Unmarshal(b, &f); err != nil {
+			return fmt.Errorf("decode fixture %s: %w", path, err)
+		}
+		for _, m := range f.Measurements {
+			if m.UUID == "" {
+				return fmt.Errorf("fixture %s: measurement uuid is not set", path)
+			}
+			sort.SliceStable(m.DataPoints, func(i, j int) bool { return m.DataPoints[i].Elapsed < m.DataPoints[j].Elapsed })
+			s.measurements[m.UUID] = m
+		}
+	}
+	return nil
+}
+
+func (s *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Got request", "method", r.Method, "path", r.URL.Path, "query", r.URL.RawQuery)
+
+	if r.URL.Path == "/api/auth/oauth2/token" && r.Method == http.MethodPost {
+		s.issueToken(w, r)
+		return
+	}
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/measurements/")
+	switch {
+	case r.URL.Path == "/api/auth/users/me" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]string{"uuid": "00000000-0000-0000-0000-000000000000", "name": "intdash-mock"})
+	case r.URL.Path == "/api/v1/data" && r.Method == http.MethodGet:
+		s.getDataPoints(w, r)
+	case path != r.URL.Path && strings.HasSuffix(path, "/markers") && r.Method == http.MethodPost:
+		s.createMarker(w, r, strings.TrimSuffix(path, "/markers"))
+	case path != r.URL.Path && !strings.Contains(path, "/") && r.Method == http.MethodGet:
+		s.getMeasurement(w, path)
+	case path != r.URL.Path && !strings.Contains(path, "/") && r.Method == http.MethodPut:
+		s.updateMeasurement(w, r, path)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// authorized reports whether the request has the token, in the X-Intdash-Token header or as the bearer token.
+func (s *mockServer) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	return r.Header.Get("X-Intdash-Token") == s.token || r.Header.Get("Authorization") == "Bearer "+s.token
+}
+
+// issueToken issues the access token for any client credentials.
+func (s *mockServer) issueToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		writeError(w, http.StatusBadRequest, "unsupported grant type")
+		return
+	}
+	token := s.token
+	if token == "" {
+		token = "mock-access-token"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   3600,
+	})
+}
+
+// measurementResponse returns the response of the measurement API of m.
+func measurementResponse(m *fixtureMeasurement) map[string]interface{} {
+	var maxElapsed float64
+	if n := len(m.DataPoints); n > 0 {
+		maxElapsed = m.DataPoints[n-1].Elapsed
+	}
+	return map[string]interface{}{
+		"uuid":             m.UUID,
+		"name":             m.Name,
+		"edge_uuid":        m.EdgeUUID,
+		"basetime":         m.Basetime.UTC().Format(time.RFC3339Nano),
+		"max_elapsed_time": maxElapsed,
+		"tags":             m.Tags,
+	}
+}
+
+func (s *mockServer) getMeasurement(w http.ResponseWriter, uuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.measurements[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "measurement not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, measurementResponse(m))
+}
+
+// updateMeasurement replaces the tags of the measurement.
+func (s *mockServer) updateMeasurement(w http.ResponseWriter, r *http.Request, uuid string) {
+	var req struct {
+		Tags map[string]string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.measurements[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "measurement not found")
+		return
+	}
+	if req.Tags != nil {
+		m.Tags = req.Tags
+		slog.Info("Updated measurement tags", "measurement_uuid", uuid, "tags", req.Tags)
+	}
+	writeJSON(w, http.StatusOK, measurementResponse(m))
+}
+
+// createMarker records the marker of the measurement.
+func (s *mockServer) createMarker(w http.ResponseWriter, r *http.Request, uuid string) {
+	var marker json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&marker); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.measurements[uuid]
+	if !ok {
+		writeError(w, http.StatusNotFound, "measurement not found")
+		return
+	}
+	m.Markers = append(m.Markers, marker)
+	slog.Info("Created measurement marker", "measurement_uuid", uuid, "marker", string(marker))
+	writeJSON(w, http.StatusCreated, marker)
+}
+
+// getDataPoints writes the data points of the measurement as JSON Lines with RFC 3339 times,
+// in time order from start (inclusive) to end (exclusive), at most limit entries.
+// The first entry of the measurement is its basetime, as in intdash.
+func (s *mockServer) getDataPoints(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	m, ok := s.measurements[query.Get("name")]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "measurement not found")
+		return
+	}
+	var start, end time.Time
+	for name, t := range map[string]*time.Time{"start": &start, "end": &end} {
+		if v := query.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid "+name)
+				return
+			}
+			*t = parsed
+		}
+	}
+	limit := -1
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+	dataID := query.Get("data_id_filter")
+
+	inRange := func(t time.Time) bool {
+		return (start.IsZero() || !t.Before(start)) && (end.IsZero() || t.Before(end))
+	}
+	w.Header().Set("Content-Type", "application/jsonl")
+	enc := json.NewEncoder(w)
+	written := 0
+	write := func(t time.Time, dataType, id string, data interface{}) {
+		if limit >= 0 && written >= limit {
+			return
+		}
+		written++
+		if err := enc.Encode(map[string]interface{}{
+			"time":      t.UTC().Format(time.RFC3339Nano),
+			"data_type": dataType,
+			"data_id":   id,
+			"data":      data,
+		}); err != nil {
+			slog.Error("Failed to write data point", "error", err)
+		}
+	}
+	if inRange(m.Basetime) {
+		write(m.Basetime, "basetime", "edge_rtc", map[string]interface{}{"type": "edge_rtc"})
+	}
+	for _, dp := range m.DataPoints {
+		t := m.Basetime.Add(time.Duration(dp.Elapsed * float64(time.Second)))
+		if (dataID == "" || dp.DataID == dataID) && inRange(t) {
+			write(t, "float", dp.DataID, dp.Value)
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,61 @@
+// Command local serves the intdash webhook handler for the local development, with the intdash API stub
+// unless INTDASH_API_URL is set and the notifications printed to stdout unless NOTIFIERS is set,
+// so no intdash or AWS environment is needed.
+//
+//	go run ./cmd/local -secret local-secret -stub-file testdata/speed.csv
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"hello-world/internal/app"
+)
+
+func main() {
+	var (
+		addr     = flag.String("addr", envOr("SERVER_ADDR", app.DefaultServerAddr), "address to listen on (defaults to $SERVER_ADDR)")
+		secret   = flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "webhook secret (defaults to $WEBHOOK_SECRET)")
+		stubFile = flag.String("stub-file", os.Getenv("INTDASH_API_STUB_FILE"), "CSV or JSON file of the data points of the intdash API stub, random data if empty (defaults to $INTDASH_API_STUB_FILE)")
+	)
+	flag.Parse()
+
+	redactor := app.NewLogRedactor(strings.Split(os.Getenv("LOG_REDACT_FIELDS"), ",")...)
+	logger, err := app.NewLogger(os.Stdout, os.Getenv("LOG_LEVEL"), redactor)
+	if err != nil {
+		log.Fatalf("Failed to provide logger: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	if err := run(*addr, *secret, *stubFile, redactor); err != nil {
+		slog.Error("Failed to run local server", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(addr, secret, stubFile string, redactor *app.LogRedactor) error {
+	// The stub reads the file from the configuration like the function does.
+	if stubFile != "" {
+		if err := os.Setenv("INTDASH_API_STUB_FILE", stubFile); err != nil {
+			return fmt.Errorf("set stub file: %w", err)
+		}
+	}
+	handler, err := app.ProvideHandler(app.Environment{Secret: secret, Local: true})
+	if err != nil {
+		return fmt.Errorf("provide handler: %w", err)
+	}
+	handler.LogRedactor = redactor
+	return app.RunServer(handler, addr, "", "")
+}
+
+// envOr returns the environment variable of the key, or def if it is empty.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
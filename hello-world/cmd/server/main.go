@@ -0,0 +1,81 @@
+// Command server serves the intdash webhook handler over plain HTTP(S), e.g. on EC2, ECS, or on-premises,
+// instead of Lambda. It is configured by the same environment variables and SSM parameters as the function.
+//
+//	go run ./cmd/server -addr :8080 -secret-file intdash-webhook-secret
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"hello-world/internal/app"
+)
+
+func main() {
+	var (
+		addr                 = flag.String("addr", envOr("SERVER_ADDR", app.DefaultServerAddr), "address to listen on (defaults to $SERVER_ADDR)")
+		certFile             = flag.String("tls-cert", os.Getenv("TLS_CERT_FILE"), "certificate file to serve HTTPS (defaults to $TLS_CERT_FILE)")
+		keyFile              = flag.String("tls-key", os.Getenv("TLS_KEY_FILE"), "key file to serve HTTPS (defaults to $TLS_KEY_FILE)")
+		secretFile           = flag.String("secret-file", "", "file of the webhook secret, used if none is configured, e.g. intdash-webhook-secret (overrides $WEBHOOK_SECRET_FILE)")
+		pprofAddr            = flag.String("pprof-addr", os.Getenv("PPROF_ADDR"), "address to serve pprof on, e.g. localhost:6060 (defaults to $PPROF_ADDR)")
+		runtimeStatsInterval = flag.String("runtime-stats-interval", os.Getenv("RUNTIME_STATS_INTERVAL"), "interval to log the runtime stats, e.g. 30s (defaults to $RUNTIME_STATS_INTERVAL)")
+	)
+	flag.Parse()
+
+	redactor := app.NewLogRedactor(strings.Split(os.Getenv("LOG_REDACT_FIELDS"), ",")...)
+	logger, err := app.NewLogger(os.Stdout, os.Getenv("LOG_LEVEL"), redactor)
+	if err != nil {
+		log.Fatalf("Failed to provide logger: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	if err := run(*addr, *certFile, *keyFile, *secretFile, *pprofAddr, *runtimeStatsInterval, redactor); err != nil {
+		slog.Error("Failed to run server", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(addr, certFile, keyFile, secretFile, pprofAddr, runtimeStatsInterval string, redactor *app.LogRedactor) error {
+	var env app.Environment
+	if secretFile != "" {
+		b, err := os.ReadFile(secretFile)
+		if err != nil {
+			return fmt.Errorf("read secret file: %w", err)
+		}
+		env.Secret = string(b)
+	}
+	handler, err := app.ProvideHandler(env)
+	if err != nil {
+		return fmt.Errorf("provide handler: %w", err)
+	}
+	handler.LogRedactor = redactor
+
+	if pprofAddr != "" {
+		app.StartPprofServer(pprofAddr)
+	}
+	if runtimeStatsInterval != "" {
+		interval, err := time.ParseDuration(runtimeStatsInterval)
+		if err != nil {
+			return fmt.Errorf("parse runtime stats interval: %w", err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("runtime stats interval %s is not positive", interval)
+		}
+		go app.LogRuntimeStats(context.Background(), interval)
+	}
+	return app.RunServer(handler, addr, certFile, keyFile)
+}
+
+// envOr returns the environment variable of the key, or def if it is empty.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
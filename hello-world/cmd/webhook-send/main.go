@@ -0,0 +1,138 @@
+// Command webhook-send posts a signed test webhook to a local or deployed intdash-webhook-app endpoint.
+//
+//	go run ./cmd/webhook-send -url http://localhost:8080/ -secret YOUR_WEBHOOK_SECRET -measurement-uuid 0f4c...
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"hello-world/pkg/webhook"
+)
+
+// The headers must match the ones validated by the handler.
+const (
+	timestampHeader  = "x-intdash-timestamp"
+	deliveryIDHeader = "x-intdash-delivery-id"
+)
+
+func main() {
+	var (
+		url             = flag.String("url", "http://localhost:8080/", "URL of the webhook endpoint")
+		secret          = flag.String("secret", os.Getenv("INTDASH_WEBHOOK_SECRET"), "webhook secret (defaults to $INTDASH_WEBHOOK_SECRET)")
+		secretFile      = flag.String("secret-file", "", "file of the webhook secret, e.g. intdash-webhook-secret")
+		algorithm       = flag.String("algorithm", "sha256", "HMAC algorithm of the signature, sha256 or sha512")
+		resourceType    = flag.String("resource-type", "measurement", "resource type of the webhook")
+		action          = flag.String("action", "finished", "action of the webhook")
+		measurementUUID = flag.String("measurement-uuid", "", "measurement UUID of the webhook")
+		projectUUID     = flag.String("project-uuid", "", "project UUID of the webhook (optional)")
+		rawBody         = flag.String("body", "", "raw JSON body, sent instead of the body built from the flags")
+		deliveryID      = flag.String("delivery-id", "", "delivery ID (defaults to a random ID)")
+		timestamp       = flag.Bool("timestamp", false, "send the current time in "+timestampHeader+" and sign it with the body")
+		dryRun          = flag.Bool("dry-run", false, "print the request instead of sending it")
+		timeout         = flag.Duration("timeout", 30*time.Second, "timeout of the request")
+	)
+	flag.Parse()
+
+	if err := run(*url, *secret, *secretFile, *algorithm, *resourceType, *action, *measurementUUID, *projectUUID, *rawBody, *deliveryID, *timestamp, *dryRun, *timeout); err != nil {
+		fmt.Fprintln(os.Stderr, "webhook-send:", err)
+		os.Exit(1)
+	}
+}
+
+func run(url, secret, secretFile, algorithm, resourceType, action, measurementUUID, projectUUID, rawBody, deliveryID string, timestamp, dryRun bool, timeout time.Duration) error {
+	if secretFile != "" {
+		b, err := os.ReadFile(secretFile)
+		if err != nil {
+			return fmt.Errorf("read secret file: %w", err)
+		}
+		secret = string(b)
+	}
+	alg, err := webhook.ParseAlgorithm(algorithm)
+	if err != nil {
+		return err
+	}
+	// If the secret is a JSON array of keys, as in the rotation, the first one is used.
+	key := webhook.ParseSecretKeys([]byte(secret))[0]
+	if len(key) == 0 {
+		return fmt.Errorf("secret is not set")
+	}
+
+	body := []byte(rawBody)
+	if rawBody == "" {
+		if measurementUUID == "" && !(&webhook.Body{ResourceType: resourceType, Action: action}).IsPing() {
+			return fmt.Errorf("measurement UUID is not set")
+		}
+		var err error
+		body, err = json.Marshal(&webhook.Body{
+			ResourceType:    resourceType,
+			Action:          action,
+			MeasurementUUID: measurementUUID,
+			ProjectUUID:     projectUUID,
+		})
+		if err != nil {
+			return fmt.Errorf("marshal body: %w", err)
+		}
+	}
+
+	if deliveryID == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return fmt.Errorf("generate delivery ID: %w", err)
+		}
+		deliveryID = hex.EncodeToString(b)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	payload := body
+	if timestamp {
+		// The timestamp is signed along with the body, as the handler validates it.
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(timestampHeader, ts)
+		payload = webhook.TimestampedPayload(ts, body)
+	}
+	req.Header.Set(alg.Header(), base64.StdEncoding.EncodeToString(alg.Sign(key, payload)))
+	req.Header.Set(deliveryIDHeader, deliveryID)
+
+	if dryRun {
+		fmt.Printf("POST %s\n", url)
+		for k := range req.Header {
+			fmt.Printf("%s: %s\n", k, req.Header.Get(k))
+		}
+		fmt.Printf("\n%s\n", body)
+		return nil
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	fmt.Println(resp.Status)
+	if len(respBody) > 0 {
+		fmt.Printf("%s\n", respBody)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HandleALBTargetGroup handles the Application Load Balancer request of intdash webhook.
+func (h *Handler) HandleALBTargetGroup(ctx context.Context, request events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	h.logger().InfoContext(ctx, "Got request", "method", request.HTTPMethod, "path", request.Path)
+
+	req := &webhookRequest{
+		Headers:           request.Headers,
+		MultiValueHeaders: request.MultiValueHeaders,
+		Body:              request.Body,
+		IsBase64Encoded:   request.IsBase64Encoded,
+		Path:              request.Path,
+	}
+	req.SourceIP = albSourceIP(req)
+	res := h.handleWebhook(ctx, req)
+	return events.ALBTargetGroupResponse{
+		Headers:           res.Headers,
+		Body:              res.Body,
+		StatusCode:        res.StatusCode,
+		StatusDescription: fmt.Sprintf("%d %s", res.StatusCode, http.StatusText(res.StatusCode)),
+	}, nil
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HandleAPIGatewayV2HTTP handles the API Gateway HTTP API (payload format version 2.0) request of intdash webhook.
+func (h *Handler) HandleAPIGatewayV2HTTP(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	h.logger().InfoContext(ctx, "Got request", "method", request.RequestContext.HTTP.Method, "path", request.RawPath, "source_ip", request.RequestContext.HTTP.SourceIP)
+
+	res := h.handleWebhook(ctx, &webhookRequest{
+		RequestID:       request.RequestContext.RequestID,
+		Headers:         request.Headers,
+		Body:            request.Body,
+		IsBase64Encoded: request.IsBase64Encoded,
+		Path:            request.RawPath,
+		PathParameters:  request.PathParameters,
+		SourceIP:        request.RequestContext.HTTP.SourceIP,
+	})
+	return events.APIGatewayV2HTTPResponse{
+		Headers:    res.Headers,
+		Body:       res.Body,
+		StatusCode: res.StatusCode,
+	}, nil
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// DefaultAuditTTL is the default period an audit record is kept.
+	DefaultAuditTTL = 30 * 24 * time.Hour
+	// auditUnknownMeasurementUUID is the measurement UUID of the records of the requests without a valid body.
+	auditUnknownMeasurementUUID = "unknown"
+)
+
+// Outcomes of the audit records, in addition to the error codes of the rejected requests, e.g. "invalid_signature".
+const (
+	// AuditOutcomeProcessed is the outcome of a processed delivery.
+	AuditOutcomeProcessed = "processed"
+	// AuditOutcomeNotified is the outcome of a delivery whose notification was sent.
+	AuditOutcomeNotified = "notified"
+	// AuditOutcomeNoAlert is the outcome of a delivery not notified because no alert rule fired.
+	AuditOutcomeNoAlert = "no_alert"
+	// AuditOutcomeDuplicate is the outcome of a delivery skipped because it had already been processed.
+	AuditOutcomeDuplicate = "duplicate"
+	// AuditOutcomeEnqueued is the outcome of a delivery enqueued to be processed by the worker function.
+	AuditOutcomeEnqueued = "enqueued"
+	// AuditOutcomeDropped is the outcome of a queued delivery of an unsupported resource type or action.
+	AuditOutcomeDropped = "dropped"
+	// AuditOutcomeBatch is the outcome of a batch delivery. Each of its events is audited as a delivery of its own.
+	AuditOutcomeBatch = "batch"
+	// AuditOutcomePing is the outcome of a ping, the test delivery of the webhook.
+	AuditOutcomePing = "ping"
+	// AuditOutcomeDryRun is the outcome of a delivery whose notification was only logged in dry-run mode.
+	AuditOutcomeDryRun = "dry_run"
+)
+
+type (
+	// AuditLog records the webhook deliveries and their outcomes,
+	// to answer questions such as why a notification was not sent.
+	AuditLog interface {
+		RecordDelivery(ctx context.Context, record *AuditRecord) error
+	}
+
+	// AuditRecord is the record of a webhook delivery.
+	AuditRecord struct {
+		DeliveryID      string
+		MeasurementUUID string
+		ResourceType    string
+		Action          string
+		// RequestID is the request ID of the event source, if any.
+		RequestID string
+		// Outcome is an AuditOutcome constant or the error code of the response, e.g. "invalid_signature".
+		Outcome    string
+		Error      string
+		ReceivedAt time.Time
+		Latency    time.Duration
+	}
+)
+
+// DynamoDBAuditLog is an AuditLog backed by a DynamoDB table.
+// The table must have the string partition key "measurement_uuid" and the string sort key "sk",
+// and TTL enabled on "expires_at". Each delivery is stored with sk "<received_at>#<delivery_id>",
+// so that the deliveries of a measurement are queried in time order.
+// The deliveries rejected without a valid body are stored with the measurement UUID "unknown".
+type DynamoDBAuditLog struct {
+	DynamoDBAPI DynamoDBAPI
+	TableName   string
+	// TTL is the period a record is kept. Defaults to DefaultAuditTTL.
+	TTL time.Duration
+}
+
+// RecordDelivery puts the record.
+func (l *DynamoDBAuditLog) RecordDelivery(ctx context.Context, record *AuditRecord) error {
+	ttl := l.TTL
+	if ttl <= 0 {
+		ttl = DefaultAuditTTL
+	}
+	measurementUUID := record.MeasurementUUID
+	if measurementUUID == "" {
+		measurementUUID = auditUnknownMeasurementUUID
+	}
+	receivedAt := record.ReceivedAt.UTC().Format(time.RFC3339Nano)
+	item := map[string]types.AttributeValue{
+		"measurement_uuid": &types.AttributeValueMemberS{Value: measurementUUID},
+		"sk":               &types.AttributeValueMemberS{Value: receivedAt + "#" + record.DeliveryID},
+		"received_at":      &types.AttributeValueMemberS{Value: receivedAt},
+		"outcome":          &types.AttributeValueMemberS{Value: record.Outcome},
+		"latency_ms":       &types.AttributeValueMemberN{Value: strconv.FormatInt(record.Latency.Milliseconds(), 10)},
+		"expires_at":       &types.AttributeValueMemberN{Value: strconv.FormatInt(record.ReceivedAt.Add(ttl).Unix(), 10)},
+	}
+	// DynamoDB rejects empty key attributes only, but the empty attributes are omitted for readability.
+	for name, v := range map[string]string{
+		"delivery_id":   record.DeliveryID,
+		"resource_type": record.ResourceType,
+		"action":        record.Action,
+		"request_id":    record.RequestID,
+		"error":         record.Error,
+	} {
+		if v != "" {
+			item[name] = &types.AttributeValueMemberS{Value: v}
+		}
+	}
+	_, err := l.DynamoDBAPI.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(l.TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("put audit record: %w", err)
+	}
+	return nil
+}
+
+type auditRecordContextKey struct{}
+
+// withAuditRecord returns a context with the audit record of the delivery being processed.
+func withAuditRecord(ctx context.Context, record *AuditRecord) context.Context {
+	return context.WithValue(ctx, auditRecordContextKey{}, record)
+}
+
+// setAuditOutcome sets the outcome of the delivery being processed, if it is audited.
+func setAuditOutcome(ctx context.Context, outcome string) {
+	if record, ok := ctx.Value(auditRecordContextKey{}).(*AuditRecord); ok {
+		record.Outcome = outcome
+	}
+}
+
+// recordAudit records the delivery to h.AuditLog, if set. A failure is only logged,
+// so that the audit does not fail the delivery.
+func (h *Handler) recordAudit(ctx context.Context, record *AuditRecord) {
+	if h.AuditLog == nil {
+		return
+	}
+	record.Latency = time.Since(record.ReceivedAt)
+	// ctx may have been canceled by the deadline, which is to be recorded.
+	ctx, cancel := detachedContext(ctx, DefaultDeadlineMargin/2)
+	defer cancel()
+	if err := h.AuditLog.RecordDelivery(ctx, record); err != nil {
+		h.logger().ErrorContext(ctx, "Failed to record audit", "outcome", record.Outcome, "error", err)
+	}
+}
+
+// auditEvent runs fn that processes the queued event, and records the audit of the delivery with its outcome.
+func (h *Handler) auditEvent(ctx context.Context, event *QueuedEvent, fn func(ctx context.Context) error) (err error) {
+	audit := &AuditRecord{
+		DeliveryID:      event.DeliveryID,
+		MeasurementUUID: event.Body.MeasurementUUID,
+		ResourceType:    event.Body.ResourceType,
+		Action:          event.Body.Action,
+		RequestID:       lambdaRequestID(ctx),
+		ReceivedAt:      h.now(),
+	}
+	ctx = withAuditRecord(ctx, audit)
+	defer func() {
+		if err != nil {
+			c := classifyError(err)
+			h.countError(ctx, c.errorType)
+			audit.Outcome, audit.Error = c.code, err.Error()
+		}
+		h.recordAudit(ctx, audit)
+	}()
+	return fn(ctx)
+}
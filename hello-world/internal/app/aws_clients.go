@@ -0,0 +1,282 @@
+package app
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/timestreamwrite"
+)
+
+// awsClients creates the AWS clients lazily, on their first calls, and at most once per service,
+// so that the cold start does not get slower as more optional destinations are configured.
+// The clients share the configuration, including the instrumentation of the tracer.
+type awsClients struct {
+	cloudWatch      func() *cloudwatch.Client
+	dynamoDB        func() *dynamodb.Client
+	eventBridge     func() *eventbridge.Client
+	firehose        func() *firehose.Client
+	kinesis         func() *kinesis.Client
+	kms             func() *kms.Client
+	s3              func() *s3.Client
+	secretsManager  func() *secretsmanager.Client
+	ses             func() *sesv2.Client
+	sfn             func() *sfn.Client
+	sns             func() *sns.Client
+	sqs             func() *sqs.Client
+	ssm             func() *ssm.Client
+	timestreamWrite func() *timestreamwrite.Client
+}
+
+// newAWSClients returns the clients of the configuration, which is to be instrumented before.
+func newAWSClients(config aws.Config) *awsClients {
+	return &awsClients{
+		cloudWatch:      sync.OnceValue(func() *cloudwatch.Client { return cloudwatch.NewFromConfig(config) }),
+		dynamoDB:        sync.OnceValue(func() *dynamodb.Client { return dynamodb.NewFromConfig(config) }),
+		eventBridge:     sync.OnceValue(func() *eventbridge.Client { return eventbridge.NewFromConfig(config) }),
+		firehose:        sync.OnceValue(func() *firehose.Client { return firehose.NewFromConfig(config) }),
+		kinesis:         sync.OnceValue(func() *kinesis.Client { return kinesis.NewFromConfig(config) }),
+		kms:             sync.OnceValue(func() *kms.Client { return kms.NewFromConfig(config) }),
+		s3:              sync.OnceValue(func() *s3.Client { return s3.NewFromConfig(config) }),
+		secretsManager:  sync.OnceValue(func() *secretsmanager.Client { return secretsmanager.NewFromConfig(config) }),
+		ses:             sync.OnceValue(func() *sesv2.Client { return sesv2.NewFromConfig(config) }),
+		sfn:             sync.OnceValue(func() *sfn.Client { return sfn.NewFromConfig(config) }),
+		sns:             sync.OnceValue(func() *sns.Client { return sns.NewFromConfig(config) }),
+		sqs:             sync.OnceValue(func() *sqs.Client { return sqs.NewFromConfig(config) }),
+		ssm:             sync.OnceValue(func() *ssm.Client { return ssm.NewFromConfig(config) }),
+		timestreamWrite: sync.OnceValue(func() *timestreamwrite.Client { return timestreamwrite.NewFromConfig(config) }),
+	}
+}
+
+// CloudWatch returns the CloudWatch API.
+func (c *awsClients) CloudWatch() *lazyCloudWatchClient {
+	return &lazyCloudWatchClient{client: c.cloudWatch}
+}
+
+// lazyCloudWatchClient calls the CloudWatch client created on its first call.
+type lazyCloudWatchClient struct {
+	client func() *cloudwatch.Client
+}
+
+func (c *lazyCloudWatchClient) PutMetricData(ctx context.Context, input *cloudwatch.PutMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error) {
+	return c.client().PutMetricData(ctx, input, optFns...)
+}
+
+// DynamoDB returns the DynamoDB API.
+func (c *awsClients) DynamoDB() *lazyDynamoDBClient {
+	return &lazyDynamoDBClient{client: c.dynamoDB}
+}
+
+// lazyDynamoDBClient calls the DynamoDB client created on its first call.
+type lazyDynamoDBClient struct {
+	client func() *dynamodb.Client
+}
+
+func (c *lazyDynamoDBClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return c.client().PutItem(ctx, input, optFns...)
+}
+
+func (c *lazyDynamoDBClient) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return c.client().DeleteItem(ctx, input, optFns...)
+}
+
+func (c *lazyDynamoDBClient) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return c.client().Query(ctx, input, optFns...)
+}
+
+func (c *lazyDynamoDBClient) BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return c.client().BatchWriteItem(ctx, input, optFns...)
+}
+
+// EventBridge returns the EventBridge API.
+func (c *awsClients) EventBridge() *lazyEventBridgeClient {
+	return &lazyEventBridgeClient{client: c.eventBridge}
+}
+
+// lazyEventBridgeClient calls the EventBridge client created on its first call.
+type lazyEventBridgeClient struct {
+	client func() *eventbridge.Client
+}
+
+func (c *lazyEventBridgeClient) PutEvents(ctx context.Context, input *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	return c.client().PutEvents(ctx, input, optFns...)
+}
+
+// Firehose returns the Firehose API.
+func (c *awsClients) Firehose() *lazyFirehoseClient {
+	return &lazyFirehoseClient{client: c.firehose}
+}
+
+// lazyFirehoseClient calls the Firehose client created on its first call.
+type lazyFirehoseClient struct {
+	client func() *firehose.Client
+}
+
+func (c *lazyFirehoseClient) PutRecordBatch(ctx context.Context, input *firehose.PutRecordBatchInput, optFns ...func(*firehose.Options)) (*firehose.PutRecordBatchOutput, error) {
+	return c.client().PutRecordBatch(ctx, input, optFns...)
+}
+
+// Kinesis returns the Kinesis API.
+func (c *awsClients) Kinesis() *lazyKinesisClient {
+	return &lazyKinesisClient{client: c.kinesis}
+}
+
+// lazyKinesisClient calls the Kinesis client created on its first call.
+type lazyKinesisClient struct {
+	client func() *kinesis.Client
+}
+
+func (c *lazyKinesisClient) PutRecord(ctx context.Context, input *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+	return c.client().PutRecord(ctx, input, optFns...)
+}
+
+// KMS returns the KMS API.
+func (c *awsClients) KMS() *lazyKMSClient {
+	return &lazyKMSClient{client: c.kms}
+}
+
+// lazyKMSClient calls the KMS client created on its first call.
+type lazyKMSClient struct {
+	client func() *kms.Client
+}
+
+func (c *lazyKMSClient) Decrypt(ctx context.Context, input *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	return c.client().Decrypt(ctx, input, optFns...)
+}
+
+// S3 returns the S3 API.
+func (c *awsClients) S3() *lazyS3Client {
+	return &lazyS3Client{client: c.s3}
+}
+
+// lazyS3Client calls the S3 client created on its first call.
+type lazyS3Client struct {
+	client func() *s3.Client
+}
+
+func (c *lazyS3Client) GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return c.client().GetObject(ctx, input, optFns...)
+}
+
+func (c *lazyS3Client) PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	return c.client().PutObject(ctx, input, optFns...)
+}
+
+func (c *lazyS3Client) ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	return c.client().ListObjectsV2(ctx, input, optFns...)
+}
+
+// SecretsManager returns the Secrets Manager API.
+func (c *awsClients) SecretsManager() *lazySecretsManagerClient {
+	return &lazySecretsManagerClient{client: c.secretsManager}
+}
+
+// lazySecretsManagerClient calls the Secrets Manager client created on its first call.
+type lazySecretsManagerClient struct {
+	client func() *secretsmanager.Client
+}
+
+func (c *lazySecretsManagerClient) GetSecretValue(ctx context.Context, input *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	return c.client().GetSecretValue(ctx, input, optFns...)
+}
+
+// SES returns the SES API.
+func (c *awsClients) SES() *lazySESClient {
+	return &lazySESClient{client: c.ses}
+}
+
+// lazySESClient calls the SES client created on its first call.
+type lazySESClient struct {
+	client func() *sesv2.Client
+}
+
+func (c *lazySESClient) SendEmail(ctx context.Context, input *sesv2.SendEmailInput, optFns ...func(*sesv2.Options)) (*sesv2.SendEmailOutput, error) {
+	return c.client().SendEmail(ctx, input, optFns...)
+}
+
+// SFN returns the Step Functions API.
+func (c *awsClients) SFN() *lazySFNClient {
+	return &lazySFNClient{client: c.sfn}
+}
+
+// lazySFNClient calls the Step Functions client created on its first call.
+type lazySFNClient struct {
+	client func() *sfn.Client
+}
+
+func (c *lazySFNClient) StartExecution(ctx context.Context, input *sfn.StartExecutionInput, optFns ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error) {
+	return c.client().StartExecution(ctx, input, optFns...)
+}
+
+// SNS returns the SNS API.
+func (c *awsClients) SNS() *lazySNSClient {
+	return &lazySNSClient{client: c.sns}
+}
+
+// lazySNSClient calls the SNS client created on its first call.
+type lazySNSClient struct {
+	client func() *sns.Client
+}
+
+func (c *lazySNSClient) Publish(ctx context.Context, input *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	return c.client().Publish(ctx, input, optFns...)
+}
+
+func (c *lazySNSClient) GetTopicAttributes(ctx context.Context, input *sns.GetTopicAttributesInput, optFns ...func(*sns.Options)) (*sns.GetTopicAttributesOutput, error) {
+	return c.client().GetTopicAttributes(ctx, input, optFns...)
+}
+
+// SQS returns the SQS API.
+func (c *awsClients) SQS() *lazySQSClient {
+	return &lazySQSClient{client: c.sqs}
+}
+
+// lazySQSClient calls the SQS client created on its first call.
+type lazySQSClient struct {
+	client func() *sqs.Client
+}
+
+func (c *lazySQSClient) SendMessage(ctx context.Context, input *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	return c.client().SendMessage(ctx, input, optFns...)
+}
+
+// SSM returns the SSM API.
+func (c *awsClients) SSM() *lazySSMClient {
+	return &lazySSMClient{client: c.ssm}
+}
+
+// lazySSMClient calls the SSM client created on its first call.
+type lazySSMClient struct {
+	client func() *ssm.Client
+}
+
+func (c *lazySSMClient) GetParametersByPath(ctx context.Context, input *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	return c.client().GetParametersByPath(ctx, input, optFns...)
+}
+
+// TimestreamWrite returns the Timestream Write API.
+func (c *awsClients) TimestreamWrite() *lazyTimestreamWriteClient {
+	return &lazyTimestreamWriteClient{client: c.timestreamWrite}
+}
+
+// lazyTimestreamWriteClient calls the Timestream Write client created on its first call.
+type lazyTimestreamWriteClient struct {
+	client func() *timestreamwrite.Client
+}
+
+func (c *lazyTimestreamWriteClient) WriteRecords(ctx context.Context, input *timestreamwrite.WriteRecordsInput, optFns ...func(*timestreamwrite.Options)) (*timestreamwrite.WriteRecordsOutput, error) {
+	return c.client().WriteRecords(ctx, input, optFns...)
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"hello-world/pkg/webhook"
+)
+
+// BatchResult is the result of an event of a batch delivery.
+type BatchResult struct {
+	// Index is the index of the event in the batch.
+	Index int `json:"index"`
+	// Status is the status code the event would have got as a single delivery, e.g. 204 or 400.
+	Status int `json:"status"`
+	// DeliveryID is the delivery ID of the event, "<delivery ID of the batch>#<index>".
+	DeliveryID string `json:"delivery_id"`
+	// Code and Message are the error code and message of a failed event.
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Errors are the invalid fields of the event, if it is invalid.
+	Errors []webhook.FieldError `json:"errors,omitempty"`
+}
+
+// BatchResponseBody is the JSON body of the 207 Multi-Status response to a batch delivery.
+type BatchResponseBody struct {
+	RequestID string        `json:"request_id,omitempty"`
+	Results   []BatchResult `json:"results"`
+}
+
+// handleBatch processes each event of the batch delivery independently, so that an invalid or failed event
+// does not fail the others, and responds with the result of each of them.
+// The signature and the timestamp have been validated for the whole batch.
+func (h *Handler) handleBatch(ctx context.Context, request *webhookRequest, audit *AuditRecord) webhookResponse {
+	events, err := webhook.SplitBatch([]byte(request.Body))
+	if err != nil {
+		return h.failWebhook(ctx, request, audit, fmt.Errorf("%w: %w", ErrInvalidBody, err))
+	}
+	batchID := h.deliveryID(request)
+	audit.DeliveryID, audit.Outcome = batchID, AuditOutcomeBatch
+	h.logger().InfoContext(ctx, "Got batch delivery", "delivery_id", batchID, "events", len(events))
+
+	results := make([]BatchResult, len(events))
+	for i, event := range events {
+		results[i] = h.handleBatchEvent(ctx, request, audit, fmt.Sprintf("%s#%d", batchID, i), event)
+		results[i].Index = i
+	}
+
+	return jsonResponse(http.StatusMultiStatus, &BatchResponseBody{RequestID: request.RequestID, Results: results})
+}
+
+// handleBatchEvent validates and processes an event of a batch delivery, audited as a delivery of its own.
+func (h *Handler) handleBatchEvent(ctx context.Context, request *webhookRequest, batch *AuditRecord, deliveryID string, data []byte) BatchResult {
+	audit := &AuditRecord{DeliveryID: deliveryID, RequestID: request.RequestID, ReceivedAt: batch.ReceivedAt}
+	defer h.recordAudit(ctx, audit)
+	ctx = withAuditRecord(ctx, audit)
+	ctx = withDeliveryID(ctx, deliveryID)
+
+	fail := func(err error) BatchResult {
+		c := h.recordError(ctx, audit, err)
+		result := BatchResult{Status: c.statusCode, DeliveryID: deliveryID, Code: c.code, Message: c.message}
+		var invalid *webhook.ValidationError
+		if errors.As(err, &invalid) {
+			result.Errors = invalid.Fields
+		}
+		return result
+	}
+
+	body, err := h.decodeBody(request, data)
+	if err != nil {
+		return fail(fmt.Errorf("%w: %w", ErrInvalidBody, err))
+	}
+	audit.MeasurementUUID, audit.ResourceType, audit.Action = body.MeasurementUUID, body.ResourceType, body.Action
+	if h.SignatureValidator == nil && h.keyRoute(body.ProjectUUID) != request.keyRoute {
+		// The event must be signed with the keys of the route of its project, as a single delivery is.
+		return fail(fmt.Errorf("%w: event of project %q is not signed with the keys of its route", ErrInvalidSignature, body.ProjectUUID))
+	}
+	if body.IsPing() {
+		audit.Outcome = AuditOutcomePing
+		return BatchResult{Status: http.StatusNoContent, DeliveryID: deliveryID}
+	}
+	processor, ok := h.lookupProcessor(body)
+	if !ok {
+		return fail(fmt.Errorf("%w: %s %s", ErrUnsupportedEvent, body.ResourceType, body.Action))
+	}
+
+	ctx = withLogAttrs(ctx, "measurement_uuid", body.MeasurementUUID)
+	event := &QueuedEvent{DeliveryID: deliveryID, Body: *body}
+	deadLetter := func(err error) {
+		// The request is of the whole batch, so only the event is replayed.
+		recorded := RecordedEvent{RequestID: request.RequestID, ReceivedAt: batch.ReceivedAt, SourceIP: request.SourceIP, Event: event}
+		h.sendDeadLetter(ctx, &DeadLetter{RecordedEvent: recorded, DeliveryID: deliveryID, Source: DeadLetterSourceWebhook}, err)
+	}
+	if h.EventQueue != nil {
+		if err := h.EventQueue.Enqueue(ctx, event); err != nil {
+			err = fmt.Errorf("%w: delivery %s: %w", ErrEnqueueFailed, deliveryID, err)
+			deadLetter(err)
+			return fail(err)
+		}
+		audit.Outcome = AuditOutcomeEnqueued
+		return BatchResult{Status: http.StatusAccepted, DeliveryID: deliveryID}
+	}
+
+	if err := h.processOnce(ctx, deliveryID, processor, body); err != nil {
+		err = fmt.Errorf("process %s %s: %w", body.ResourceType, body.Action, err)
+		deadLetter(err)
+		return fail(err)
+	}
+	return BatchResult{Status: http.StatusNoContent, DeliveryID: deliveryID}
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultMaxBodySize is the default maximum size of a webhook body, far larger than an intdash event.
+const DefaultMaxBodySize = 1 << 20
+
+// maxBodySize returns the maximum size of a body, or math.MaxInt64 - 1 if the limit is disabled.
+func (h *Handler) maxBodySize() int64 {
+	switch {
+	case h.MaxBodySize < 0:
+		return math.MaxInt64 - 1
+	case h.MaxBodySize == 0:
+		return DefaultMaxBodySize
+	}
+	return h.MaxBodySize
+}
+
+// validateBodySize rejects a body larger than the maximum size, before the signature and the body are processed.
+func (h *Handler) validateBodySize(request *webhookRequest) error {
+	// The body may have been truncated to one byte more than the limit, so its size is not reported.
+	if limit := h.maxBodySize(); request.bodySize() > limit {
+		return fmt.Errorf("body exceeds the limit of %d bytes", limit)
+	}
+	return nil
+}
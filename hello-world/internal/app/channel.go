@@ -0,0 +1,404 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"hello-world/pkg/analyze"
+	"hello-world/pkg/intdash"
+)
+
+// DefaultChannelConcurrency is the default maximum number of channels processed concurrently.
+const DefaultChannelConcurrency = 4
+
+// Channel is a data ID of a measurement to fetch and analyze.
+type Channel struct {
+	// DataID is the data ID of the float64 series, e.g. "float64:speed".
+	DataID string
+	// Unit is the unit of the values shown in notifications, e.g. "km/h". Optional.
+	Unit string
+	// Analyzers analyze the fetched data points. Defaults to a StatisticsAnalyzer.
+	Analyzers []analyze.Analyzer
+	// AlertRules are the threshold rules evaluated on the statistics of the channel.
+	AlertRules []analyze.AlertRule
+	// SentinelValues are the values that mean "no data" in the data points.
+	// They are dropped before the analysis like NaN and Inf.
+	SentinelValues []float64
+	// Window, if set, limits the data points to a segment of the measurement.
+	Window *intdash.TimeWindow
+}
+
+// ChannelConfig is an element of the CHANNELS configuration, a JSON array such as
+//
+//	[
+//	  {"data_id": "float64:speed", "unit": "km/h", "analyzers": ["statistics", "outliers"], "alert_rules": "max > 120"},
+//	  {"data_id": "float64:temperature", "unit": "degC", "config": {"STATISTICS": "min,max"}}
+//	]
+//
+// Config overrides the configuration values, e.g. "STATISTICS" or "HISTOGRAM_BUCKETS", for the channel.
+type ChannelConfig struct {
+	DataID     string            `json:"data_id"`
+	Unit       string            `json:"unit"`
+	Analyzers  []string          `json:"analyzers"`
+	AlertRules string            `json:"alert_rules"`
+	Config     map[string]string `json:"config"`
+}
+
+// parseChannelConfigs parses the CHANNELS configuration.
+func parseChannelConfigs(s string) ([]*ChannelConfig, error) {
+	var configs []*ChannelConfig
+	if err := json.Unmarshal([]byte(s), &configs); err != nil {
+		return nil, fmt.Errorf("unmarshal channels: %w", err)
+	}
+	for i, c := range configs {
+		if c.DataID == "" {
+			return nil, fmt.Errorf("data_id of channel %d is empty", i)
+		}
+	}
+	return configs, nil
+}
+
+// processChannels processes the channels of the measurement concurrently with at most h.ChannelConcurrency workers,
+// and returns the results in the order of h.Channels. If a channel fails, the others are canceled
+// and the first error is returned. measurement is required only if a channel has a window.
+func (h *Handler) processChannels(ctx context.Context, measurementUUID string, measurement *intdash.Measurement) ([]*AnalysisResult, error) {
+	concurrency := h.ChannelConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultChannelConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		results  = make([]*AnalysisResult, len(h.Channels))
+		sem      = make(chan struct{}, concurrency)
+	)
+	for i, ch := range h.Channels {
+		wg.Add(1)
+		go func(i int, ch *Channel) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			result, err := func() (result *AnalysisResult, err error) {
+				// A panic in a goroutine cannot be recovered by the caller.
+				defer func() {
+					if v := recover(); v != nil {
+						err = h.recovered(ctx, v)
+					}
+				}()
+				return h.processChannel(ctx, measurementUUID, ch, measurement)
+			}()
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("process channel %s: %w", ch.DataID, err)
+					cancel()
+				})
+				return
+			}
+			results[i] = result
+		}(i, ch)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if measurement != nil {
+		for _, result := range results {
+			result.EdgeUUID = measurement.EdgeUUID
+		}
+	}
+	return results, nil
+}
+
+// processChannel fetches, sanitizes and analyzes the data points of the channel of the measurement.
+// measurement is required only if the channel has a window.
+func (h *Handler) processChannel(ctx context.Context, measurementUUID string, ch *Channel, measurement *intdash.Measurement) (*AnalysisResult, error) {
+	start := time.Now()
+	ctx = withLogAttrs(ctx, "data_id", ch.DataID)
+	if analysis, ok := h.newStreamingAnalysis(ch); ok {
+		return h.streamChannel(ctx, measurementUUID, ch, measurement, analysis, start)
+	}
+	dataPoints, partialReason, err := h.fetchChannel(ctx, measurementUUID, ch, measurement)
+	if err != nil {
+		return nil, err
+	}
+	ctx, end := h.startStage(ctx, "analyze")
+	dataPoints, quality := analyze.SanitizeDataPoints(dataPoints, ch.SentinelValues)
+	if quality.Dropped > 0 {
+		h.logger().InfoContext(ctx, "Dropped invalid data points", "dropped", quality.Dropped, "total", quality.Total)
+	}
+	result := &AnalysisResult{
+		MeasurementUUID: measurementUUID,
+		DataID:          ch.DataID,
+		Unit:            ch.Unit,
+		DataPoints:      dataPoints,
+		DataQuality:     quality,
+		Partial:         partialReason != "",
+		PartialReason:   partialReason,
+	}
+	err = ch.analyze(ctx, result)
+	end(err)
+	if err != nil {
+		return nil, err
+	}
+	result.ProcessedAt = h.now().UTC()
+	result.ProcessingTimeMillis = time.Since(start).Milliseconds()
+	if len(ch.AlertRules) > 0 {
+		result.Alerts = ch.firedAlerts(result.Statistics)
+	}
+	return result, nil
+}
+
+// fetchChannel fetches the data points of the channel of the measurement.
+// If the fetch fails mid-way and partial results are allowed, the data points fetched until then are returned
+// with the error as the reason of the partial result.
+func (h *Handler) fetchChannel(ctx context.Context, measurementUUID string, ch *Channel, measurement *intdash.Measurement) ([]intdash.DataPoint, string, error) {
+	fetchCtx, end := h.startStage(ctx, "fetch")
+	tr := h.channelTimeRange(fetchCtx, ch, measurement)
+	dataPoints, err := h.IntdashAPI.FetchFloat64DataPoints(fetchCtx, measurementUUID, ch.DataID, tr)
+	end(err)
+	if err != nil {
+		// The rest of the processing cannot be done if the whole processing has been canceled.
+		if !h.AllowPartialResults || len(dataPoints) == 0 || ctx.Err() != nil {
+			return nil, "", fmt.Errorf("%w: fetch data points: %w", ErrFetchFailed, err)
+		}
+		h.logger().WarnContext(ctx, "Analyzing the data points fetched before the error", "data_points", len(dataPoints), "error", err)
+		return dataPoints, err.Error(), nil
+	}
+	return dataPoints, "", nil
+}
+
+// channelTimeRange returns the time range of the data points of the channel, the whole measurement unless it has a window.
+func (h *Handler) channelTimeRange(ctx context.Context, ch *Channel, measurement *intdash.Measurement) intdash.TimeRange {
+	if ch.Window == nil {
+		return intdash.TimeRange{}
+	}
+	tr := ch.Window.Range(measurement)
+	h.logger().InfoContext(ctx, "Fetching data points in time window", "window", ch.Window.String(), "start", tr.Start)
+	return tr
+}
+
+// streamingAnalysis is the analysis of the data points of a channel as they are fetched, without holding them.
+type streamingAnalysis struct {
+	sanitizer    analyze.DataPointSanitizer
+	analyzers    []analyze.Analyzer
+	accumulators []analyze.Accumulator
+	// statistics accumulates the statistics of the alert rules if no analyzer is the statistics analyzer.
+	statistics *analyze.StatisticsAccumulator
+}
+
+// newStreamingAnalysis returns the streaming analysis of the channel, if every analyzer of the channel can accumulate
+// with its configuration and the data points are not needed after the analysis, by the result archives
+// or the markers written back.
+func (h *Handler) newStreamingAnalysis(ch *Channel) (*streamingAnalysis, bool) {
+	if len(h.ResultArchives) > 0 || (h.WriteBack != nil && h.WriteBack.Markers) {
+		return nil, false
+	}
+	analysis := &streamingAnalysis{
+		sanitizer: analyze.DataPointSanitizer{Sentinels: ch.SentinelValues},
+		analyzers: ch.Analyzers,
+	}
+	if len(analysis.analyzers) == 0 {
+		analysis.analyzers = []analyze.Analyzer{&analyze.StatisticsAnalyzer{Extra: analyze.AlertStatistics(ch.AlertRules)}}
+	}
+	hasStatistics := false
+	for _, analyzer := range analysis.analyzers {
+		streaming, ok := analyzer.(analyze.StreamingAnalyzer)
+		if !ok {
+			return nil, false
+		}
+		acc, ok := streaming.NewAccumulator()
+		if !ok {
+			return nil, false
+		}
+		_, isStatistics := analyzer.(*analyze.StatisticsAnalyzer)
+		hasStatistics = hasStatistics || isStatistics
+		analysis.accumulators = append(analysis.accumulators, acc)
+	}
+	if !hasStatistics {
+		// The percentiles of the alert rules need all the values.
+		if _, ok := (&analyze.StatisticsAnalyzer{Extra: analyze.AlertStatistics(ch.AlertRules)}).NewAccumulator(); !ok {
+			return nil, false
+		}
+		analysis.statistics = &analyze.StatisticsAccumulator{}
+	}
+	return analysis, true
+}
+
+// add analyzes the data point if it is valid.
+func (a *streamingAnalysis) add(dp intdash.DataPoint) {
+	if !a.sanitizer.Valid(dp) {
+		return
+	}
+	for _, acc := range a.accumulators {
+		acc.Add(dp)
+	}
+	if a.statistics != nil {
+		a.statistics.Add(dp)
+	}
+}
+
+// finish stores the results of the data points added in result.
+func (a *streamingAnalysis) finish(result *AnalysisResult) {
+	result.DataQuality = a.sanitizer.Quality()
+	for i, analyzer := range a.analyzers {
+		r := a.accumulators[i].Result()
+		if stats, ok := r.(*analyze.Statistics); ok {
+			result.Statistics = stats
+		}
+		result.Analyses = append(result.Analyses, &analyze.Analysis{Analyzer: analyzer.Name(), Result: r})
+	}
+	if result.Statistics == nil {
+		result.Statistics = a.statistics.Statistics()
+	}
+}
+
+// streamChannel analyzes the data points of the channel of the measurement as they are fetched,
+// so that a large measurement is analyzed in the memory of a data point at a time. The result has no data points.
+func (h *Handler) streamChannel(ctx context.Context, measurementUUID string, ch *Channel, measurement *intdash.Measurement, analysis *streamingAnalysis, start time.Time) (*AnalysisResult, error) {
+	fetchCtx, end := h.startStage(ctx, "fetch")
+	tr := h.channelTimeRange(fetchCtx, ch, measurement)
+	h.logger().DebugContext(fetchCtx, "Analyzing data points as they are fetched")
+	err := streamFloat64DataPoints(fetchCtx, h.IntdashAPI, measurementUUID, ch.DataID, tr, func(dp intdash.DataPoint) error {
+		analysis.add(dp)
+		return nil
+	})
+	end(err)
+	result := &AnalysisResult{
+		MeasurementUUID: measurementUUID,
+		DataID:          ch.DataID,
+		Unit:            ch.Unit,
+	}
+	if err != nil {
+		fetched := analysis.sanitizer.Quality().Total
+		// The rest of the processing cannot be done if the whole processing has been canceled.
+		if !h.AllowPartialResults || fetched == 0 || ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: fetch data points: %w", ErrFetchFailed, err)
+		}
+		h.logger().WarnContext(ctx, "Analyzing the data points fetched before the error", "data_points", fetched, "error", err)
+		result.Partial, result.PartialReason = true, err.Error()
+	}
+
+	ctx, end = h.startStage(ctx, "analyze")
+	analysis.finish(result)
+	end(nil)
+	if quality := result.DataQuality; quality != nil && quality.Dropped > 0 {
+		h.logger().InfoContext(ctx, "Dropped invalid data points", "dropped", quality.Dropped, "total", quality.Total)
+	}
+	result.ProcessedAt = h.now().UTC()
+	result.ProcessingTimeMillis = time.Since(start).Milliseconds()
+	if len(ch.AlertRules) > 0 {
+		result.Alerts = ch.firedAlerts(result.Statistics)
+	}
+	return result, nil
+}
+
+// streamFloat64DataPoints calls fn with each data point of the data ID of the measurement as it is fetched by api,
+// or after fetching all of them if api cannot stream them, e.g. a stub.
+func streamFloat64DataPoints(ctx context.Context, api IntdashAPI, measurementUUID, dataID string, tr intdash.TimeRange, fn func(intdash.DataPoint) error) error {
+	if streamAPI, ok := api.(IntdashStreamAPI); ok {
+		return streamAPI.StreamFloat64DataPoints(ctx, measurementUUID, dataID, tr, fn)
+	}
+	dataPoints, err := api.FetchFloat64DataPoints(ctx, measurementUUID, dataID, tr)
+	for _, dp := range dataPoints {
+		if err := fn(dp); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// needsMeasurement reports whether the measurement metadata is needed to process a finished measurement,
+// for the notification, the windows of the channels or the edge dimension of the metrics.
+func (h *Handler) needsMeasurement() bool {
+	if h.IncludeMeasurement {
+		return true
+	}
+	for _, archive := range h.ResultArchives {
+		switch archive.(type) {
+		case *CloudWatchMetricsPublisher, *EMFMetricsPublisher:
+			return true
+		}
+	}
+	for _, ch := range h.Channels {
+		if ch.Window != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// analyze runs the analyzers of the channel on the data points and stores their results in result.
+// The statistics are taken from the statistics analyzer, or calculated if it is not configured,
+// because the alert rules, the result store and the metrics depend on them.
+func (ch *Channel) analyze(ctx context.Context, result *AnalysisResult) error {
+	analyzers := ch.Analyzers
+	if len(analyzers) == 0 {
+		analyzers = []analyze.Analyzer{&analyze.StatisticsAnalyzer{Extra: analyze.AlertStatistics(ch.AlertRules)}}
+	}
+	for _, analyzer := range analyzers {
+		r, err := analyzer.Analyze(ctx, result.DataPoints)
+		if err != nil {
+			return fmt.Errorf("analyze with %s: %w", analyzer.Name(), err)
+		}
+		if stats, ok := r.(*analyze.Statistics); ok {
+			result.Statistics = stats
+		}
+		result.Analyses = append(result.Analyses, &analyze.Analysis{Analyzer: analyzer.Name(), Result: r})
+	}
+	if result.Statistics == nil {
+		result.Statistics = analyze.CalculateStatistics(result.DataPoints, analyze.AlertStatistics(ch.AlertRules))
+	}
+	return nil
+}
+
+// firedAlerts returns the names of the alert rules of the channel that fire for the given statistics.
+func (ch *Channel) firedAlerts(stats *analyze.Statistics) []string {
+	var fired []string
+	for _, rule := range ch.AlertRules {
+		if rule.Fires(stats) {
+			fired = append(fired, rule.String())
+		}
+	}
+	return fired
+}
+
+// hasAlertRules reports whether any channel has alert rules.
+func (h *Handler) hasAlertRules() bool {
+	for _, ch := range h.Channels {
+		if len(ch.AlertRules) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationAlerts returns the fired alert rules of all results.
+// If there are multiple results, the rules are prefixed with the data IDs, e.g. "float64:speed: max > 120".
+func notificationAlerts(results []*AnalysisResult) []string {
+	var alerts []string
+	for _, result := range results {
+		for _, alert := range result.Alerts {
+			if len(results) > 1 {
+				alert = result.DataID + ": " + alert
+			}
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
@@ -0,0 +1,234 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"hello-world/pkg/intdash"
+	"hello-world/pkg/notify"
+)
+
+const (
+	// DefaultCircuitFailureThreshold is the default number of consecutive failures that opens a circuit.
+	DefaultCircuitFailureThreshold = 5
+	// DefaultCircuitOpenTimeout is the default time a circuit stays open before a probe is let through.
+	DefaultCircuitOpenTimeout = 30 * time.Second
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker fails the calls to a downstream service fast while it is failing, instead of every call
+// waiting for its timeout. The circuit opens after FailureThreshold consecutive failures, and the calls are rejected
+// with ErrCircuitOpen. After OpenTimeout, the circuit is half-open and a call is let through as a probe:
+// the circuit closes if it succeeds, or opens again if it fails.
+//
+// The state is kept in memory, so on Lambda it is of each execution environment.
+type CircuitBreaker struct {
+	// Name identifies the service in the logs and the errors, e.g. "intdash".
+	Name string
+	// FailureThreshold defaults to DefaultCircuitFailureThreshold.
+	FailureThreshold int
+	// OpenTimeout defaults to DefaultCircuitOpenTimeout.
+	OpenTimeout time.Duration
+	// IsFailure reports whether the error of a call is a failure of the service. Defaults to any error
+	// but context.Canceled.
+	IsFailure func(err error) bool
+	// Clock returns the current time. Defaults to time.Now.
+	Clock func() time.Time
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// Do calls fn unless the circuit is open, and records its result.
+func (b *CircuitBreaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+	err = fn(ctx)
+	b.record(ctx, probe, err)
+	return err
+}
+
+// allow returns ErrCircuitOpen if the call is to be rejected, and whether the call is the probe of a half-open circuit.
+func (b *CircuitBreaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case circuitOpen:
+		retryIn := b.openTimeout() - b.now().Sub(b.openedAt)
+		if retryIn > 0 {
+			return false, fmt.Errorf("%w: %s, retry in %s", ErrCircuitOpen, b.Name, retryIn.Round(time.Second))
+		}
+		b.state = circuitHalfOpen
+		fallthrough
+	case circuitHalfOpen:
+		// Only a probe is let through at a time.
+		if b.probing {
+			return false, fmt.Errorf("%w: %s, probing", ErrCircuitOpen, b.Name)
+		}
+		b.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// record records the result of a call let through. Only the probe ends the probing, so that a call let through
+// before the circuit opened does not let another probe through.
+func (b *CircuitBreaker) record(ctx context.Context, probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if err == nil || !b.isFailure(err) {
+		if b.state != circuitClosed {
+			slog.InfoContext(ctx, "Closed circuit", "circuit", b.Name)
+		}
+		b.state, b.failures = circuitClosed, 0
+		return
+	}
+	b.failures++
+	if probe || b.failures >= b.failureThreshold() {
+		if b.state != circuitOpen {
+			slog.WarnContext(ctx, "Opened circuit", "circuit", b.Name, "failures", b.failures, "error", err)
+		}
+		b.state, b.openedAt = circuitOpen, b.now()
+	}
+}
+
+func (b *CircuitBreaker) isFailure(err error) bool {
+	if b.IsFailure != nil {
+		return b.IsFailure(err)
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+func (b *CircuitBreaker) failureThreshold() int {
+	if b.FailureThreshold > 0 {
+		return b.FailureThreshold
+	}
+	return DefaultCircuitFailureThreshold
+}
+
+func (b *CircuitBreaker) openTimeout() time.Duration {
+	if b.OpenTimeout > 0 {
+		return b.OpenTimeout
+	}
+	return DefaultCircuitOpenTimeout
+}
+
+func (b *CircuitBreaker) now() time.Time {
+	if b.Clock != nil {
+		return b.Clock()
+	}
+	return time.Now()
+}
+
+// isIntdashFailure reports whether the error of a call to the intdash API is a failure of the service.
+// The client errors, such as a measurement not found, are not, except for too many requests.
+func isIntdashFailure(err error) bool {
+	var statusErr *intdash.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode < 500 && statusErr.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// CircuitBreakingIntdashAPI is an IntdashAPI behind a circuit breaker.
+type CircuitBreakingIntdashAPI struct {
+	IntdashAPI
+	Breaker *CircuitBreaker
+}
+
+// FetchFloat64DataPoints fetches the data points unless the circuit is open.
+func (a *CircuitBreakingIntdashAPI) FetchFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr intdash.TimeRange) (dataPoints []intdash.DataPoint, err error) {
+	err = a.Breaker.Do(ctx, func(ctx context.Context) (err error) {
+		dataPoints, err = a.IntdashAPI.FetchFloat64DataPoints(ctx, measurementUUID, dataID, tr)
+		return err
+	})
+	return dataPoints, err
+}
+
+// StreamFloat64DataPoints streams the data points unless the circuit is open.
+func (a *CircuitBreakingIntdashAPI) StreamFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr intdash.TimeRange, fn func(intdash.DataPoint) error) error {
+	return a.Breaker.Do(ctx, func(ctx context.Context) error {
+		return streamFloat64DataPoints(ctx, a.IntdashAPI, measurementUUID, dataID, tr, fn)
+	})
+}
+
+// FetchMeasurement fetches the measurement unless the circuit is open.
+func (a *CircuitBreakingIntdashAPI) FetchMeasurement(ctx context.Context, measurementUUID string) (measurement *intdash.Measurement, err error) {
+	err = a.Breaker.Do(ctx, func(ctx context.Context) (err error) {
+		measurement, err = a.IntdashAPI.FetchMeasurement(ctx, measurementUUID)
+		return err
+	})
+	return measurement, err
+}
+
+// Check checks the intdash API regardless of the circuit, if it can be checked.
+func (a *CircuitBreakingIntdashAPI) Check(ctx context.Context) error {
+	checker, ok := a.IntdashAPI.(interface {
+		Check(ctx context.Context) error
+	})
+	if !ok {
+		return errCheckSkipped
+	}
+	return checker.Check(ctx)
+}
+
+// CircuitBreakingNotifier is a notifier behind a circuit breaker.
+type CircuitBreakingNotifier struct {
+	notify.Notifier
+	Breaker *CircuitBreaker
+}
+
+// Notify sends the notification unless the circuit is open.
+func (n *CircuitBreakingNotifier) Notify(ctx context.Context, notification *notify.Notification) error {
+	return n.Breaker.Do(ctx, func(ctx context.Context) error {
+		return n.Notifier.Notify(ctx, notification)
+	})
+}
+
+// Check checks the destination regardless of the circuit, if it can be checked.
+func (n *CircuitBreakingNotifier) Check(ctx context.Context) error {
+	checker, ok := n.Notifier.(notify.Checker)
+	if !ok {
+		return errCheckSkipped
+	}
+	return checker.Check(ctx)
+}
+
+// useCircuitBreakers puts the intdash API and each notifier of the handler and of its routes
+// behind a circuit breaker of its own, made by newBreaker.
+func (h *Handler) useCircuitBreakers(newBreaker func(name string) *CircuitBreaker) {
+	if h.IntdashAPI != nil {
+		b := newBreaker("intdash")
+		b.IsFailure = isIntdashFailure
+		h.IntdashAPI = &CircuitBreakingIntdashAPI{IntdashAPI: h.IntdashAPI, Breaker: b}
+	}
+	wrap := func(notifiers []notify.Notifier) {
+		for i, n := range notifiers {
+			notifiers[i] = &CircuitBreakingNotifier{Notifier: n, Breaker: newBreaker("notifier:" + n.Name())}
+		}
+	}
+	wrap(h.Notifiers)
+	for _, r := range h.Routes {
+		wrap(r.Notifiers)
+	}
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerStaleCallDoesNotEndProbe(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	b := &CircuitBreaker{Name: "intdash", FailureThreshold: 1, OpenTimeout: time.Minute, Clock: func() time.Time { return now }}
+	ctx := context.Background()
+
+	// A call is let through before the circuit opens, and fails after the probe is let through.
+	stale, err := b.allow()
+	if err != nil || stale {
+		t.Fatalf("allow() of the closed circuit = %t, %v, want no probe", stale, err)
+	}
+	if err := b.Do(ctx, func(ctx context.Context) error { return errUnavailable }); !errors.Is(err, errUnavailable) {
+		t.Fatalf("Do() error = %v, want %v", err, errUnavailable)
+	}
+	now = now.Add(time.Minute)
+	probe, err := b.allow()
+	if err != nil || !probe {
+		t.Fatalf("allow() of the half-open circuit = %t, %v, want a probe", probe, err)
+	}
+	b.record(ctx, stale, errUnavailable)
+
+	// The probe is still in flight, so no other call is let through.
+	now = now.Add(time.Minute)
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow() while probing error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	b.record(ctx, probe, nil)
+	if b.state != circuitClosed || b.probing {
+		t.Errorf("state = %v, probing = %t after the probe succeeded, want closed", b.state, b.probing)
+	}
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+const (
+	// DefaultMetricsNamespace is the default CloudWatch namespace of the published metrics.
+	DefaultMetricsNamespace = "IntdashWebhook"
+)
+
+type CloudWatchPutMetricDataAPI interface {
+	PutMetricData(ctx context.Context, params *cloudwatch.PutMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error)
+}
+
+// metric is a metric value published for an analysis result.
+type metric struct {
+	Name  string
+	Value float64
+	Unit  types.StandardUnit
+}
+
+// resultMetrics returns the metrics published for the given result.
+// The statistics that are NaN or Inf, e.g. the average of a channel without valid data points, are skipped,
+// because CloudWatch rejects them and JSON cannot encode them.
+func resultMetrics(result *AnalysisResult) []metric {
+	var metrics []metric
+	for _, m := range []metric{
+		{Name: "Count", Value: float64(result.Statistics.Count), Unit: types.StandardUnitCount},
+		{Name: "Average", Value: result.Statistics.Average, Unit: types.StandardUnitNone},
+		{Name: "UnbiasedVariance", Value: result.Statistics.UnbiasedVariance, Unit: types.StandardUnitNone},
+		{Name: "ProcessingTime", Value: float64(result.ProcessingTimeMillis), Unit: types.StandardUnitMilliseconds},
+	} {
+		if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+			continue
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// errorMetrics returns the metrics published for an error, with the dimension "ErrorType".
+func errorMetrics(errorType string) ([][2]string, []metric) {
+	return [][2]string{{"ErrorType", errorType}}, []metric{{Name: "Errors", Value: 1, Unit: types.StandardUnitCount}}
+}
+
+// metricDimensions returns the dimensions of the metrics published for the given result.
+func metricDimensions(result *AnalysisResult) [][2]string {
+	dims := [][2]string{{"MeasurementUUID", result.MeasurementUUID}}
+	if result.DataID != "" {
+		dims = append(dims, [2]string{"DataID", result.DataID})
+	}
+	if result.EdgeUUID != "" {
+		dims = append(dims, [2]string{"EdgeUUID", result.EdgeUUID})
+	}
+	return dims
+}
+
+// CloudWatchMetricsPublisher is a ResultArchive that publishes the statistics as CloudWatch metrics with PutMetricData.
+// It is also an ErrorCounter.
+type CloudWatchMetricsPublisher struct {
+	CloudWatchPutMetricDataAPI CloudWatchPutMetricDataAPI
+	// Namespace is the metric namespace. Defaults to DefaultMetricsNamespace.
+	Namespace string
+}
+
+// Archive publishes the statistics of the result.
+func (p *CloudWatchMetricsPublisher) Archive(ctx context.Context, result *AnalysisResult) error {
+	return p.put(ctx, metricDimensions(result), resultMetrics(result), result.ProcessedAt)
+}
+
+// CountError publishes the "Errors" metric of the error type.
+func (p *CloudWatchMetricsPublisher) CountError(ctx context.Context, errorType string) error {
+	dims, metrics := errorMetrics(errorType)
+	return p.put(ctx, dims, metrics, time.Now())
+}
+
+// put publishes the metrics with the dimensions.
+func (p *CloudWatchMetricsPublisher) put(ctx context.Context, dimensions [][2]string, metrics []metric, timestamp time.Time) error {
+	namespace := p.Namespace
+	if namespace == "" {
+		namespace = DefaultMetricsNamespace
+	}
+	var dims []types.Dimension
+	for _, d := range dimensions {
+		dims = append(dims, types.Dimension{Name: aws.String(d[0]), Value: aws.String(d[1])})
+	}
+	var data []types.MetricDatum
+	for _, m := range metrics {
+		data = append(data, types.MetricDatum{
+			MetricName: aws.String(m.Name),
+			Dimensions: dims,
+			Timestamp:  aws.Time(timestamp),
+			Value:      aws.Float64(m.Value),
+			Unit:       m.Unit,
+		})
+	}
+
+	_, err := p.CloudWatchPutMetricDataAPI.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
+		Namespace:  aws.String(namespace),
+		MetricData: data,
+	})
+	if err != nil {
+		return fmt.Errorf("put metric data to %s: %w", namespace, err)
+	}
+	slog.InfoContext(ctx, "Published metrics to CloudWatch", "metrics", len(data), "namespace", namespace)
+	return nil
+}
+
+// EMFMetricsPublisher is a ResultArchive that writes the statistics as CloudWatch embedded metric format (EMF) logs.
+// It is also an ErrorCounter.
+// In Lambda, CloudWatch Logs extracts the metrics from the function logs without any API calls.
+type EMFMetricsPublisher struct {
+	// Namespace is the metric namespace. Defaults to DefaultMetricsNamespace.
+	Namespace string
+	// Writer is where the EMF logs are written. Defaults to os.Stdout.
+	Writer io.Writer
+}
+
+// emfMetricDirective is the metric directive of an EMF log.
+type emfMetricDirective struct {
+	Namespace  string                `json:"Namespace"`
+	Dimensions [][]string            `json:"Dimensions"`
+	Metrics    []emfMetricDefinition `json:"Metrics"`
+}
+
+type emfMetricDefinition struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// Archive writes an EMF log of the statistics of the result.
+func (p *EMFMetricsPublisher) Archive(ctx context.Context, result *AnalysisResult) error {
+	return p.write(metricDimensions(result), resultMetrics(result), result.ProcessedAt)
+}
+
+// CountError writes an EMF log of the "Errors" metric of the error type.
+func (p *EMFMetricsPublisher) CountError(ctx context.Context, errorType string) error {
+	dims, metrics := errorMetrics(errorType)
+	return p.write(dims, metrics, time.Now())
+}
+
+// write writes an EMF log of the metrics with the dimensions.
+func (p *EMFMetricsPublisher) write(dimensions [][2]string, metrics []metric, timestamp time.Time) error {
+	namespace := p.Namespace
+	if namespace == "" {
+		namespace = DefaultMetricsNamespace
+	}
+	w := p.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+
+	doc := map[string]interface{}{}
+	directive := emfMetricDirective{Namespace: namespace}
+	var dimNames []string
+	for _, d := range dimensions {
+		dimNames = append(dimNames, d[0])
+		doc[d[0]] = d[1]
+	}
+	directive.Dimensions = [][]string{dimNames}
+	for _, m := range metrics {
+		directive.Metrics = append(directive.Metrics, emfMetricDefinition{Name: m.Name, Unit: string(m.Unit)})
+		doc[m.Name] = m.Value
+	}
+	doc["_aws"] = map[string]interface{}{
+		"Timestamp":         timestamp.UnixNano() / int64(1000000),
+		"CloudWatchMetrics": []emfMetricDirective{directive},
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("marshal EMF log: %w", err)
+	}
+	if _, err := fmt.Fprintln(w, string(b)); err != nil {
+		return fmt.Errorf("write EMF log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"hello-world/pkg/analyze"
+)
+
+func TestEMFMetricsPublisherSkipsNonFiniteStatistics(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		stats       *analyze.Statistics
+		wantAverage bool
+	}{
+		{name: "finite", stats: &analyze.Statistics{Count: 2, Average: 1.5, UnbiasedVariance: 0.5}, wantAverage: true},
+		{name: "empty channel", stats: &analyze.Statistics{Average: math.NaN(), UnbiasedVariance: math.NaN()}},
+		{name: "overflow", stats: &analyze.Statistics{Count: 2, Average: math.Inf(1), UnbiasedVariance: math.Inf(1)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &EMFMetricsPublisher{Writer: &buf}
+			result := &AnalysisResult{
+				MeasurementUUID: "00000000-0000-0000-0000-000000000000",
+				DataID:          "float64:speed",
+				ProcessedAt:     time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+				Statistics:      tt.stats,
+			}
+			if err := p.Archive(context.Background(), result); err != nil {
+				t.Fatalf("Archive() error = %v", err)
+			}
+			var doc map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+				t.Fatalf("unmarshal EMF log %q: %v", buf.String(), err)
+			}
+			if _, ok := doc["Average"]; ok != tt.wantAverage {
+				t.Errorf("Average in EMF log = %t, want %t", ok, tt.wantAverage)
+			}
+			if _, ok := doc["Count"]; !ok {
+				t.Errorf("Count is not in EMF log %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestMetricDimensions(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		result *AnalysisResult
+		want   [][2]string
+	}{
+		{
+			name:   "measurement",
+			result: &AnalysisResult{MeasurementUUID: "m"},
+			want:   [][2]string{{"MeasurementUUID", "m"}},
+		},
+		{
+			name:   "channel of edge",
+			result: &AnalysisResult{MeasurementUUID: "m", DataID: "float64:speed", EdgeUUID: "e"},
+			want:   [][2]string{{"MeasurementUUID", "m"}, {"DataID", "float64:speed"}, {"EdgeUUID", "e"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metricDimensions(tt.result); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("metricDimensions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
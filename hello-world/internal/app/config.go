@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+type SSMAPI interface {
+	GetParametersByPath(ctx context.Context, input *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+}
+
+// Config looks up configuration values by name, e.g. "SNS_TOPIC_ARN".
+// Values loaded from SSM Parameter Store take precedence over environment variables.
+type Config struct {
+	params map[string]string
+}
+
+// Get returns the value of the given configuration name.
+// It falls back to the environment variable of the same name when the parameter is not present.
+func (c *Config) Get(name string) string {
+	if v, ok := c.params[name]; ok {
+		return v
+	}
+	return os.Getenv(name)
+}
+
+// With returns a Config in which the given values take precedence over c.
+func (c *Config) With(overrides map[string]string) *Config {
+	params := make(map[string]string, len(c.params)+len(overrides))
+	for k, v := range c.params {
+		params[k] = v
+	}
+	for k, v := range overrides {
+		params[k] = v
+	}
+	return &Config{params: params}
+}
+
+// loadConfig loads all parameters under the given path prefix from SSM Parameter Store.
+// SecureString parameters are decrypted. The parameter "<prefix>/SNS_TOPIC_ARN" is
+// available as Get("SNS_TOPIC_ARN").
+// If prefix is empty, only environment variables are used.
+func loadConfig(ctx context.Context, ssmAPI SSMAPI, prefix string) (*Config, error) {
+	cfg := &Config{params: map[string]string{}}
+	if prefix == "" {
+		return cfg, nil
+	}
+
+	prefix = "/" + strings.Trim(prefix, "/")
+	var nextToken *string
+	for {
+		out, err := ssmAPI.GetParametersByPath(ctx, &ssm.GetParametersByPathInput{
+			Path:           aws.String(prefix),
+			Recursive:      aws.Bool(true),
+			WithDecryption: aws.Bool(true),
+			NextToken:      nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get parameters by path %q: %w", prefix, err)
+		}
+		for _, p := range out.Parameters {
+			name := strings.TrimPrefix(aws.ToString(p.Name), prefix+"/")
+			cfg.params[name] = aws.ToString(p.Value)
+		}
+		if out.NextToken == nil {
+			break
+		}
+		nextToken = out.NextToken
+	}
+	return cfg, nil
+}
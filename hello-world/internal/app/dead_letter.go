@@ -0,0 +1,160 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+const (
+	// DefaultDeadLetterPrefix is the default key prefix of the dead letters in S3.
+	DefaultDeadLetterPrefix = "dead-letters/"
+	// DefaultDeadLetterMaxReceiveCount is the default number of the receives of a queued event
+	// at which its failure is terminal.
+	DefaultDeadLetterMaxReceiveCount = 3
+)
+
+// Sources of DeadLetter.
+const (
+	DeadLetterSourceWebhook = "webhook"
+	DeadLetterSourceSQS     = "sqs"
+)
+
+// DeadLetterQueue receives the events that failed terminally with their failures,
+// so that they can be inspected and replayed with the full context.
+type DeadLetterQueue interface {
+	SendDeadLetter(ctx context.Context, letter *DeadLetter) error
+}
+
+// DeadLetter is an event that failed terminally. It is a RecordedEvent, so that it can be replayed.
+type DeadLetter struct {
+	RecordedEvent
+	DeliveryID string `json:"delivery_id,omitempty"`
+	// Source is where the event failed, DeadLetterSourceWebhook or DeadLetterSourceSQS.
+	Source   string    `json:"source"`
+	FailedAt time.Time `json:"failed_at"`
+	// ErrorCode is the error code of the failure, e.g. "fetch_failed".
+	ErrorCode string `json:"error_code"`
+	Error     string `json:"error"`
+	// Attempts is the number of the attempts, if known.
+	Attempts int `json:"attempts,omitempty"`
+	// Message is the queue message that could not be decoded into an event, if any.
+	Message string `json:"message,omitempty"`
+}
+
+// S3DeadLetterQueue is a DeadLetterQueue that writes each letter to S3 as a JSON object
+// with the key "<prefix><yyyy>/<mm>/<dd>/<failed_at>_<delivery_id>.json".
+// The objects can be replayed with the replay mode.
+type S3DeadLetterQueue struct {
+	S3PutObjectAPI S3PutObjectAPI
+	Bucket         string
+	// Prefix is the key prefix of the letters. Defaults to DefaultDeadLetterPrefix.
+	Prefix string
+}
+
+// SendDeadLetter puts the letter as JSON.
+func (q *S3DeadLetterQueue) SendDeadLetter(ctx context.Context, letter *DeadLetter) error {
+	prefix := q.Prefix
+	if prefix == "" {
+		prefix = DefaultDeadLetterPrefix
+	}
+	key := prefix + letter.FailedAt.UTC().Format("2006/01/02/20060102T150405.000000000Z")
+	if letter.DeliveryID != "" {
+		key += "_" + executionName(letter.DeliveryID)
+	}
+	key += ".json"
+	b, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("marshal dead letter: %w", err)
+	}
+	_, err = q.S3PutObjectAPI.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(q.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(b),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("put object s3://%s/%s: %w", q.Bucket, key, err)
+	}
+	slog.InfoContext(ctx, "Sent dead letter", "location", fmt.Sprintf("s3://%s/%s", q.Bucket, key))
+	return nil
+}
+
+// SQSDeadLetterQueue is a DeadLetterQueue that sends each letter to an SQS queue as JSON.
+type SQSDeadLetterQueue struct {
+	SQSSendMessageAPI SQSSendMessageAPI
+	QueueURL          string
+}
+
+// SendDeadLetter sends the letter as JSON.
+func (q *SQSDeadLetterQueue) SendDeadLetter(ctx context.Context, letter *DeadLetter) error {
+	b, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("marshal dead letter: %w", err)
+	}
+	out, err := q.SQSSendMessageAPI.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.QueueURL),
+		MessageBody: aws.String(string(b)),
+	})
+	if err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	slog.InfoContext(ctx, "Sent dead letter", "message_id", aws.ToString(out.MessageId))
+	return nil
+}
+
+// sendDeadLetter sends the letter of the failure to h.DeadLetters, and reports whether it has been sent.
+// A failure to send is only logged.
+func (h *Handler) sendDeadLetter(ctx context.Context, letter *DeadLetter, failure error) bool {
+	if h.DeadLetters == nil {
+		return false
+	}
+	letter.FailedAt = h.now()
+	letter.ErrorCode = classifyError(failure).code
+	letter.Error = failure.Error()
+	// ctx may have been canceled by the deadline, which is the failure to be sent.
+	ctx, cancel := detachedContext(ctx, DefaultDeadlineMargin/2)
+	defer cancel()
+	if err := h.DeadLetters.SendDeadLetter(ctx, letter); err != nil {
+		h.logger().ErrorContext(ctx, "Failed to send dead letter", "error", err)
+		return false
+	}
+	return true
+}
+
+// deadLetterSQSMessage sends the letter of the failed message if it is the last receive of the message,
+// and reports whether it has been sent, in which case the message is not to be retried.
+func (h *Handler) deadLetterSQSMessage(ctx context.Context, record events.SQSMessage, failure error) bool {
+	if h.DeadLetters == nil {
+		return false
+	}
+	maxReceiveCount := h.DeadLetterMaxReceiveCount
+	if maxReceiveCount <= 0 {
+		maxReceiveCount = DefaultDeadLetterMaxReceiveCount
+	}
+	count, _ := strconv.Atoi(record.Attributes["ApproximateReceiveCount"])
+	if count < maxReceiveCount {
+		return false
+	}
+	letter := &DeadLetter{
+		RecordedEvent: RecordedEvent{RequestID: record.MessageId, ReceivedAt: h.now()},
+		Source:        DeadLetterSourceSQS,
+		Attempts:      count,
+	}
+	var event QueuedEvent
+	if err := json.Unmarshal([]byte(record.Body), &event); err != nil {
+		letter.Message = record.Body
+	} else {
+		letter.DeliveryID, letter.Event = event.DeliveryID, &event
+	}
+	return h.sendDeadLetter(ctx, letter, failure)
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultDeadlineMargin is the default time reserved before the Lambda deadline to respond and clean up.
+const DefaultDeadlineMargin = 2 * time.Second
+
+// DefaultStageTimeShares are the default shares of the remaining processing time given to each stage.
+// For example, "fetch" may take 60% of the time remaining when it starts, leaving the rest for "analyze" and "notify".
+// The stages not listed may take all the remaining time.
+var DefaultStageTimeShares = map[string]float64{
+	"signature":  0.2,
+	"fetch":      0.6,
+	"analyze":    0.5,
+	"archive":    0.5,
+	"write_back": 0.5,
+	"notify":     1,
+}
+
+// withProcessingDeadline returns a context whose deadline is the Lambda deadline minus h.DeadlineMargin,
+// so that the function can respond before it is killed. ctx is returned as is if it has no deadline.
+func (h *Handler) withProcessingDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	margin := h.DeadlineMargin
+	if margin <= 0 {
+		margin = DefaultDeadlineMargin
+	}
+	return context.WithDeadline(ctx, deadline.Add(-margin))
+}
+
+// withStageTimeout returns a context that times out after the share of the stage of the remaining processing time.
+func (h *Handler) withStageTimeout(ctx context.Context, stage string) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	shares := h.StageTimeShares
+	if shares == nil {
+		shares = DefaultStageTimeShares
+	}
+	share, ok := shares[stage]
+	if !ok || share >= 1 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(float64(time.Until(deadline))*share))
+}
+
+// detachedContext returns a context that is not canceled with ctx, with the given timeout,
+// to clean up after ctx has been canceled, e.g. by the processing deadline.
+func detachedContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), timeout)
+}
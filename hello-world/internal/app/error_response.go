@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+
+	"hello-world/pkg/webhook"
+)
+
+// Error codes of ErrorResponseBody.
+const (
+	ErrorCodeForbiddenSource  = "forbidden_source"
+	ErrorCodeBodyTooLarge     = "body_too_large"
+	ErrorCodeInvalidBody      = "invalid_body"
+	ErrorCodeInvalidSignature = "invalid_signature"
+	ErrorCodeInvalidTimestamp = "invalid_timestamp"
+	ErrorCodeUnsupportedEvent = "unsupported_event"
+	ErrorCodeEnqueueFailed    = "enqueue_failed"
+	ErrorCodeCircuitOpen      = "circuit_open"
+	ErrorCodeFetchFailed      = "fetch_failed"
+	ErrorCodePublishFailed    = "publish_failed"
+	ErrorCodeProcessingFailed = "processing_failed"
+	ErrorCodeDeadlineExceeded = "deadline_exceeded"
+	ErrorCodeInternalError    = "internal_error"
+	ErrorCodeMethodNotAllowed = "method_not_allowed"
+)
+
+// ErrorResponseBody is the JSON body of an error response.
+type ErrorResponseBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors are the invalid fields of the body, if the body is invalid.
+	Errors []webhook.FieldError `json:"errors,omitempty"`
+}
+
+// errorResponse makes a JSON error response for the given request, with the invalid fields if any.
+func errorResponse(request *webhookRequest, statusCode int, code, message string, fields ...webhook.FieldError) webhookResponse {
+	b, err := json.Marshal(&ErrorResponseBody{
+		Code:      code,
+		Message:   message,
+		RequestID: request.RequestID,
+		Errors:    fields,
+	})
+	if err != nil {
+		// Never happens, as the body consists of strings only.
+		slog.Error("Failed to marshal error response", "error", err)
+	}
+	return webhookResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(b),
+	}
+}
+
+// jsonResponse makes a JSON response of v, e.g. the result of a batch delivery.
+func jsonResponse(statusCode int, v interface{}) webhookResponse {
+	b, err := json.Marshal(v)
+	if err != nil {
+		// Never happens, as the bodies consist of strings, numbers and booleans only.
+		slog.Error("Failed to marshal response", "error", err)
+	}
+	return webhookResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(b),
+	}
+}
+
+// lambdaRequestID returns the AWS request ID of the Lambda invocation, if any.
+func lambdaRequestID(ctx context.Context) string {
+	lc, ok := lambdacontext.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return lc.AwsRequestID
+}
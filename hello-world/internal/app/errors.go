@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"hello-world/pkg/webhook"
+)
+
+// The errors of a webhook delivery. The errors returned while handling a delivery wrap one of them,
+// which decides the response, the audit outcome and the error type of the errors metric.
+var (
+	ErrForbiddenSource  = errors.New("forbidden source IP")
+	ErrBodyTooLarge     = errors.New("request body too large")
+	ErrInvalidBody      = errors.New("invalid request body")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	ErrUnsupportedEvent = errors.New("unsupported resource type or action")
+	ErrEnqueueFailed    = errors.New("failed to enqueue event")
+	// ErrCircuitOpen is the error of a call to intdash or a notifier rejected by its open circuit breaker.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrFetchFailed is the error of fetching the measurement or the data points from intdash.
+	ErrFetchFailed = errors.New("failed to fetch from intdash")
+	// ErrPublishFailed is the error of sending the notification to any of the notifiers.
+	ErrPublishFailed = errors.New("failed to publish notification")
+)
+
+// errorClass is how an error is responded and counted.
+type errorClass struct {
+	// err is the sentinel error of the class. A nil err matches any error.
+	err        error
+	statusCode int
+	// code is the error code of the response and the outcome of the audit record.
+	code    string
+	message string
+	// errorType is the dimension of the errors metric.
+	errorType string
+	level     slog.Level
+}
+
+// errorClasses are the classes of the errors, matched in order.
+var errorClasses = []*errorClass{
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, ErrorCodeDeadlineExceeded, "Processing deadline exceeded", "DeadlineExceeded", slog.LevelError},
+	{ErrForbiddenSource, http.StatusForbidden, ErrorCodeForbiddenSource, "Forbidden source IP", "ForbiddenSource", slog.LevelWarn},
+	{ErrBodyTooLarge, http.StatusRequestEntityTooLarge, ErrorCodeBodyTooLarge, "Request body too large", "BodyTooLarge", slog.LevelWarn},
+	{ErrInvalidBody, http.StatusBadRequest, ErrorCodeInvalidBody, "Invalid request body", "InvalidBody", slog.LevelError},
+	{ErrInvalidSignature, http.StatusBadRequest, ErrorCodeInvalidSignature, "Invalid signature", "InvalidSignature", slog.LevelError},
+	{ErrInvalidTimestamp, http.StatusBadRequest, ErrorCodeInvalidTimestamp, "Invalid timestamp", "InvalidTimestamp", slog.LevelError},
+	{ErrUnsupportedEvent, http.StatusUnprocessableEntity, ErrorCodeUnsupportedEvent, "Unsupported resource type or action", "UnsupportedEvent", slog.LevelInfo},
+	{ErrEnqueueFailed, http.StatusInternalServerError, ErrorCodeEnqueueFailed, "Failed to enqueue event", "EnqueueFailed", slog.LevelError},
+	{ErrCircuitOpen, http.StatusServiceUnavailable, ErrorCodeCircuitOpen, "Downstream service unavailable", "CircuitOpen", slog.LevelWarn},
+	{ErrFetchFailed, http.StatusInternalServerError, ErrorCodeFetchFailed, "Failed to fetch data from intdash", "FetchFailed", slog.LevelError},
+	{ErrPublishFailed, http.StatusInternalServerError, ErrorCodePublishFailed, "Failed to publish notification", "PublishFailed", slog.LevelError},
+	{nil, http.StatusInternalServerError, ErrorCodeProcessingFailed, "Failed to process event", "ProcessingFailed", slog.LevelError},
+}
+
+// classifyError returns the first class of errorClasses that err matches.
+func classifyError(err error) *errorClass {
+	for _, c := range errorClasses {
+		if c.err == nil || errors.Is(err, c.err) {
+			return c
+		}
+	}
+	panic("unreachable: the last error class matches any error")
+}
+
+// failWebhook logs and counts the error of the delivery, records it in the audit record,
+// and returns the error response of its class.
+func (h *Handler) failWebhook(ctx context.Context, request *webhookRequest, audit *AuditRecord, err error) webhookResponse {
+	c := h.recordError(ctx, audit, err)
+	var invalid *webhook.ValidationError
+	if errors.As(err, &invalid) {
+		return errorResponse(request, c.statusCode, c.code, c.message, invalid.Fields...)
+	}
+	return errorResponse(request, c.statusCode, c.code, c.message)
+}
+
+// recordError logs and counts the error, records it in the audit record, and returns its class.
+func (h *Handler) recordError(ctx context.Context, audit *AuditRecord, err error) *errorClass {
+	c := classifyError(err)
+	h.logger().Log(ctx, c.level, "Failed to handle webhook", "code", c.code, "error", err)
+	h.countError(ctx, c.errorType)
+	audit.Outcome, audit.Error = c.code, err.Error()
+	return c
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// DefaultEventRecordPrefix is the default key prefix of the recorded events.
+	DefaultEventRecordPrefix = "events/"
+)
+
+// EventRecorder records the raw webhook requests, so that they can be replayed later,
+// e.g. to re-process the measurements after fixing an analyzer.
+type EventRecorder interface {
+	RecordEvent(ctx context.Context, event *RecordedEvent) error
+}
+
+// RecordedEvent is a raw webhook request as received, including the signature header.
+type RecordedEvent struct {
+	RequestID         string              `json:"request_id,omitempty"`
+	ReceivedAt        time.Time           `json:"received_at"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multi_value_headers,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"is_base64_encoded,omitempty"`
+	Path              string              `json:"path,omitempty"`
+	PathParameters    map[string]string   `json:"path_parameters,omitempty"`
+	SourceIP          string              `json:"source_ip,omitempty"`
+	// Event is the validated event of a dead letter. If the body is empty, e.g. for a failed queued event
+	// whose request is not known, the event is replayed without the request.
+	Event *QueuedEvent `json:"event,omitempty"`
+}
+
+// newRecordedEvent returns the RecordedEvent of the request.
+func newRecordedEvent(request *webhookRequest, receivedAt time.Time) *RecordedEvent {
+	return &RecordedEvent{
+		RequestID:         request.RequestID,
+		ReceivedAt:        receivedAt,
+		Headers:           request.Headers,
+		MultiValueHeaders: request.MultiValueHeaders,
+		Body:              request.Body,
+		IsBase64Encoded:   request.IsBase64Encoded,
+		Path:              request.Path,
+		PathParameters:    request.PathParameters,
+		SourceIP:          request.SourceIP,
+	}
+}
+
+// request returns the webhook request of the event.
+func (e *RecordedEvent) request() *webhookRequest {
+	return &webhookRequest{
+		RequestID:         e.RequestID,
+		Headers:           e.Headers,
+		MultiValueHeaders: e.MultiValueHeaders,
+		Body:              e.Body,
+		IsBase64Encoded:   e.IsBase64Encoded,
+		Path:              e.Path,
+		PathParameters:    e.PathParameters,
+		SourceIP:          e.SourceIP,
+	}
+}
+
+// S3EventRecorder is an EventRecorder that writes each event to S3 as a JSON object
+// with the key "<prefix><yyyy>/<mm>/<dd>/<received_at>_<request_id>.json".
+type S3EventRecorder struct {
+	S3PutObjectAPI S3PutObjectAPI
+	Bucket         string
+	// Prefix is the key prefix of the events. Defaults to DefaultEventRecordPrefix.
+	Prefix string
+}
+
+// RecordEvent puts the event as JSON.
+func (r *S3EventRecorder) RecordEvent(ctx context.Context, event *RecordedEvent) error {
+	prefix := r.Prefix
+	if prefix == "" {
+		prefix = DefaultEventRecordPrefix
+	}
+	receivedAt := event.ReceivedAt.UTC()
+	key := prefix + receivedAt.Format("2006/01/02/20060102T150405.000000000Z")
+	if event.RequestID != "" {
+		key += "_" + event.RequestID
+	}
+	key += ".json"
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	_, err = r.S3PutObjectAPI.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(r.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(b),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("put object s3://%s/%s: %w", r.Bucket, key, err)
+	}
+	slog.DebugContext(ctx, "Recorded event", "location", fmt.Sprintf("s3://%s/%s", r.Bucket, key))
+	return nil
+}
+
+// recordEvent records the raw request to h.EventRecorder, if set. A failure is only logged,
+// so that the recording does not fail the delivery.
+func (h *Handler) recordEvent(ctx context.Context, request *webhookRequest, receivedAt time.Time) {
+	if h.EventRecorder == nil {
+		return
+	}
+	if err := h.EventRecorder.RecordEvent(ctx, newRecordedEvent(request, receivedAt)); err != nil {
+		h.logger().ErrorContext(ctx, "Failed to record event", "error", err)
+	}
+}
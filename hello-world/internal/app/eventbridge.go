@@ -0,0 +1,88 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"hello-world/pkg/webhook"
+)
+
+// HandleEventBridge handles the intdash webhook events delivered via EventBridge, e.g. from a partner event bus
+// or a custom bus, instead of API Gateway. The detail of an event is the webhook body.
+// The payload is an event of a rule target, or an array of the events of an EventBridge Pipes target.
+//
+// The signature is not validated, as the bus accepts only the events put by the authorized sources.
+// The event ID is the delivery ID, which EventBridge keeps on retries.
+// If any event fails, an error is returned so that the events are retried.
+func (h *Handler) HandleEventBridge(ctx context.Context, payload json.RawMessage) error {
+	defer h.flushTracer(ctx)
+	ctx, cancel := h.withProcessingDeadline(ctx)
+	defer cancel()
+
+	var evs []events.CloudWatchEvent
+	if bytes.HasPrefix(bytes.TrimSpace(payload), []byte("[")) {
+		if err := json.Unmarshal(payload, &evs); err != nil {
+			return fmt.Errorf("unmarshal events: %w", err)
+		}
+	} else {
+		var ev events.CloudWatchEvent
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			return fmt.Errorf("unmarshal event: %w", err)
+		}
+		evs = append(evs, ev)
+	}
+
+	var failed int
+	for _, ev := range evs {
+		evCtx, end := h.startSpan(ctx, "eventbridge_event")
+		err := h.handleEventBridgeEvent(evCtx, ev)
+		end(err)
+		if err != nil {
+			h.logger().ErrorContext(ctx, "Failed to process event", "event_id", ev.ID, "source", ev.Source, "error", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to process %d of %d events", failed, len(evs))
+	}
+	return nil
+}
+
+func (h *Handler) handleEventBridgeEvent(ctx context.Context, ev events.CloudWatchEvent) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = h.recovered(ctx, v)
+		}
+	}()
+	ctx = withLogAttrs(ctx, "event_id", ev.ID, "source", ev.Source, "detail_type", ev.DetailType)
+	body, err := webhook.DecodeBody(ev.Detail, h.AllowUnknownFields)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBody, err)
+	}
+	event := &QueuedEvent{DeliveryID: ev.ID, Body: *body}
+	return h.auditEvent(ctx, event, func(ctx context.Context) error {
+		if body.IsPing() {
+			h.logger().InfoContext(ctx, "Got ping")
+			setAuditOutcome(ctx, AuditOutcomePing)
+			return nil
+		}
+		processor, ok := h.lookupProcessor(body)
+		if !ok {
+			h.logger().InfoContext(ctx, "Dropped unsupported resource type or action", "resource_type", body.ResourceType, "action", body.Action)
+			setAuditOutcome(ctx, AuditOutcomeDropped)
+			return nil
+		}
+		if h.EventQueue != nil {
+			if err := h.EventQueue.Enqueue(ctx, event); err != nil {
+				return fmt.Errorf("%w: delivery %s: %w", ErrEnqueueFailed, event.DeliveryID, err)
+			}
+			setAuditOutcome(ctx, AuditOutcomeEnqueued)
+			return nil
+		}
+		return h.processOnce(ctx, event.DeliveryID, processor, body)
+	})
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/firehose/types"
+)
+
+const (
+	// FirehoseMaxRecordsPerBatch is the maximum number of records in a PutRecordBatch request.
+	FirehoseMaxRecordsPerBatch = 500
+)
+
+type FirehosePutRecordBatchAPI interface {
+	PutRecordBatch(ctx context.Context, input *firehose.PutRecordBatchInput, optFns ...func(*firehose.Options)) (*firehose.PutRecordBatchOutput, error)
+}
+
+// FirehoseRecord is a line of the newline-delimited JSON put by FirehosePublisher.
+type FirehoseRecord struct {
+	MeasurementUUID string    `json:"measurement_uuid"`
+	DataID          string    `json:"data_id"`
+	Time            time.Time `json:"time"`
+	Value           float64   `json:"value"`
+}
+
+// FirehosePublisher is a ResultArchive that puts the raw data points to a Firehose delivery stream
+// as newline-delimited JSON, one FirehoseRecord per line.
+type FirehosePublisher struct {
+	FirehosePutRecordBatchAPI FirehosePutRecordBatchAPI
+	DeliveryStreamName        string
+}
+
+// Archive puts the data points of the result in batches of FirehoseMaxRecordsPerBatch.
+func (p *FirehosePublisher) Archive(ctx context.Context, result *AnalysisResult) error {
+	for start := 0; start < len(result.DataPoints); start += FirehoseMaxRecordsPerBatch {
+		end := start + FirehoseMaxRecordsPerBatch
+		if end > len(result.DataPoints) {
+			end = len(result.DataPoints)
+		}
+		records := make([]types.Record, 0, end-start)
+		for _, dp := range result.DataPoints[start:end] {
+			b, err := json.Marshal(&FirehoseRecord{
+				MeasurementUUID: result.MeasurementUUID,
+				DataID:          result.DataID,
+				Time:            dp.Time,
+				Value:           dp.Value,
+			})
+			if err != nil {
+				return fmt.Errorf("marshal record at %s: %w", dp.Time, err)
+			}
+			records = append(records, types.Record{Data: append(b, '\n')})
+		}
+
+		out, err := p.FirehosePutRecordBatchAPI.PutRecordBatch(ctx, &firehose.PutRecordBatchInput{
+			DeliveryStreamName: aws.String(p.DeliveryStreamName),
+			Records:            records,
+		})
+		if err != nil {
+			return fmt.Errorf("put record batch %d-%d to %s: %w", start, end, p.DeliveryStreamName, err)
+		}
+		if failed := aws.ToInt32(out.FailedPutCount); failed > 0 {
+			for _, res := range out.RequestResponses {
+				if res.ErrorCode != nil {
+					return fmt.Errorf("put record batch %d-%d to %s: %d records failed: %s: %s",
+						start, end, p.DeliveryStreamName, failed, aws.ToString(res.ErrorCode), aws.ToString(res.ErrorMessage))
+				}
+			}
+			return fmt.Errorf("put record batch %d-%d to %s: %d records failed", start, end, p.DeliveryStreamName, failed)
+		}
+	}
+	slog.InfoContext(ctx, "Put records to Firehose", "records", len(result.DataPoints), "delivery_stream", p.DeliveryStreamName)
+	return nil
+}
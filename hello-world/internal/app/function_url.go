@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HandleLambdaFunctionURL handles the Lambda Function URL request of intdash webhook.
+// Function URLs deliver lowercase header names, and may deliver the body base64-encoded.
+// The status endpoints are served as by the standalone server.
+func (h *Handler) HandleLambdaFunctionURL(ctx context.Context, request events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	if res, ok := h.serveStatus(request.RequestContext.HTTP.Method, request.RawPath); ok {
+		return events.LambdaFunctionURLResponse{
+			Headers:    res.Headers,
+			Body:       res.Body,
+			StatusCode: res.StatusCode,
+		}, nil
+	}
+	h.logger().InfoContext(ctx, "Got request", "method", request.RequestContext.HTTP.Method, "path", request.RawPath, "source_ip", request.RequestContext.HTTP.SourceIP)
+
+	res := h.handleWebhook(ctx, &webhookRequest{
+		RequestID:       request.RequestContext.RequestID,
+		Headers:         request.Headers,
+		Body:            request.Body,
+		IsBase64Encoded: request.IsBase64Encoded,
+		Path:            request.RawPath,
+		SourceIP:        request.RequestContext.HTTP.SourceIP,
+	})
+	return events.LambdaFunctionURLResponse{
+		Headers:    res.Headers,
+		Body:       res.Body,
+		StatusCode: res.StatusCode,
+	}, nil
+}
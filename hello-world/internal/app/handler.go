@@ -0,0 +1,643 @@
+// Package app is the intdash webhook handler and its configuration, served by the Lambda function in hello-world,
+// the standalone server in cmd/server and the local development server in cmd/local.
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"hello-world/pkg/analyze"
+	"hello-world/pkg/cache"
+	"hello-world/pkg/intdash"
+	"hello-world/pkg/notify"
+	"hello-world/pkg/webhook"
+)
+
+type (
+	IntdashAPI interface {
+		// FetchFloat64DataPoints fetches the data points. On error, the data points fetched so far may be returned with the error.
+		FetchFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr intdash.TimeRange) ([]intdash.DataPoint, error)
+		FetchMeasurement(ctx context.Context, measurementUUID string) (*intdash.Measurement, error)
+	}
+
+	// IntdashStreamAPI is implemented by the IntdashAPIs that call fn with each data point as it is read from the response,
+	// so that the data points are analyzed without holding all of them.
+	IntdashStreamAPI interface {
+		StreamFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr intdash.TimeRange, fn func(intdash.DataPoint) error) error
+	}
+
+	Handler struct {
+		IntdashAPI IntdashAPI
+		// SHA256Keys are the HMAC keys tried in order during signature validation,
+		// so the webhook secret can be rotated without dropping deliveries.
+		// They are used with SignatureAlgorithm, which may be other than SHA256 despite the name.
+		SHA256Keys [][]byte
+		// SHA256KeySource provides the HMAC keys. If set, it is used instead of SHA256Keys.
+		SHA256KeySource webhook.KeySource
+		// SignatureAlgorithm is the HMAC algorithm of the signatures, in the header of its name,
+		// e.g. "x-intdash-signature-512" for webhook.SHA512. Defaults to webhook.SHA256.
+		SignatureAlgorithm *webhook.Algorithm
+		// SignatureValidator, if set, validates the signatures instead of the HMAC keys.
+		SignatureValidator SignatureValidator
+		// Notifiers receive the analysis results. Each of them is notified independently.
+		Notifiers []notify.Notifier
+		// Processors dispatches events by resource type and action.
+		// Events without a registered processor are rejected as unsupported.
+		Processors *ProcessorRegistry
+		// Channels are the data IDs fetched and analyzed for a finished measurement,
+		// each with its own analyzers and alert rules. The results are notified together.
+		Channels []*Channel
+		// ChannelConcurrency is the maximum number of channels fetched and analyzed concurrently.
+		// Defaults to DefaultChannelConcurrency.
+		ChannelConcurrency int
+		// NotificationFormat is the format of the notification body,
+		// NotificationFormatText (default) or NotificationFormatJSON.
+		NotificationFormat string
+		// Locale is the locale of the notification text. If nil, the text is in English.
+		Locale *analyze.Locale
+		// IncludeMeasurement includes the measurement metadata, such as the name and the edge, in the notification.
+		IncludeMeasurement bool
+		// MeasurementLink, if set, builds the link to the measurement in the notification.
+		MeasurementLink *MeasurementLinkBuilder
+		// NotificationTemplate, if set, renders the notification body and subject.
+		NotificationTemplate *NotificationTemplate
+		// ResultStore stores measurement metadata and results. Optional.
+		ResultStore ResultStore
+		// ResultArchives archive the fetched data points and their statistics before notifying.
+		ResultArchives []ResultArchive
+		// WriteBack, if set, writes the results back to the measurement in intdash before notifying.
+		WriteBack *IntdashWriteBack
+		// MeasurementCache, if set, keeps the fetched measurements across the warm invocations,
+		// e.g. for the retries of a delivery and the steps of a Step Functions execution.
+		// A measurement is invalidated once its results are written back.
+		MeasurementCache *cache.Cache[string, *intdash.Measurement]
+		// DryRun validates, fetches and analyzes the deliveries as usual, but logs the notifications instead of sending them
+		// and skips the archives and the write-back, e.g. to roll out new analyzer settings in production safely.
+		DryRun bool
+		// TenantKeys are the HMAC keys of each tenant, e.g. an intdash organization, so that several tenants
+		// deliver to one handler with their own secrets. The deliveries without a tenant use the keys of the handler.
+		TenantKeys map[string]webhook.KeySource
+		// TenantSource is where the tenant of a delivery is taken from. Defaults to the header DefaultTenantHeader.
+		TenantSource *TenantSource
+		// Routes route the deliveries of the projects or the edges to their own keys, notifiers and channels.
+		// The first route of the project, or else of the edge, is used. The other deliveries use those of the handler.
+		Routes []*Route
+
+		// AllowedSourceIPs, if set, are the networks the deliveries are accepted from, checked before anything else
+		// as defense in depth beyond the signature.
+		AllowedSourceIPs []netip.Prefix
+		// MaxBodySize is the maximum size of a body in bytes, checked before the signature and the body are processed.
+		// Defaults to DefaultMaxBodySize. A negative value disables the limit.
+		MaxBodySize int64
+
+		// TimestampHeader is the name of the delivery timestamp header. Defaults to DefaultTimestampHeader.
+		TimestampHeader string
+		// TimestampTolerance is the maximum age of a delivery. Zero disables the timestamp validation.
+		// If it is enabled, the signature covers the timestamp as "<timestamp>.<body>".
+		TimestampTolerance time.Duration
+		// ClockSkew is the allowance for a delivery timestamp ahead of the local clock.
+		ClockSkew time.Duration
+
+		// AllowUnknownFields accepts the webhook bodies with fields unknown to webhook.Body,
+		// e.g. ones added by a newer intdash. By default, they are rejected as invalid.
+		AllowUnknownFields bool
+
+		// IdempotencyStore skips deliveries that have already been processed. Optional.
+		IdempotencyStore IdempotencyStore
+		// DeliveryIDHeader is the name of the delivery ID header. Defaults to DefaultDeliveryIDHeader.
+		DeliveryIDHeader string
+
+		// EventRecorder, if set, records the raw requests before they are validated, to be replayed later.
+		EventRecorder EventRecorder
+
+		// EventQueue, if set, receives validated events instead of processing them synchronously.
+		// The events are then processed by HandleSQS in a worker function, or by HandleStepFunctions in a state machine.
+		EventQueue EventQueue
+		// DataPointStaging stages the fetched data points between the steps of HandleStepFunctions.
+		DataPointStaging *S3DataPointStaging
+
+		// DeadLetters, if set, receives the events that failed terminally with their failures: the deliveries
+		// that failed to be processed or enqueued, and the queued events that failed on their last receive.
+		DeadLetters DeadLetterQueue
+		// DeadLetterMaxReceiveCount is the receive count of a queued event at which its failure is terminal.
+		// Defaults to DefaultDeadLetterMaxReceiveCount.
+		DeadLetterMaxReceiveCount int
+
+		// LogRedactor redacts the headers and the body of the requests logged at the debug level.
+		// If nil, only the default keys are redacted.
+		LogRedactor *LogRedactor
+		// Logger logs the processing. Defaults to slog.Default().
+		Logger *slog.Logger
+		// Clock returns the current time, e.g. for the timestamp validation. Defaults to time.Now.
+		Clock func() time.Time
+		// Tracer traces the processing stages. Optional.
+		Tracer Tracer
+		// AuditLog records the deliveries and their outcomes. Optional.
+		AuditLog AuditLog
+		// ErrorCounters count the errors, such as recovered panics, as metrics. Optional.
+		ErrorCounters []ErrorCounter
+
+		// AllowPartialResults analyzes the data points fetched before a fetch error, e.g. a timeout,
+		// and marks the result as partial, instead of failing the delivery.
+		AllowPartialResults bool
+
+		// DeadlineMargin is the time reserved before the Lambda deadline to respond.
+		// Defaults to DefaultDeadlineMargin.
+		DeadlineMargin time.Duration
+		// StageTimeShares are the shares of the remaining processing time given to the stages.
+		// Defaults to DefaultStageTimeShares.
+		StageTimeShares map[string]float64
+
+		// awsClients are the AWS clients of the handler provided by ProvideHandler, e.g. for the replay.
+		awsClients *awsClients
+	}
+)
+
+// HandleAPIGatewayProxy handles the API Gateway Proxy request of intdash webhook.
+func (h *Handler) HandleAPIGatewayProxy(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	h.logger().InfoContext(ctx, "Got request", "method", request.HTTPMethod, "path", request.Path, "source_ip", request.RequestContext.Identity.SourceIP)
+
+	res := h.handleWebhook(ctx, &webhookRequest{
+		RequestID:         request.RequestContext.RequestID,
+		Headers:           request.Headers,
+		MultiValueHeaders: request.MultiValueHeaders,
+		Body:              request.Body,
+		IsBase64Encoded:   request.IsBase64Encoded,
+		Path:              request.Path,
+		PathParameters:    request.PathParameters,
+		SourceIP:          request.RequestContext.Identity.SourceIP,
+	})
+	return events.APIGatewayProxyResponse{
+		Headers:    res.Headers,
+		Body:       res.Body,
+		StatusCode: res.StatusCode,
+	}, nil
+}
+
+// handleWebhook validates and processes the webhook delivery.
+// It is shared by all event sources.
+func (h *Handler) handleWebhook(ctx context.Context, request *webhookRequest) (res webhookResponse) {
+	if request.RequestID == "" {
+		request.RequestID = lambdaRequestID(ctx)
+	}
+	audit := &AuditRecord{RequestID: request.RequestID, ReceivedAt: h.now()}
+	ctx = withAuditRecord(ctx, audit)
+	ctx, end := h.startSpan(ctx, "webhook")
+	defer func(ctx context.Context) {
+		var err error
+		if res.StatusCode >= http.StatusInternalServerError {
+			err = fmt.Errorf("respond with status %d", res.StatusCode)
+		}
+		end(err)
+		h.recordAudit(ctx, audit)
+		h.flushTracer(ctx)
+	}(ctx)
+	defer func() {
+		if v := recover(); v != nil {
+			err := h.recovered(ctx, v)
+			audit.Outcome, audit.Error = ErrorCodeInternalError, err.Error()
+			res = errorResponse(request, http.StatusInternalServerError, ErrorCodeInternalError, "Internal error")
+		}
+	}()
+	ctx = withLogAttrs(ctx, "request_id", request.RequestID, "stage", "validate")
+	ctx, cancel := h.withProcessingDeadline(ctx)
+	defer cancel()
+	if err := h.validateSourceIP(request); err != nil {
+		return h.failWebhook(ctx, request, audit, fmt.Errorf("%w: %w", ErrForbiddenSource, err))
+	}
+	if err := h.validateBodySize(request); err != nil {
+		return h.failWebhook(ctx, request, audit, fmt.Errorf("%w: %w", ErrBodyTooLarge, err))
+	}
+	h.recordEvent(ctx, request, audit.ReceivedAt)
+
+	if err := request.decodeBody(); err != nil {
+		return h.failWebhook(ctx, request, audit, fmt.Errorf("%w: %w", ErrInvalidBody, err))
+	}
+	if h.logger().Enabled(ctx, slog.LevelDebug) {
+		redactor := h.LogRedactor
+		if redactor == nil {
+			redactor = NewLogRedactor()
+		}
+		h.logger().DebugContext(ctx, "Got request details", "headers", redactor.Headers(request), "body", redactor.Body(request.Body))
+	}
+
+	sigCtx, sigEnd := h.startStage(ctx, "signature")
+	err := h.validateSignature(sigCtx, request)
+	sigEnd(err)
+	if err != nil {
+		return h.failWebhook(ctx, request, audit, err)
+	}
+	if request.tenant != "" {
+		ctx = withLogAttrs(ctx, "tenant", request.tenant)
+	}
+
+	if err := h.validateTimestamp(request); err != nil {
+		return h.failWebhook(ctx, request, audit, fmt.Errorf("%w: %w", ErrInvalidTimestamp, err))
+	}
+	if webhook.IsBatch([]byte(request.Body)) {
+		return h.handleBatch(ctx, request, audit)
+	}
+
+	body, err := h.extractWebhookBody(ctx, request)
+	if err != nil {
+		return h.failWebhook(ctx, request, audit, err)
+	}
+	audit.MeasurementUUID, audit.ResourceType, audit.Action = body.MeasurementUUID, body.ResourceType, body.Action
+	if body.IsPing() {
+		audit.Outcome = AuditOutcomePing
+		return h.respondPing(ctx, body)
+	}
+	processor, ok := h.lookupProcessor(body)
+	if !ok {
+		return h.failWebhook(ctx, request, audit, fmt.Errorf("%w: %s %s", ErrUnsupportedEvent, body.ResourceType, body.Action))
+	}
+
+	deliveryID := h.deliveryID(request)
+	audit.DeliveryID = deliveryID
+	ctx = withLogAttrs(ctx, "measurement_uuid", body.MeasurementUUID)
+	event := &QueuedEvent{DeliveryID: deliveryID, Body: *body}
+	deadLetter := func(err error) {
+		recorded := newRecordedEvent(request, audit.ReceivedAt)
+		recorded.Event = event
+		h.sendDeadLetter(ctx, &DeadLetter{RecordedEvent: *recorded, DeliveryID: deliveryID, Source: DeadLetterSourceWebhook}, err)
+	}
+	if h.EventQueue != nil {
+		if err := h.EventQueue.Enqueue(ctx, event); err != nil {
+			err = fmt.Errorf("%w: delivery %s: %w", ErrEnqueueFailed, deliveryID, err)
+			deadLetter(err)
+			return h.failWebhook(ctx, request, audit, err)
+		}
+		audit.Outcome = AuditOutcomeEnqueued
+		return webhookResponse{
+			Body:       "",
+			StatusCode: http.StatusAccepted,
+		}
+	}
+
+	if err := h.processOnce(ctx, deliveryID, processor, body); err != nil {
+		err = fmt.Errorf("process %s %s: %w", body.ResourceType, body.Action, err)
+		deadLetter(err)
+		return h.failWebhook(ctx, request, audit, err)
+	}
+
+	return webhookResponse{
+		Body:       "",
+		StatusCode: http.StatusNoContent,
+	}
+}
+
+// processOnce processes the event with the given processor, unless the delivery has already been processed.
+// If processing fails, the delivery is released so that a retry is processed again.
+func (h *Handler) processOnce(ctx context.Context, deliveryID string, processor Processor, body *webhook.Body) error {
+	if h.IdempotencyStore != nil {
+		claimed, err := h.IdempotencyStore.Claim(ctx, deliveryID)
+		if err != nil {
+			return fmt.Errorf("claim delivery %s: %w", deliveryID, err)
+		}
+		if !claimed {
+			h.logger().InfoContext(ctx, "Skipped already processed delivery")
+			setAuditOutcome(ctx, AuditOutcomeDuplicate)
+			return nil
+		}
+	}
+	setAuditOutcome(ctx, AuditOutcomeProcessed)
+
+	ctx = withDeliveryID(ctx, deliveryID)
+	ctx = withLogAttrs(ctx, "measurement_uuid", body.MeasurementUUID)
+	ctx, end := h.startStage(ctx, "process")
+	err := processor.Process(ctx, body)
+	end(err)
+	if err != nil {
+		if h.IdempotencyStore != nil {
+			// ctx may have been canceled by the deadline, but the delivery must be released for the retry.
+			releaseCtx, cancel := detachedContext(ctx, DefaultDeadlineMargin/2)
+			defer cancel()
+			if err := h.IdempotencyStore.Release(releaseCtx, deliveryID); err != nil {
+				h.logger().ErrorContext(ctx, "Failed to release delivery", "error", err)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// lookupProcessor returns the processor for the resource type and action of the given body.
+func (h *Handler) lookupProcessor(body *webhook.Body) (Processor, bool) {
+	if h.Processors == nil {
+		return nil, false
+	}
+	return h.Processors.Lookup(body.ResourceType, body.Action)
+}
+
+// ProcessMeasurementFinished fetches and analyzes the data points of each channel of the finished measurement,
+// archives and stores the results if configured, and sends the results to the notifiers.
+// If any channel has alert rules, the notification is sent only when a rule fires.
+// The measurement is routed by its project, or else by its edge.
+func (h *Handler) ProcessMeasurementFinished(ctx context.Context, body *webhook.Body) error {
+	ctx, rh, measurement, err := h.routeMeasurement(ctx, body, nil)
+	if err != nil {
+		return err
+	}
+	return rh.processMeasurement(ctx, body, measurement)
+}
+
+// routeMeasurement returns the handler of the route of the finished measurement, and the measurement
+// fetched if the route needs it and it is not given. The returned context has the name of the route.
+func (h *Handler) routeMeasurement(ctx context.Context, body *webhook.Body, measurement *intdash.Measurement) (context.Context, *Handler, *intdash.Measurement, error) {
+	route := h.projectRoute(body.ProjectUUID)
+	rh := h.withRoute(route)
+	// The measurement is fetched once for all the channels.
+	if measurement == nil && (rh.needsMeasurement() || (route == nil && h.hasEdgeRoutes())) {
+		fetchCtx, end := h.startStage(ctx, "fetch")
+		m, err := h.fetchMeasurement(fetchCtx, body.MeasurementUUID)
+		end(err)
+		if err != nil {
+			return ctx, nil, nil, fmt.Errorf("%w: fetch measurement: %w", ErrFetchFailed, err)
+		}
+		measurement = m
+	}
+	if route == nil {
+		route = h.edgeRoute(measurement)
+		rh = h.withRoute(route)
+	}
+	if route != nil {
+		ctx = withLogAttrs(ctx, "route", route.Name)
+	}
+	return ctx, rh, measurement, nil
+}
+
+// DefaultMeasurementCacheTTL is the default time a fetched measurement is kept in the cache.
+const DefaultMeasurementCacheTTL = time.Minute
+
+// fetchMeasurement fetches the measurement, or returns it from h.MeasurementCache.
+func (h *Handler) fetchMeasurement(ctx context.Context, measurementUUID string) (*intdash.Measurement, error) {
+	if h.MeasurementCache == nil {
+		return h.IntdashAPI.FetchMeasurement(ctx, measurementUUID)
+	}
+	if m, ok := h.MeasurementCache.Get(measurementUUID); ok {
+		h.logger().DebugContext(ctx, "Got measurement from cache")
+		return m, nil
+	}
+	m, err := h.IntdashAPI.FetchMeasurement(ctx, measurementUUID)
+	if err != nil {
+		return nil, err
+	}
+	h.MeasurementCache.Set(measurementUUID, m)
+	return m, nil
+}
+
+// processMeasurement analyzes, archives, writes back and notifies the results of the finished measurement.
+func (h *Handler) processMeasurement(ctx context.Context, body *webhook.Body, measurement *intdash.Measurement) error {
+	results, err := h.processChannels(ctx, body.MeasurementUUID, measurement)
+	if err != nil {
+		return err
+	}
+
+	if (len(h.ResultArchives) > 0 || h.ResultStore != nil) && h.DryRun {
+		dataIDs := make([]string, 0, len(results))
+		for _, result := range results {
+			dataIDs = append(dataIDs, result.DataID)
+		}
+		h.logger().InfoContext(ctx, "Dry run: skipped archive",
+			"archives", len(h.ResultArchives), "result_store", h.ResultStore != nil, "data_ids", dataIDs)
+	} else if err := h.archiveResults(ctx, results); err != nil {
+		return err
+	}
+
+	if h.WriteBack != nil && h.DryRun {
+		h.logger().InfoContext(ctx, "Dry run: skipped write-back")
+	} else if h.WriteBack != nil {
+		writeBackCtx, end := h.startStage(ctx, "write_back")
+		err := h.WriteBack.WriteBack(writeBackCtx, body.MeasurementUUID, measurement, results)
+		end(err)
+		if err != nil {
+			return fmt.Errorf("write back results: %w", err)
+		}
+		if h.MeasurementCache != nil {
+			h.MeasurementCache.Invalidate(body.MeasurementUUID)
+		}
+	}
+
+	ctx, end := h.startStage(ctx, "notify")
+	err = h.notifyResults(ctx, body, measurement, results)
+	end(err)
+	return err
+}
+
+// archiveResults archives and stores the results.
+func (h *Handler) archiveResults(ctx context.Context, results []*AnalysisResult) (err error) {
+	ctx, end := h.startStage(ctx, "archive")
+	defer func() { end(err) }()
+	for _, result := range results {
+		for _, archive := range h.ResultArchives {
+			if err := archive.Archive(ctx, result); err != nil {
+				return fmt.Errorf("archive result: %w", err)
+			}
+		}
+		if h.ResultStore != nil {
+			if err := h.ResultStore.SaveResult(ctx, result); err != nil {
+				return fmt.Errorf("save result: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// notifyResults sends the notification of the results to the notifiers,
+// unless there are alert rules and none of them fired.
+func (h *Handler) notifyResults(ctx context.Context, body *webhook.Body, measurement *intdash.Measurement, results []*AnalysisResult) error {
+	alerts := notificationAlerts(results)
+	if h.hasAlertRules() && len(alerts) == 0 {
+		h.logger().InfoContext(ctx, "No alert rule fired")
+		setAuditOutcome(ctx, AuditOutcomeNoAlert)
+		return nil
+	}
+
+	n := &notify.Notification{
+		Event:      body,
+		DeliveryID: deliveryIDFromContext(ctx),
+		Alerts:     alerts,
+	}
+	for _, result := range results {
+		n.Partial = n.Partial || result.Partial
+	}
+	if h.IncludeMeasurement {
+		n.Measurement = measurement
+	}
+	var err error
+	if h.MeasurementLink != nil {
+		if n.Link, err = h.MeasurementLink.Link(body); err != nil {
+			return err
+		}
+	}
+	if h.NotificationFormat == NotificationFormatJSON {
+		if n.Body, err = makeJSONNotificationBody(n, results); err != nil {
+			return err
+		}
+	} else {
+		n.Body = h.makeNotificationBody(n, results)
+	}
+	if h.NotificationTemplate != nil {
+		n.Body, n.Subject, err = h.NotificationTemplate.Render(&NotificationTemplateData{
+			Event:       body,
+			DeliveryID:  n.DeliveryID,
+			Alerts:      alerts,
+			Severity:    n.Severity(),
+			Link:        n.Link,
+			Measurement: n.Measurement,
+			Results:     results,
+			Default:     n.Body,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if h.DryRun {
+		notifiers := make([]string, 0, len(h.Notifiers))
+		for _, notifier := range h.Notifiers {
+			notifiers = append(notifiers, notifier.Name())
+		}
+		h.logger().InfoContext(ctx, "Dry run: skipped notification",
+			"notifiers", notifiers, "severity", n.Severity(), "alerts", alerts, "subject", n.Subject, "body", n.Body)
+		setAuditOutcome(ctx, AuditOutcomeDryRun)
+		return nil
+	}
+	if err := h.notify(ctx, n); err != nil {
+		return err
+	}
+	setAuditOutcome(ctx, AuditOutcomeNotified)
+	return nil
+}
+
+// validateSignature validates the signature of the given request with the keys of the route of the project
+// of the body if it has its own, or else of the tenant of the request if any, or else of the handler.
+// The error wraps ErrInvalidSignature.
+func (h *Handler) validateSignature(ctx context.Context, request *webhookRequest) error {
+	var err error
+	if h.SignatureValidator != nil {
+		err = h.SignatureValidator.ValidateSignature(ctx, request.header, h.signedPayload(request))
+	} else {
+		var source webhook.KeySource
+		if source, err = h.requestKeySource(request); err == nil {
+			alg := h.signatureAlgorithm()
+			err = alg.ValidateSignature(ctx, request.header(alg.Header()), h.signedPayload(request), source)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// requestKeySource returns the source of the HMAC keys of the signature of the request.
+func (h *Handler) requestKeySource(request *webhookRequest) (webhook.KeySource, error) {
+	if request.keyRoute = h.keyRoute(request.bodyField("project_uuid")); request.keyRoute != nil {
+		return request.keyRoute.KeySource, nil
+	}
+	source, err := h.tenantKeySource(request)
+	if err != nil || source != nil {
+		return source, err
+	}
+	return h.keySource(), nil
+}
+
+// signatureAlgorithm returns the HMAC algorithm of the signatures.
+func (h *Handler) signatureAlgorithm() *webhook.Algorithm {
+	if h.SignatureAlgorithm == nil {
+		return webhook.SHA256
+	}
+	return h.SignatureAlgorithm
+}
+
+// keySource returns the source of the HMAC keys used to validate signatures.
+func (h *Handler) keySource() webhook.KeySource {
+	if h.SHA256KeySource != nil {
+		return h.SHA256KeySource
+	}
+	return webhook.StaticKeys(h.SHA256Keys)
+}
+
+// extractWebhookBody extracts and validates the webhook body from the given request.
+func (h *Handler) extractWebhookBody(ctx context.Context, request *webhookRequest) (*webhook.Body, error) {
+	body, err := h.decodeBody(request, []byte(request.Body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBody, err)
+	}
+	return body, nil
+}
+
+// decodeBody decodes the event of the request with the decoder of its schema version,
+// given by the version header or the version field of the event.
+func (h *Handler) decodeBody(request *webhookRequest, data []byte) (*webhook.Body, error) {
+	return webhook.DecodeVersionedBody(data, request.header(webhook.VersionHeader), h.AllowUnknownFields)
+}
+
+// makeNotificationBody makes a notification body of n from the given results.
+// The body contains a line for each of the fired alert rules and the link to the measurement,
+// followed by the results of the analyzers and the data-quality section of each channel.
+// If there are multiple channels, each of them has a header line.
+func (h *Handler) makeNotificationBody(n *notify.Notification, results []*AnalysisResult) string {
+	w := &analyze.TextWriter{Locale: h.Locale}
+	for _, alert := range n.Alerts {
+		w.Printf("Alert: %s\n", alert)
+	}
+	if n.Link != "" {
+		w.Printf("Link: %s\n", n.Link)
+	}
+	if m := n.Measurement; m != nil {
+		w.Printf("Measurement: %s\n", m.Name)
+		if m.EdgeUUID != "" {
+			w.Printf("Edge: %s\n", m.EdgeUUID)
+		}
+		w.Printf("Basetime: %s\n", m.Basetime.Format(time.RFC3339Nano))
+		w.Printf("Duration: %s\n", m.Duration)
+		if len(m.Tags) > 0 {
+			w.Printf("Tags: %s\n", formatTags(m.Tags))
+		}
+	}
+	for _, result := range results {
+		if len(results) > 1 {
+			if result.Unit != "" {
+				w.Printf("Channel: %s (%s)\n", result.DataID, result.Unit)
+			} else {
+				w.Printf("Channel: %s\n", result.DataID)
+			}
+		}
+		if result.Partial {
+			if result.DataQuality != nil {
+				w.Printf("Partial: %d data points fetched before the error: %s\n", result.DataQuality.Total, result.PartialReason)
+			} else {
+				w.Printf("Partial: data points fetched before the error: %s\n", result.PartialReason)
+			}
+		}
+		for _, analysis := range result.Analyses {
+			analysis.Result.WriteText(w)
+		}
+		if result.DataQuality != nil {
+			result.DataQuality.WriteText(w)
+		}
+	}
+	return w.String()
+}
+
+// formatTags formats the tags as "key=value" separated by commas, in the order of the keys.
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + tags[k]
+	}
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"hello-world/pkg/webhook"
+)
+
+// The fuzz targets harden the parsing of the internet-facing endpoint against malformed input:
+//
+//	go test -run '^$' -fuzz FuzzValidateSignature -fuzztime 1m
+//	go test -run '^$' -fuzz FuzzExtractWebhookBody -fuzztime 1m
+
+func FuzzValidateSignature(f *testing.F) {
+	body := `{"resource_type":"measurement","action":"finished","measurement_uuid":"00000000-0000-0000-0000-000000000000"}`
+	for _, seed := range []struct {
+		key, body, signature string
+	}{
+		{"secret", body, base64.StdEncoding.EncodeToString(webhook.Sign([]byte("secret"), []byte(body)))},
+		{"secret", body, ""},
+		{"secret", body, "not base64!"},
+		{"secret", body, "AAAA===="},
+		{"secret", body, base64.RawStdEncoding.EncodeToString(webhook.Sign([]byte("secret"), []byte(body)))},
+		{"", "", base64.StdEncoding.EncodeToString(webhook.Sign(nil, nil))},
+		{"secret", strings.Repeat("x", 1<<20), "c2lnbmF0dXJl"},
+	} {
+		f.Add([]byte(seed.key), seed.body, seed.signature)
+	}
+
+	f.Fuzz(func(t *testing.T, key []byte, body, signature string) {
+		h := &Handler{SHA256Keys: [][]byte{key}}
+		valid := base64.StdEncoding.EncodeToString(webhook.Sign(key, []byte(body)))
+
+		err := h.validateSignature(context.Background(), &webhookRequest{
+			Headers: map[string]string{webhook.SignatureHeader: signature},
+			Body:    body,
+		})
+		if signature == valid && err != nil {
+			t.Errorf("validateSignature() error = %v with the valid signature", err)
+		}
+		if err == nil {
+			// Any accepted signature must decode to the HMAC of the body.
+			got, decodeErr := base64.StdEncoding.DecodeString(signature)
+			if decodeErr != nil || !reflect.DeepEqual(got, webhook.Sign(key, []byte(body))) {
+				t.Errorf("validateSignature() accepted %q for the body %q", signature, body)
+			}
+		}
+
+		// The valid signature of the body must not be accepted for a tampered body.
+		err = h.validateSignature(context.Background(), &webhookRequest{
+			Headers: map[string]string{webhook.SignatureHeader: valid},
+			Body:    body + " ",
+		})
+		if err == nil {
+			t.Errorf("validateSignature() accepted the signature of %q for a tampered body", body)
+		}
+	})
+}
+
+func FuzzExtractWebhookBody(f *testing.F) {
+	for _, seed := range []string{
+		`{"resource_type":"measurement","action":"finished","measurement_uuid":"00000000-0000-0000-0000-000000000000"}`,
+		`{"resource_type":"measurement","action":"finished","measurement_uuid":"u","project_uuid":"p"}`,
+		`{}`,
+		`null`,
+		`[]`,
+		`"measurement"`,
+		`{"resource_type":1,"action":null}`,
+		`{"resource_type":"measurement","resource_type":"other"}`,
+		`{"measurement_uuid":"\u0000\ud800"}`,
+		strings.Repeat(`{"a":`, 10000) + `1` + strings.Repeat(`}`, 10000),
+		strings.Repeat(`[`, 100000),
+		`{"measurement_uuid":"` + strings.Repeat("x", 1<<20) + `"}`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		h := &Handler{}
+		body, err := h.extractWebhookBody(context.Background(), &webhookRequest{Body: raw})
+		if err != nil {
+			if body != nil {
+				t.Errorf("extractWebhookBody() = %+v with error %v", body, err)
+			}
+			return
+		}
+		if body == nil {
+			t.Fatalf("extractWebhookBody() = nil without error")
+		}
+
+		// The extracted body must survive a round trip, as it is enqueued and archived as JSON.
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal extracted body: %v", err)
+		}
+		var got webhook.Body
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("unmarshal extracted body %s: %v", b, err)
+		}
+		if !reflect.DeepEqual(&got, body) {
+			t.Errorf("round trip = %+v, want %+v", got, *body)
+		}
+	})
+}
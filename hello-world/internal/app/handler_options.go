@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"hello-world/pkg/notify"
+	"hello-world/pkg/webhook"
+)
+
+// SignatureValidator validates the signature of a webhook request, in place of the built-in validation
+// of the HMAC signature, in webhook.SignatureHeader for SHA256, with SHA256Keys or SHA256KeySource.
+type SignatureValidator interface {
+	// ValidateSignature returns an error if the signature of the payload is invalid.
+	// The payload is the body, or "<timestamp>.<body>" if the timestamp is validated. See Handler.signedPayload.
+	// header returns the first value of the given request header, matching the name case-insensitively.
+	ValidateSignature(ctx context.Context, header func(name string) string, body []byte) error
+}
+
+// Option configures a Handler created by NewHandler.
+type Option func(h *Handler)
+
+// NewHandler returns a Handler configured by the options.
+// The handler must have an intdash API, at least one notifier and the signature validation,
+// either WithSignatureValidator, WithSHA256Keys, WithSHA256KeySource or WithTenantKeys.
+// Unless WithProcessors is given, the measurement events are processed by the built-in processors.
+// The fields of the handler not covered by the options may be set before it is used.
+func NewHandler(opts ...Option) (*Handler, error) {
+	h := &Handler{}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.IntdashAPI == nil {
+		return nil, fmt.Errorf("intdash API is not set")
+	}
+	if len(h.Notifiers) == 0 {
+		return nil, fmt.Errorf("no notifier is configured")
+	}
+	if h.SignatureValidator == nil && h.SHA256KeySource == nil && len(h.SHA256Keys) == 0 && len(h.TenantKeys) == 0 {
+		return nil, fmt.Errorf("signature validation is not configured")
+	}
+	if h.Processors == nil {
+		h.Processors = NewProcessorRegistry()
+		h.Processors.Register("measurement", "created", ProcessorFunc(h.ProcessMeasurementCreated))
+		h.Processors.Register("measurement", "updated", ProcessorFunc(h.ProcessMeasurementUpdated))
+		h.Processors.Register("measurement", "finished", ProcessorFunc(h.ProcessMeasurementFinished))
+		h.Processors.Register("measurement", "deleted", ProcessorFunc(h.ProcessMeasurementDeleted))
+	}
+	return h, nil
+}
+
+// WithIntdashAPI sets the client of the intdash API, e.g. an intdash.Client or an IntdashAPIStub.
+func WithIntdashAPI(api IntdashAPI) Option {
+	return func(h *Handler) {
+		h.IntdashAPI = api
+	}
+}
+
+// WithNotifiers adds the notifiers.
+func WithNotifiers(notifiers ...notify.Notifier) Option {
+	return func(h *Handler) {
+		h.Notifiers = append(h.Notifiers, notifiers...)
+	}
+}
+
+// WithChannels adds the channels analyzed for a finished measurement.
+func WithChannels(channels ...*Channel) Option {
+	return func(h *Handler) {
+		h.Channels = append(h.Channels, channels...)
+	}
+}
+
+// WithSignatureValidator replaces the built-in signature validation with v.
+func WithSignatureValidator(v SignatureValidator) Option {
+	return func(h *Handler) {
+		h.SignatureValidator = v
+	}
+}
+
+// WithSHA256Keys sets the HMAC keys of the built-in signature validation, tried in order.
+func WithSHA256Keys(keys ...[]byte) Option {
+	return func(h *Handler) {
+		h.SHA256Keys = keys
+	}
+}
+
+// WithSignatureAlgorithm sets the HMAC algorithm of the built-in signature validation, e.g. webhook.SHA512.
+func WithSignatureAlgorithm(algorithm *webhook.Algorithm) Option {
+	return func(h *Handler) {
+		h.SignatureAlgorithm = algorithm
+	}
+}
+
+// WithSHA256KeySource sets the source of the HMAC keys of the built-in signature validation.
+func WithSHA256KeySource(source webhook.KeySource) Option {
+	return func(h *Handler) {
+		h.SHA256KeySource = source
+	}
+}
+
+// WithTenantKeys sets the HMAC keys of each tenant, taken from source, or the header DefaultTenantHeader if nil.
+func WithTenantKeys(source *TenantSource, keys map[string]webhook.KeySource) Option {
+	return func(h *Handler) {
+		h.TenantSource = source
+		h.TenantKeys = keys
+	}
+}
+
+// WithRoutes adds the routes of the projects or the edges.
+func WithRoutes(routes ...*Route) Option {
+	return func(h *Handler) {
+		h.Routes = append(h.Routes, routes...)
+	}
+}
+
+// WithProcessors replaces the built-in processors of the events.
+func WithProcessors(processors *ProcessorRegistry) Option {
+	return func(h *Handler) {
+		h.Processors = processors
+	}
+}
+
+// WithClock sets the clock of the handler, e.g. a fixed time in tests.
+func WithClock(now func() time.Time) Option {
+	return func(h *Handler) {
+		h.Clock = now
+	}
+}
+
+// WithLogger sets the logger of the handler.
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *Handler) {
+		h.Logger = logger
+	}
+}
+
+// WithTracer sets the tracer of the processing stages.
+func WithTracer(tracer Tracer) Option {
+	return func(h *Handler) {
+		h.Tracer = tracer
+	}
+}
+
+// now returns the current time of h.Clock.
+func (h *Handler) now() time.Time {
+	if h.Clock == nil {
+		return time.Now()
+	}
+	return h.Clock()
+}
+
+// logger returns h.Logger, or the default logger if not set.
+func (h *Handler) logger() *slog.Logger {
+	if h.Logger == nil {
+		return slog.Default()
+	}
+	return h.Logger
+}
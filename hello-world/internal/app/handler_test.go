@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"hello-world/pkg/notify"
+	"hello-world/pkg/webhook"
+)
+
+// recordingArchive records the archived results.
+type recordingArchive struct {
+	mu      sync.Mutex
+	results []*AnalysisResult
+}
+
+func (a *recordingArchive) Archive(ctx context.Context, result *AnalysisResult) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.results = append(a.results, result)
+	return nil
+}
+
+func TestProcessMeasurementFinishedDryRun(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		dryRun       bool
+		wantArchived int
+		wantNotified int
+	}{
+		{name: "processed", wantArchived: 1, wantNotified: 1},
+		{name: "dry run", dryRun: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := &recordingArchive{}
+			notifier := &countingNotifier{name: "sns"}
+			h := &Handler{
+				IntdashAPI:     &IntdashAPIStub{Generator: &StubDataGenerator{Mean: 100, StdDev: 15, Count: 10}},
+				Channels:       []*Channel{{DataID: "float64:speed"}},
+				ResultArchives: []ResultArchive{archive},
+				Notifiers:      []notify.Notifier{notifier},
+				DryRun:         tt.dryRun,
+			}
+			body := &webhook.Body{
+				ResourceType:    webhook.ResourceTypeMeasurement,
+				Action:          webhook.ActionFinished,
+				MeasurementUUID: "00000000-0000-0000-0000-000000000000",
+			}
+			if err := h.ProcessMeasurementFinished(context.Background(), body); err != nil {
+				t.Fatalf("ProcessMeasurementFinished() error = %v", err)
+			}
+			if len(archive.results) != tt.wantArchived {
+				t.Errorf("archived %d results, want %d", len(archive.results), tt.wantArchived)
+			}
+			if notifier.count != tt.wantNotified {
+				t.Errorf("notified %d times, want %d", notifier.count, tt.wantNotified)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// DefaultDeliveryIDHeader is the default name of the header that contains the delivery ID.
+	DefaultDeliveryIDHeader = "x-intdash-delivery-id"
+	// DefaultIdempotencyTTL is the default period a processed delivery ID is remembered.
+	DefaultIdempotencyTTL = 24 * time.Hour
+)
+
+type (
+	// IdempotencyStore records processed delivery IDs so that retried deliveries are processed only once.
+	IdempotencyStore interface {
+		// Claim records the given delivery ID. It returns false if the ID has already been recorded.
+		Claim(ctx context.Context, deliveryID string) (bool, error)
+		// Release removes the given delivery ID, so that a retried delivery is processed again.
+		Release(ctx context.Context, deliveryID string) error
+	}
+
+	DynamoDBAPI interface {
+		PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+		DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+		Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+		BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	}
+)
+
+// DynamoDBIdempotencyStore is an IdempotencyStore backed by a DynamoDB table.
+// The table must have the string partition key "delivery_id", and TTL enabled on "expires_at".
+type DynamoDBIdempotencyStore struct {
+	DynamoDBAPI DynamoDBAPI
+	TableName   string
+	TTL         time.Duration
+}
+
+// Claim puts the delivery ID with a condition that it does not exist yet.
+func (s *DynamoDBIdempotencyStore) Claim(ctx context.Context, deliveryID string) (bool, error) {
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	now := time.Now()
+	_, err := s.DynamoDBAPI.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.TableName),
+		Item: map[string]types.AttributeValue{
+			"delivery_id": &types.AttributeValueMemberS{Value: deliveryID},
+			"claimed_at":  &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339)},
+			"expires_at":  &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(ttl).Unix(), 10)},
+		},
+		// An expired item may remain until DynamoDB deletes it, so it is treated as absent.
+		ConditionExpression: aws.String("attribute_not_exists(delivery_id) OR expires_at < :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return false, nil
+		}
+		return false, fmt.Errorf("put delivery ID: %w", err)
+	}
+	return true, nil
+}
+
+// Release deletes the delivery ID.
+func (s *DynamoDBIdempotencyStore) Release(ctx context.Context, deliveryID string) error {
+	_, err := s.DynamoDBAPI.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.TableName),
+		Key: map[string]types.AttributeValue{
+			"delivery_id": &types.AttributeValueMemberS{Value: deliveryID},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("delete delivery ID: %w", err)
+	}
+	return nil
+}
+
+// deliveryID returns the delivery ID of the given request.
+// If the delivery ID header is absent, the SHA-256 hash of the body is used instead,
+// since a retried delivery has the same body.
+func (h *Handler) deliveryID(request *webhookRequest) string {
+	header := h.DeliveryIDHeader
+	if header == "" {
+		header = DefaultDeliveryIDHeader
+	}
+	if v := request.header(header); v != "" {
+		return v
+	}
+	sum := sha256.Sum256([]byte(request.Body))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+type deliveryIDContextKey struct{}
+
+// withDeliveryID returns a context with the delivery ID being processed.
+func withDeliveryID(ctx context.Context, deliveryID string) context.Context {
+	return context.WithValue(ctx, deliveryIDContextKey{}, deliveryID)
+}
+
+// deliveryIDFromContext returns the delivery ID being processed, or "" if unknown.
+func deliveryIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(deliveryIDContextKey{}).(string)
+	return id
+}
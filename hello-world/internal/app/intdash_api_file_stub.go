@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hello-world/pkg/intdash"
+)
+
+// IntdashAPIFileStub is an IntdashAPI implementation for local testing that serves the data points of a file.
+// The file is re-read when it is modified, so the data can be edited while the handler is running.
+//
+// A ".json" file is an array of {"data_id": "float64:speed", "elapsed": 1.5, "value": 100}.
+// Any other file is CSV with the header row "elapsed,value" and an optional "data_id" column.
+// elapsed is the elapsed time in seconds from the start of the measurement.
+// The data points without a data ID are returned for any data ID.
+// The measurement ends at the current time, truncated to seconds, and lasts until the last data point.
+type IntdashAPIFileStub struct {
+	IntdashAPIStub
+	Path string
+
+	mu         sync.Mutex
+	modTime    time.Time
+	dataPoints []stubDataPoint
+}
+
+// stubDataPoint is a data point of the file of IntdashAPIFileStub.
+type stubDataPoint struct {
+	DataID  string  `json:"data_id"`
+	Elapsed float64 `json:"elapsed"`
+	Value   float64 `json:"value"`
+}
+
+// FetchMeasurement returns a measurement that ends at the current time, truncated to seconds,
+// and lasts until the last data point of the file.
+func (s *IntdashAPIFileStub) FetchMeasurement(ctx context.Context, measurementUUID string) (*intdash.Measurement, error) {
+	dps, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var duration time.Duration
+	for _, dp := range dps {
+		if d := stubElapsed(dp.Elapsed); d > duration {
+			duration = d
+		}
+	}
+	return &intdash.Measurement{
+		UUID:     measurementUUID,
+		Name:     "stub",
+		Basetime: time.Now().Truncate(time.Second).Add(-duration),
+		Duration: duration,
+	}, nil
+}
+
+// FetchFloat64DataPoints returns the data points of the file of the data ID in the time range.
+func (s *IntdashAPIFileStub) FetchFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr intdash.TimeRange) ([]intdash.DataPoint, error) {
+	m, err := s.FetchMeasurement(ctx, measurementUUID)
+	if err != nil {
+		return nil, err
+	}
+	dps, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var res []intdash.DataPoint
+	for _, v := range dps {
+		if v.DataID != "" && v.DataID != dataID {
+			continue
+		}
+		dp := intdash.DataPoint{
+			Time:  m.Basetime.Add(stubElapsed(v.Elapsed)),
+			Value: v.Value,
+		}
+		if (!tr.Start.IsZero() && dp.Time.Before(tr.Start)) || (!tr.End.IsZero() && !dp.Time.Before(tr.End)) {
+			continue
+		}
+		res = append(res, dp)
+	}
+	return res, nil
+}
+
+// load returns the data points of the file, re-reading it if it has been modified since the last read.
+func (s *IntdashAPIFileStub) load(ctx context.Context) ([]stubDataPoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("stat stub data: %w", err)
+	}
+	if s.dataPoints != nil && info.ModTime().Equal(s.modTime) {
+		return s.dataPoints, nil
+	}
+
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("open stub data: %w", err)
+	}
+	defer f.Close()
+	var dps []stubDataPoint
+	if strings.EqualFold(filepath.Ext(s.Path), ".json") {
+		if err := json.NewDecoder(f).Decode(&dps); err != nil {
+			return nil, fmt.Errorf("decode stub data: %w", err)
+		}
+	} else {
+		if dps, err = readStubCSV(f); err != nil {
+			return nil, fmt.Errorf("read stub data: %w", err)
+		}
+	}
+	if dps == nil {
+		dps = []stubDataPoint{}
+	}
+	s.dataPoints = dps
+	s.modTime = info.ModTime()
+	slog.InfoContext(ctx, "Loaded stub data", "path", s.Path, "data_points", len(dps))
+	return dps, nil
+}
+
+// readStubCSV reads the CSV of the data points with the header row.
+func readStubCSV(r io.Reader) ([]stubDataPoint, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	elapsedColumn, ok := columns["elapsed"]
+	if !ok {
+		return nil, fmt.Errorf("elapsed column is not found")
+	}
+	valueColumn, ok := columns["value"]
+	if !ok {
+		return nil, fmt.Errorf("value column is not found")
+	}
+	dataIDColumn, hasDataID := columns["data_id"]
+
+	dps := make([]stubDataPoint, 0, len(records)-1)
+	for i, record := range records[1:] {
+		var dp stubDataPoint
+		if dp.Elapsed, err = strconv.ParseFloat(strings.TrimSpace(record[elapsedColumn]), 64); err != nil {
+			return nil, fmt.Errorf("line %d: parse elapsed: %w", i+2, err)
+		}
+		if dp.Value, err = strconv.ParseFloat(strings.TrimSpace(record[valueColumn]), 64); err != nil {
+			return nil, fmt.Errorf("line %d: parse value: %w", i+2, err)
+		}
+		if hasDataID {
+			dp.DataID = strings.TrimSpace(record[dataIDColumn])
+		}
+		dps = append(dps, dp)
+	}
+	return dps, nil
+}
+
+// stubElapsed converts the elapsed time in seconds to a duration.
+func stubElapsed(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"time"
+
+	"hello-world/pkg/intdash"
+)
+
+const (
+	// StubDistributionNormal generates values from the normal distribution.
+	StubDistributionNormal = "normal"
+	// StubDistributionUniform generates values from the uniform distribution of the same mean and standard deviation.
+	StubDistributionUniform = "uniform"
+	// StubDistributionSawtooth generates a sawtooth wave of the same mean and standard deviation.
+	StubDistributionSawtooth = "sawtooth"
+)
+
+// IntdashAPIStub is an IntdashAPI implementation for local testing.
+// It does not call the intdash API.
+type IntdashAPIStub struct {
+	// Generator generates the data points. Defaults to DefaultStubDataGenerator().
+	Generator *StubDataGenerator
+}
+
+// StubDataGenerator generates the data points of IntdashAPIStub.
+type StubDataGenerator struct {
+	// Distribution is StubDistributionNormal, StubDistributionUniform or StubDistributionSawtooth.
+	// Defaults to StubDistributionNormal.
+	Distribution string
+	Mean         float64
+	StdDev       float64
+	// Count is the number of the data points of a measurement. Defaults to 1000.
+	Count int
+	// Interval is the interval of the data points. Defaults to 10ms.
+	Interval time.Duration
+	// Period is the number of the data points of a period of the sawtooth wave. Defaults to 100.
+	Period int
+	Seed   int64
+	// Outliers is the number of the data points replaced with Mean + OutlierSigma * StdDev, evenly spaced.
+	Outliers int
+	// OutlierSigma is the deviation of the outliers in standard deviations. Defaults to 10.
+	OutlierSigma float64
+	// NaNs is the number of the data points replaced with NaN, evenly spaced.
+	NaNs int
+}
+
+// DefaultStubDataGenerator returns the generator of 1000 data points 10ms apart
+// from the normal distribution (mean = 100, stddev = 15) with the seed 0.
+func DefaultStubDataGenerator() *StubDataGenerator {
+	return &StubDataGenerator{Distribution: StubDistributionNormal, Mean: 100, StdDev: 15}
+}
+
+func (g *StubDataGenerator) count() int {
+	if g.Count <= 0 {
+		return 1000
+	}
+	return g.Count
+}
+
+func (g *StubDataGenerator) interval() time.Duration {
+	if g.Interval <= 0 {
+		return 10 * time.Millisecond
+	}
+	return g.Interval
+}
+
+// values generates the values of the data points.
+func (g *StubDataGenerator) values() ([]float64, error) {
+	n := g.count()
+	values := make([]float64, n)
+	r := rand.New(rand.NewSource(g.Seed))
+	// The half width of the uniform distribution and the sawtooth wave of the standard deviation.
+	halfWidth := g.StdDev * math.Sqrt(3)
+	switch g.Distribution {
+	case StubDistributionNormal, "":
+		for i := range values {
+			values[i] = r.NormFloat64()*g.StdDev + g.Mean
+		}
+	case StubDistributionUniform:
+		for i := range values {
+			values[i] = g.Mean + (r.Float64()*2-1)*halfWidth
+		}
+	case StubDistributionSawtooth:
+		period := g.Period
+		if period <= 0 {
+			period = 100
+		}
+		for i := range values {
+			values[i] = g.Mean + (float64(i%period)/float64(period)*2-1)*halfWidth
+		}
+	default:
+		return nil, fmt.Errorf("unknown stub distribution %q", g.Distribution)
+	}
+
+	sigma := g.OutlierSigma
+	if sigma == 0 {
+		sigma = 10
+	}
+	outliers := map[int]bool{}
+	for k := 0; k < g.Outliers && k < n; k++ {
+		i := (k + 1) * n / (g.Outliers + 1)
+		values[i] = g.Mean + sigma*g.StdDev
+		outliers[i] = true
+	}
+	// A NaN is moved to the next position if its position has an outlier or a NaN, so that all of them are injected.
+	for k := 0; k < g.NaNs && k < n-len(outliers); k++ {
+		i := (k + 1) * n / (g.NaNs + 1)
+		for outliers[i%n] || math.IsNaN(values[i%n]) {
+			i++
+		}
+		values[i%n] = math.NaN()
+	}
+	return values, nil
+}
+
+func (s *IntdashAPIStub) generator() *StubDataGenerator {
+	if s.Generator == nil {
+		return DefaultStubDataGenerator()
+	}
+	return s.Generator
+}
+
+// FetchMeasurement returns a measurement that ends at the current time, truncated to seconds.
+func (s *IntdashAPIStub) FetchMeasurement(ctx context.Context, measurementUUID string) (*intdash.Measurement, error) {
+	g := s.generator()
+	duration := time.Duration(g.count()) * g.interval()
+	return &intdash.Measurement{
+		UUID:     measurementUUID,
+		Name:     "stub",
+		Basetime: time.Now().Truncate(time.Second).Add(-duration),
+		Duration: duration,
+	}, nil
+}
+
+// FetchFloat64DataPoints generates float64 data points with the generator, the same for any data ID.
+// The data points are spaced by the interval in the measurement returned by FetchMeasurement,
+// and only those in the time range are returned.
+func (s *IntdashAPIStub) FetchFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr intdash.TimeRange) ([]intdash.DataPoint, error) {
+	m, err := s.FetchMeasurement(ctx, measurementUUID)
+	if err != nil {
+		return nil, err
+	}
+	g := s.generator()
+	values, err := g.values()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]intdash.DataPoint, 0, len(values))
+	for i, v := range values {
+		dp := intdash.DataPoint{
+			Time:  m.Basetime.Add(time.Duration(i+1) * g.interval()),
+			Value: v,
+		}
+		if (!tr.Start.IsZero() && dp.Time.Before(tr.Start)) || (!tr.End.IsZero() && !dp.Time.Before(tr.End)) {
+			continue
+		}
+		res = append(res, dp)
+	}
+	return res, nil
+}
+
+// UpdateMeasurementTags only logs the tags.
+func (s *IntdashAPIStub) UpdateMeasurementTags(ctx context.Context, measurementUUID string, tags map[string]string) error {
+	slog.InfoContext(ctx, "Stub: updated measurement tags", "tags", tags)
+	return nil
+}
+
+// CreateMeasurementMarker only logs the marker.
+func (s *IntdashAPIStub) CreateMeasurementMarker(ctx context.Context, measurementUUID string, marker *intdash.MeasurementMarker) error {
+	slog.InfoContext(ctx, "Stub: created measurement marker", "name", marker.Name, "start", marker.Start, "end", marker.End)
+	return nil
+}
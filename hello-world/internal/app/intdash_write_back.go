@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"hello-world/pkg/intdash"
+)
+
+// IntdashWriteBackAPI is the intdash API to write the analysis results back to the measurements.
+type IntdashWriteBackAPI interface {
+	FetchMeasurement(ctx context.Context, measurementUUID string) (*intdash.Measurement, error)
+	// UpdateMeasurementTags replaces the tags of the measurement.
+	UpdateMeasurementTags(ctx context.Context, measurementUUID string, tags map[string]string) error
+	CreateMeasurementMarker(ctx context.Context, measurementUUID string, marker *intdash.MeasurementMarker) error
+}
+
+// IntdashWriteBack writes the analysis results back to the measurement in intdash,
+// so that the results are visible in intdash itself.
+type IntdashWriteBack struct {
+	API IntdashWriteBackAPI
+	// TagStatistics are the statistics written as measurement tags, e.g. "average=98.2".
+	// If there are multiple channels, the tags are prefixed with the data IDs, e.g. "float64:speed.average=98.2".
+	// The tag "anomaly" is also written, "true" if any alert rule fired.
+	TagStatistics []string
+	// Markers creates a span marker over the analyzed data points of each channel with fired alert rules.
+	Markers bool
+}
+
+// WriteBack writes the results of the measurement back to intdash.
+// The measurement is fetched if m is nil.
+func (w *IntdashWriteBack) WriteBack(ctx context.Context, measurementUUID string, m *intdash.Measurement, results []*AnalysisResult) error {
+	if m == nil {
+		var err error
+		if m, err = w.API.FetchMeasurement(ctx, measurementUUID); err != nil {
+			return fmt.Errorf("fetch measurement: %w", err)
+		}
+	}
+
+	if len(w.TagStatistics) > 0 {
+		tags := map[string]string{}
+		// Keep the existing tags.
+		for k, v := range m.Tags {
+			tags[k] = v
+		}
+		anomaly := false
+		for _, result := range results {
+			prefix := ""
+			if len(results) > 1 {
+				prefix = result.DataID + "."
+			}
+			for _, name := range w.TagStatistics {
+				tags[prefix+name] = strconv.FormatFloat(result.Statistics.Value(name), 'g', 6, 64)
+			}
+			anomaly = anomaly || len(result.Alerts) > 0
+		}
+		tags["anomaly"] = strconv.FormatBool(anomaly)
+		if err := w.API.UpdateMeasurementTags(ctx, measurementUUID, tags); err != nil {
+			return fmt.Errorf("update measurement tags: %w", err)
+		}
+		slog.InfoContext(ctx, "Wrote measurement tags", "tags", len(tags))
+	}
+
+	if w.Markers {
+		for _, result := range results {
+			if len(result.Alerts) == 0 || len(result.DataPoints) == 0 {
+				continue
+			}
+			marker := &intdash.MeasurementMarker{
+				Name:        fmt.Sprintf("Alert: %s", result.DataID),
+				Description: strings.Join(result.Alerts, ", "),
+				Start:       result.DataPoints[0].Time.Sub(m.Basetime),
+				End:         result.DataPoints[len(result.DataPoints)-1].Time.Sub(m.Basetime),
+			}
+			if err := w.API.CreateMeasurementMarker(ctx, measurementUUID, marker); err != nil {
+				return fmt.Errorf("create marker of %s: %w", result.DataID, err)
+			}
+			slog.InfoContext(ctx, "Created measurement marker", "name", marker.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+//go:build integration
+
+// The integration tests run the handler end-to-end against SNS, SQS, DynamoDB and Secrets Manager of LocalStack:
+//
+//	go test -tags=integration -v ./...
+//
+// A LocalStack container is started with docker, unless LOCALSTACK_ENDPOINT is set, e.g. "http://localhost:4566".
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"hello-world/pkg/webhook"
+)
+
+const (
+	localStackImage = "localstack/localstack:3"
+	// integrationSecret is the webhook secret stored in Secrets Manager.
+	integrationSecret = "integration-secret"
+)
+
+// integrationEnv is the AWS resources created in LocalStack for the tests.
+type integrationEnv struct {
+	awsCfg aws.Config
+	// notifyQueueURL is the queue subscribed to the SNS topic with raw message delivery.
+	notifyQueueURL string
+	// eventQueueURL is the queue of the events in the queue mode.
+	eventQueueURL  string
+	auditTableName string
+}
+
+var integration *integrationEnv
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegrationTests(m))
+}
+
+func runIntegrationTests(m *testing.M) int {
+	endpoint := os.Getenv("LOCALSTACK_ENDPOINT")
+	if endpoint == "" {
+		var (
+			stop func()
+			err  error
+		)
+		endpoint, stop, err = startLocalStack()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to start LocalStack:", err)
+			return 1
+		}
+		defer stop()
+	}
+
+	for k, v := range map[string]string{
+		"AWS_ENDPOINT_URL":      endpoint,
+		"AWS_REGION":            "us-east-1",
+		"AWS_ACCESS_KEY_ID":     "test",
+		"AWS_SECRET_ACCESS_KEY": "test",
+	} {
+		os.Setenv(k, v)
+	}
+	ctx := context.Background()
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load AWS config:", err)
+		return 1
+	}
+	env, err := setUpIntegrationEnv(ctx, awsCfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to set up LocalStack resources:", err)
+		return 1
+	}
+	integration = env
+	return m.Run()
+}
+
+// startLocalStack starts a LocalStack container and waits until it is ready.
+// It returns the endpoint and the function to stop the container.
+func startLocalStack() (string, func(), error) {
+	out, err := exec.Command("docker", "run", "-d", "--rm", "-p", "127.0.0.1::4566",
+		"-e", "SERVICES=sns,sqs,dynamodb,secretsmanager", localStackImage).Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("docker run: %w", err)
+	}
+	id := strings.TrimSpace(string(out))
+	stop := func() { exec.Command("docker", "stop", id).Run() }
+
+	out, err = exec.Command("docker", "port", id, "4566/tcp").Output()
+	if err != nil {
+		stop()
+		return "", nil, fmt.Errorf("docker port: %w", err)
+	}
+	endpoint := "http://" + strings.TrimSpace(strings.Split(string(out), "\n")[0])
+
+	deadline := time.Now().Add(90 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(endpoint + "/_localstack/health")
+		if err == nil {
+			var health struct {
+				Services map[string]string `json:"services"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&health)
+			resp.Body.Close()
+			if err == nil && localStackReady(health.Services) {
+				return endpoint, stop, nil
+			}
+		}
+		time.Sleep(time.Second)
+	}
+	stop()
+	return "", nil, fmt.Errorf("LocalStack is not ready at %s", endpoint)
+}
+
+// localStackReady reports whether all the services used by the tests are available.
+func localStackReady(services map[string]string) bool {
+	for _, name := range []string{"sns", "sqs", "dynamodb", "secretsmanager"} {
+		if s := services[name]; s != "available" && s != "running" {
+			return false
+		}
+	}
+	return true
+}
+
+// setUpIntegrationEnv creates the resources of the tests, and sets the environment variables of the handler.
+// The names have a unique suffix, so that the tests can be run repeatedly against the same LocalStack.
+func setUpIntegrationEnv(ctx context.Context, awsCfg aws.Config) (*integrationEnv, error) {
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	env := &integrationEnv{awsCfg: awsCfg, auditTableName: "audit-" + suffix}
+	sqsClient := sqs.NewFromConfig(awsCfg)
+	snsClient := sns.NewFromConfig(awsCfg)
+
+	topic, err := snsClient.CreateTopic(ctx, &sns.CreateTopicInput{Name: aws.String("notify-" + suffix)})
+	if err != nil {
+		return nil, fmt.Errorf("create topic: %w", err)
+	}
+	for name, url := range map[string]*string{"notify-" + suffix: &env.notifyQueueURL, "events-" + suffix: &env.eventQueueURL} {
+		queue, err := sqsClient.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String(name)})
+		if err != nil {
+			return nil, fmt.Errorf("create queue: %w", err)
+		}
+		*url = aws.ToString(queue.QueueUrl)
+	}
+	attrs, err := sqsClient.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(env.notifyQueueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get queue attributes: %w", err)
+	}
+	_, err = snsClient.Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn:   topic.TopicArn,
+		Protocol:   aws.String("sqs"),
+		Endpoint:   aws.String(attrs.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]),
+		Attributes: map[string]string{"RawMessageDelivery": "true"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("subscribe: %w", err)
+	}
+
+	dynamodbClient := dynamodb.NewFromConfig(awsCfg)
+	idempotencyTableName := "idempotency-" + suffix
+	for name, keys := range map[string][]string{
+		idempotencyTableName: {"delivery_id"},
+		env.auditTableName:   {"measurement_uuid", "sk"},
+	} {
+		input := &dynamodb.CreateTableInput{
+			TableName:   aws.String(name),
+			BillingMode: dynamodbtypes.BillingModePayPerRequest,
+		}
+		for i, key := range keys {
+			keyType := dynamodbtypes.KeyTypeHash
+			if i > 0 {
+				keyType = dynamodbtypes.KeyTypeRange
+			}
+			input.KeySchema = append(input.KeySchema, dynamodbtypes.KeySchemaElement{AttributeName: aws.String(key), KeyType: keyType})
+			input.AttributeDefinitions = append(input.AttributeDefinitions, dynamodbtypes.AttributeDefinition{
+				AttributeName: aws.String(key),
+				AttributeType: dynamodbtypes.ScalarAttributeTypeS,
+			})
+		}
+		if _, err := dynamodbClient.CreateTable(ctx, input); err != nil {
+			return nil, fmt.Errorf("create table %s: %w", name, err)
+		}
+	}
+
+	secret, err := secretsmanager.NewFromConfig(awsCfg).CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+		Name:         aws.String("webhook-secret-" + suffix),
+		SecretString: aws.String(integrationSecret),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create secret: %w", err)
+	}
+
+	for k, v := range map[string]string{
+		"SNS_TOPIC_ARN":          aws.ToString(topic.TopicArn),
+		"INTDASH_API_STUB":       "true",
+		"INTDASH_DATA_ID":        "float64:speed",
+		"WEBHOOK_SECRET_ID":      aws.ToString(secret.ARN),
+		"IDEMPOTENCY_TABLE_NAME": idempotencyTableName,
+		"AUDIT_TABLE_NAME":       env.auditTableName,
+	} {
+		os.Setenv(k, v)
+	}
+	return env, nil
+}
+
+// newIntegrationHandler provides the handler from the environment variables, as the function does.
+func newIntegrationHandler(t *testing.T) *Handler {
+	t.Helper()
+	h, err := ProvideHandler(Environment{})
+	if err != nil {
+		t.Fatalf("ProvideHandler() error = %v", err)
+	}
+	return h
+}
+
+// newSignedRequest returns a request of the measurement finished event signed with the secret.
+func newSignedRequest(measurementUUID, deliveryID, secret string) *webhookRequest {
+	body := fmt.Sprintf(`{"resource_type":"measurement","action":"finished","measurement_uuid":%q}`, measurementUUID)
+	return &webhookRequest{
+		Headers: map[string]string{
+			webhook.SignatureHeader: base64.StdEncoding.EncodeToString(webhook.Sign([]byte(secret), []byte(body))),
+			DefaultDeliveryIDHeader: deliveryID,
+		},
+		Body: body,
+	}
+}
+
+// uniqueID returns a random UUID unique to the test, used as the measurement UUID and the delivery ID.
+func uniqueID(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	b[6], b[8] = b[6]&0x0f|0x40, b[8]&0x3f|0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// receiveNotifications returns the bodies of the SNS messages of the measurement, received within wait.
+func receiveNotifications(t *testing.T, measurementUUID string, wait time.Duration) []string {
+	t.Helper()
+	ctx := context.Background()
+	client := sqs.NewFromConfig(integration.awsCfg)
+	var bodies []string
+	for deadline := time.Now().Add(wait); time.Now().Before(deadline); {
+		out, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(integration.notifyQueueURL),
+			MaxNumberOfMessages:   10,
+			WaitTimeSeconds:       1,
+			MessageAttributeNames: []string{"All"},
+		})
+		if err != nil {
+			t.Fatalf("ReceiveMessage() error = %v", err)
+		}
+		for _, msg := range out.Messages {
+			attr, ok := msg.MessageAttributes["measurement_uuid"]
+			if !ok || aws.ToString(attr.StringValue) != measurementUUID {
+				continue
+			}
+			bodies = append(bodies, aws.ToString(msg.Body))
+			if _, err := client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(integration.notifyQueueURL),
+				ReceiptHandle: msg.ReceiptHandle,
+			}); err != nil {
+				t.Fatalf("DeleteMessage() error = %v", err)
+			}
+		}
+	}
+	return bodies
+}
+
+// auditOutcomes returns the outcomes of the audit records of the measurement in time order.
+func auditOutcomes(t *testing.T, measurementUUID string) []string {
+	t.Helper()
+	out, err := dynamodb.NewFromConfig(integration.awsCfg).Query(context.Background(), &dynamodb.QueryInput{
+		TableName:                 aws.String(integration.auditTableName),
+		KeyConditionExpression:    aws.String("measurement_uuid = :m"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{":m": &dynamodbtypes.AttributeValueMemberS{Value: measurementUUID}},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	var outcomes []string
+	for _, item := range out.Items {
+		if v, ok := item["outcome"].(*dynamodbtypes.AttributeValueMemberS); ok {
+			outcomes = append(outcomes, v.Value)
+		}
+	}
+	return outcomes
+}
+
+func TestIntegrationPublish(t *testing.T) {
+	h := newIntegrationHandler(t)
+	id := uniqueID(t)
+
+	res := h.handleWebhook(context.Background(), newSignedRequest(id, id, integrationSecret))
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatalf("StatusCode = %d, want %d, body %s", res.StatusCode, http.StatusNoContent, res.Body)
+	}
+
+	bodies := receiveNotifications(t, id, 5*time.Second)
+	if len(bodies) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(bodies))
+	}
+	if !strings.Contains(bodies[0], "Average:") {
+		t.Errorf("notification = %q, want the statistics", bodies[0])
+	}
+	if got := auditOutcomes(t, id); len(got) != 1 || got[0] != AuditOutcomeNotified {
+		t.Errorf("audit outcomes = %v, want [%s]", got, AuditOutcomeNotified)
+	}
+}
+
+func TestIntegrationInvalidSignature(t *testing.T) {
+	h := newIntegrationHandler(t)
+	id := uniqueID(t)
+
+	res := h.handleWebhook(context.Background(), newSignedRequest(id, id, "wrong-secret"))
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("StatusCode = %d, want %d", res.StatusCode, http.StatusBadRequest)
+	}
+	var body ErrorResponseBody
+	if err := json.Unmarshal([]byte(res.Body), &body); err != nil {
+		t.Fatalf("unmarshal response body: %v", err)
+	}
+	if body.Code != ErrorCodeInvalidSignature {
+		t.Errorf("code = %q, want %q", body.Code, ErrorCodeInvalidSignature)
+	}
+
+	if bodies := receiveNotifications(t, id, 3*time.Second); len(bodies) != 0 {
+		t.Errorf("got %d notifications, want none", len(bodies))
+	}
+	// The body is not trusted without the valid signature, so the record is of the unknown measurement.
+	if got := auditOutcomes(t, id); len(got) != 0 {
+		t.Errorf("audit outcomes = %v, want none", got)
+	}
+}
+
+func TestIntegrationDuplicateDelivery(t *testing.T) {
+	h := newIntegrationHandler(t)
+	id := uniqueID(t)
+
+	for i := 0; i < 2; i++ {
+		res := h.handleWebhook(context.Background(), newSignedRequest(id, id, integrationSecret))
+		if res.StatusCode != http.StatusNoContent {
+			t.Fatalf("delivery %d: StatusCode = %d, want %d, body %s", i, res.StatusCode, http.StatusNoContent, res.Body)
+		}
+	}
+
+	if bodies := receiveNotifications(t, id, 5*time.Second); len(bodies) != 1 {
+		t.Errorf("got %d notifications, want 1", len(bodies))
+	}
+	got := auditOutcomes(t, id)
+	if len(got) != 2 || got[0] != AuditOutcomeNotified || got[1] != AuditOutcomeDuplicate {
+		t.Errorf("audit outcomes = %v, want [%s %s]", got, AuditOutcomeNotified, AuditOutcomeDuplicate)
+	}
+}
+
+func TestIntegrationQueueMode(t *testing.T) {
+	h := newIntegrationHandler(t)
+	client := sqs.NewFromConfig(integration.awsCfg)
+	h.EventQueue = &SQSEventQueue{SQSSendMessageAPI: client, QueueURL: integration.eventQueueURL}
+	id := uniqueID(t)
+
+	res := h.handleWebhook(context.Background(), newSignedRequest(id, id, integrationSecret))
+	if res.StatusCode != http.StatusAccepted {
+		t.Fatalf("StatusCode = %d, want %d, body %s", res.StatusCode, http.StatusAccepted, res.Body)
+	}
+
+	out, err := client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(integration.eventQueueURL),
+		MaxNumberOfMessages: 10,
+		WaitTimeSeconds:     5,
+	})
+	if err != nil {
+		t.Fatalf("ReceiveMessage() error = %v", err)
+	}
+	var event events.SQSEvent
+	for _, msg := range out.Messages {
+		event.Records = append(event.Records, events.SQSMessage{
+			MessageId: aws.ToString(msg.MessageId),
+			Body:      aws.ToString(msg.Body),
+		})
+	}
+	if len(event.Records) != 1 {
+		t.Fatalf("got %d queued events, want 1", len(event.Records))
+	}
+
+	worker := newIntegrationHandler(t)
+	resp, err := worker.HandleSQS(context.Background(), event)
+	if err != nil {
+		t.Fatalf("HandleSQS() error = %v", err)
+	}
+	if len(resp.BatchItemFailures) != 0 {
+		t.Fatalf("HandleSQS() batch item failures = %v, want none", resp.BatchItemFailures)
+	}
+	if bodies := receiveNotifications(t, id, 5*time.Second); len(bodies) != 1 {
+		t.Errorf("got %d notifications, want 1", len(bodies))
+	}
+	got := auditOutcomes(t, id)
+	if len(got) != 2 || got[0] != AuditOutcomeEnqueued || got[1] != AuditOutcomeNotified {
+		t.Errorf("audit outcomes = %v, want [%s %s]", got, AuditOutcomeEnqueued, AuditOutcomeNotified)
+	}
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// DefaultLogRedactKeys are the default keys whose values are redacted from the logs.
+var DefaultLogRedactKeys = []string{"signature", "authorization", "cookie", "secret", "password", "token", "api-key", "api_key"}
+
+// logRedacted replaces the redacted values.
+const logRedacted = "[REDACTED]"
+
+// NewLogger returns a logger that writes JSON lines to w at the given level, e.g. "debug", "info", "warn" or "error".
+// The level defaults to "info" if empty.
+// The records are annotated with the Lambda request ID, the delivery ID and the attributes of the context,
+// and the attributes redacted by redactor are replaced with "[REDACTED]".
+func NewLogger(w io.Writer, level string, redactor *LogRedactor) (*slog.Logger, error) {
+	var l slog.Level
+	if level != "" {
+		if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+			return nil, fmt.Errorf("parse log level: %w", err)
+		}
+	}
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: l, ReplaceAttr: redactor.ReplaceAttr})
+	return slog.New(&contextLogHandler{Handler: h}), nil
+}
+
+// LogRedactor redacts secrets, such as the signature and authorization headers, from the logs.
+// A key is redacted if it contains any of Keys, case-insensitively, e.g. "x-intdash-signature-256" by "signature".
+type LogRedactor struct {
+	Keys []string
+}
+
+// NewLogRedactor returns a LogRedactor of DefaultLogRedactKeys and the given extra keys, e.g. body fields.
+func NewLogRedactor(extraKeys ...string) *LogRedactor {
+	r := &LogRedactor{Keys: append([]string{}, DefaultLogRedactKeys...)}
+	for _, k := range extraKeys {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			r.Keys = append(r.Keys, k)
+		}
+	}
+	return r
+}
+
+// redacts reports whether the value of the key is redacted.
+func (r *LogRedactor) redacts(key string) bool {
+	if r == nil {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, k := range r.Keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceAttr redacts the attribute if its key is redacted. It is a slog.HandlerOptions.ReplaceAttr.
+func (r *LogRedactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+		return a
+	}
+	if r.redacts(a.Key) {
+		return slog.String(a.Key, logRedacted)
+	}
+	return a
+}
+
+// Headers returns the headers with the redacted values replaced, to be logged.
+func (r *LogRedactor) Headers(request *webhookRequest) map[string]string {
+	headers := map[string]string{}
+	for k, v := range request.Headers {
+		headers[k] = v
+	}
+	for k, vs := range request.MultiValueHeaders {
+		if len(vs) > 0 {
+			headers[k] = strings.Join(vs, ", ")
+		}
+	}
+	for k := range headers {
+		if r.redacts(k) {
+			headers[k] = logRedacted
+		}
+	}
+	return headers
+}
+
+// Body returns the JSON body with the values of the redacted fields replaced at any depth, to be logged.
+// A body that is not JSON is logged only by its size.
+func (r *LogRedactor) Body(body string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return fmt.Sprintf("(%d bytes, not JSON)", len(body))
+	}
+	return r.redactJSON(v)
+}
+
+// redactJSON replaces the values of the redacted fields of the decoded JSON.
+func (r *LogRedactor) redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if r.redacts(k) {
+				v[k] = logRedacted
+			} else {
+				v[k] = r.redactJSON(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = r.redactJSON(e)
+		}
+	}
+	return v
+}
+
+type logAttrsContextKey struct{}
+
+// withLogAttrs returns a context whose log records have the given attributes, e.g. "measurement_uuid" or "stage".
+// An attribute replaces the one of the same key already in the context.
+func withLogAttrs(ctx context.Context, args ...any) context.Context {
+	parent := logAttrsFromContext(ctx)
+	attrs := make([]slog.Attr, 0, len(parent)+len(args))
+	added := slog.Group("", args...).Value.Group()
+	for _, a := range parent {
+		replaced := false
+		for _, b := range added {
+			replaced = replaced || a.Key == b.Key
+		}
+		if !replaced {
+			attrs = append(attrs, a)
+		}
+	}
+	attrs = append(attrs, added...)
+	return context.WithValue(ctx, logAttrsContextKey{}, attrs)
+}
+
+// logAttrsFromContext returns the attributes added by withLogAttrs.
+func logAttrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(logAttrsContextKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextLogHandler is a slog.Handler that adds the correlation attributes of the context to the records.
+type contextLogHandler struct {
+	slog.Handler
+}
+
+// Handle adds "lambda_request_id", "delivery_id" and the attributes of withLogAttrs to the record.
+func (h *contextLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := lambdaRequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("lambda_request_id", id))
+	}
+	if id := deliveryIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("delivery_id", id))
+	}
+	r.AddAttrs(logAttrsFromContext(ctx)...)
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a contextLogHandler with the attributes.
+func (h *contextLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextLogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a contextLogHandler with the group.
+func (h *contextLogHandler) WithGroup(name string) slog.Handler {
+	return &contextLogHandler{Handler: h.Handler.WithGroup(name)}
+}
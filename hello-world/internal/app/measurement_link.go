@@ -0,0 +1,56 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"hello-world/pkg/webhook"
+)
+
+// DefaultMeasurementLinkTemplate is the default template of the link to a measurement in the intdash console.
+const DefaultMeasurementLinkTemplate = `{{.ConsoleURL}}/measurements/{{.MeasurementUUID}}{{with .ProjectUUID}}?projectUuid={{.}}{{end}}`
+
+// MeasurementLinkBuilder builds the deep links to the measurements in the intdash console,
+// e.g. Data Visualizer or Meas Hub, so that the recipients of a notification can open the data.
+type MeasurementLinkBuilder struct {
+	// ConsoleURL is the base URL of the console, e.g. "https://example.intdash.jp/console".
+	ConsoleURL string
+	// Template is executed with MeasurementLinkData to make the link.
+	Template *template.Template
+}
+
+// MeasurementLinkData is the data the link template is executed with.
+type MeasurementLinkData struct {
+	// ConsoleURL is the base URL of the console without the trailing slash.
+	ConsoleURL      string
+	MeasurementUUID string
+	ProjectUUID     string
+}
+
+// NewMeasurementLinkBuilder returns a MeasurementLinkBuilder with the template parsed from linkTemplate.
+// If linkTemplate is empty, DefaultMeasurementLinkTemplate is used.
+func NewMeasurementLinkBuilder(consoleURL, linkTemplate string) (*MeasurementLinkBuilder, error) {
+	if linkTemplate == "" {
+		linkTemplate = DefaultMeasurementLinkTemplate
+	}
+	tmpl, err := template.New("link").Parse(linkTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse link template: %w", err)
+	}
+	return &MeasurementLinkBuilder{ConsoleURL: consoleURL, Template: tmpl}, nil
+}
+
+// Link returns the link to the measurement of the event.
+func (b *MeasurementLinkBuilder) Link(event *webhook.Body) (string, error) {
+	var link strings.Builder
+	err := b.Template.Execute(&link, &MeasurementLinkData{
+		ConsoleURL:      strings.TrimSuffix(b.ConsoleURL, "/"),
+		MeasurementUUID: event.MeasurementUUID,
+		ProjectUUID:     event.ProjectUUID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("execute link template: %w", err)
+	}
+	return link.String(), nil
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"hello-world/pkg/webhook"
+)
+
+// ResultStore stores measurement metadata and analysis results.
+type ResultStore interface {
+	// SaveMeasurement stores (or overwrites) the metadata of the measurement in the given event.
+	SaveMeasurement(ctx context.Context, body *webhook.Body) error
+	// SaveResult stores the analysis result of a measurement.
+	SaveResult(ctx context.Context, result *AnalysisResult) error
+	// DeleteResults deletes everything stored for the given measurement.
+	DeleteResults(ctx context.Context, measurementUUID string) error
+}
+
+// ProcessMeasurementCreated stores the metadata of the created measurement.
+func (h *Handler) ProcessMeasurementCreated(ctx context.Context, body *webhook.Body) error {
+	return h.saveMeasurement(ctx, body)
+}
+
+// ProcessMeasurementUpdated overwrites the stored metadata of the updated measurement.
+func (h *Handler) ProcessMeasurementUpdated(ctx context.Context, body *webhook.Body) error {
+	return h.saveMeasurement(ctx, body)
+}
+
+// ProcessMeasurementDeleted cleans up the stored results of the deleted measurement.
+func (h *Handler) ProcessMeasurementDeleted(ctx context.Context, body *webhook.Body) error {
+	if h.ResultStore == nil {
+		h.logger().InfoContext(ctx, "No result store is configured, nothing to clean up", "measurement_uuid", body.MeasurementUUID)
+		return nil
+	}
+	if err := h.ResultStore.DeleteResults(ctx, body.MeasurementUUID); err != nil {
+		return fmt.Errorf("delete results: %w", err)
+	}
+	return nil
+}
+
+func (h *Handler) saveMeasurement(ctx context.Context, body *webhook.Body) error {
+	if h.ResultStore == nil {
+		h.logger().InfoContext(ctx, "No result store is configured, skipped storing measurement", "measurement_uuid", body.MeasurementUUID)
+		return nil
+	}
+	if err := h.ResultStore.SaveMeasurement(ctx, body); err != nil {
+		return fmt.Errorf("save measurement: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hello-world/pkg/analyze"
+	"hello-world/pkg/intdash"
+	"hello-world/pkg/notify"
+	"hello-world/pkg/webhook"
+)
+
+// The golden files are the expected notification bodies under testdata/golden. After an intended change
+// of the format, regenerate them and review the diff:
+//
+//	go test -run TestNotificationGolden -update
+var update = flag.Bool("update", false, "update the golden files")
+
+// goldenBasetime is the basetime of the measurement of the golden tests.
+var goldenBasetime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// goldenDataPoints returns 1000 data points 10 ms apart: a sine wave of 5 Hz around 100,
+// a spike of 180 at 2 s, a NaN at 3 s and a gap of 500 ms from 6 s.
+func goldenDataPoints() []intdash.DataPoint {
+	var dps []intdash.DataPoint
+	for i := 0; i < 1000; i++ {
+		elapsed := time.Duration(i) * 10 * time.Millisecond
+		if elapsed >= 6*time.Second && elapsed < 6500*time.Millisecond {
+			continue
+		}
+		v := 100 + 20*math.Sin(2*math.Pi*5*elapsed.Seconds())
+		switch elapsed {
+		case 2 * time.Second:
+			v = 180
+		case 3 * time.Second:
+			v = math.NaN()
+		}
+		dps = append(dps, intdash.DataPoint{Time: goldenBasetime.Add(elapsed), Value: v})
+	}
+	return dps
+}
+
+// goldenCase is a combination of the channels and the notification options.
+type goldenCase struct {
+	name string
+	// config is the configuration of the channels, e.g. "CHANNELS" or "ANALYZERS".
+	config             map[string]string
+	includeMeasurement bool
+	link               string
+	partial            bool
+}
+
+var goldenCases = []goldenCase{
+	{
+		name:   "statistics",
+		config: map[string]string{"INTDASH_DATA_ID": "float64:speed"},
+	},
+	{
+		name: "alert",
+		config: map[string]string{
+			"INTDASH_DATA_ID": "float64:speed",
+			"ALERT_RULES":     "max > 150, average < 50",
+		},
+		link: "https://example.intdash.jp/console/measurements/00000000-0000-0000-0000-000000000000",
+	},
+	{
+		name: "histogram_outliers",
+		config: map[string]string{
+			"INTDASH_DATA_ID":   "float64:speed",
+			"ANALYZERS":         "statistics,histogram,outliers",
+			"HISTOGRAM_BUCKETS": "5",
+			"HISTOGRAM_BARS":    "true",
+		},
+	},
+	{
+		name: "crossings_gaps_fft",
+		config: map[string]string{
+			"INTDASH_DATA_ID":       "float64:speed",
+			"ANALYZERS":             "statistics,crossings,gaps,fft",
+			"CROSSING_THRESHOLDS":   "115",
+			"CROSSING_MAX_LISTED":   "3",
+			"GAP_EXPECTED_INTERVAL": "10ms",
+			"FFT_SAMPLING_RATE":     "100",
+			"FFT_TOP_N":             "2",
+		},
+	},
+	{
+		name: "channels",
+		config: map[string]string{
+			"CHANNELS": `[{"data_id":"float64:speed","unit":"km/h","alert_rules":"max > 150"},` +
+				`{"data_id":"float64:temperature","unit":"degC","config":{"STATISTICS":"min,max"}}]`,
+		},
+		includeMeasurement: true,
+		partial:            true,
+	},
+}
+
+// goldenResults analyzes the data points with the channels of the case, as processChannel does,
+// with the processing time fixed.
+func goldenResults(t *testing.T, c goldenCase) []*AnalysisResult {
+	t.Helper()
+	channels, err := provideChannels(&Config{params: c.config}, analyze.NewAnalyzerRegistry())
+	if err != nil {
+		t.Fatalf("provideChannels() error = %v", err)
+	}
+	var results []*AnalysisResult
+	for _, ch := range channels {
+		dataPoints, quality := analyze.SanitizeDataPoints(goldenDataPoints(), ch.SentinelValues)
+		result := &AnalysisResult{
+			MeasurementUUID:      "00000000-0000-0000-0000-000000000000",
+			DataID:               ch.DataID,
+			Unit:                 ch.Unit,
+			DataPoints:           dataPoints,
+			DataQuality:          quality,
+			ProcessedAt:          goldenBasetime.Add(time.Minute),
+			ProcessingTimeMillis: 42,
+		}
+		if c.partial {
+			result.Partial = true
+			result.PartialReason = "read response body: context deadline exceeded"
+		}
+		if err := ch.analyze(context.Background(), result); err != nil {
+			t.Fatalf("analyze() error = %v", err)
+		}
+		if len(ch.AlertRules) > 0 {
+			result.Alerts = ch.firedAlerts(result.Statistics)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestNotificationGolden(t *testing.T) {
+	for _, c := range goldenCases {
+		results := goldenResults(t, c)
+		n := &notify.Notification{
+			Event: &webhook.Body{
+				ResourceType:    "measurement",
+				Action:          "finished",
+				MeasurementUUID: "00000000-0000-0000-0000-000000000000",
+			},
+			Alerts:  notificationAlerts(results),
+			Link:    c.link,
+			Partial: c.partial,
+		}
+		if c.includeMeasurement {
+			n.Measurement = &intdash.Measurement{
+				UUID:     n.Event.MeasurementUUID,
+				Name:     "golden",
+				EdgeUUID: "11111111-1111-1111-1111-111111111111",
+				Basetime: goldenBasetime,
+				Duration: 10 * time.Second,
+				Tags:     map[string]string{"vehicle": "test", "driver": "alice"},
+			}
+		}
+
+		for _, locale := range []string{"en", "ja"} {
+			t.Run(c.name+"/"+locale, func(t *testing.T) {
+				h := &Handler{Locale: analyze.Locales[locale]}
+				assertGolden(t, c.name+"."+locale+".txt", h.makeNotificationBody(n, results))
+			})
+		}
+		t.Run(c.name+"/json", func(t *testing.T) {
+			body, err := makeJSONNotificationBody(n, results)
+			if err != nil {
+				t.Fatalf("makeJSONNotificationBody() error = %v", err)
+			}
+			assertGolden(t, c.name+".json", body+"\n")
+		})
+	}
+}
+
+// assertGolden compares got with the golden file, or updates the file with -update.
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the golden file (run with -update and review the diff):\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"hello-world/pkg/analyze"
+	"hello-world/pkg/intdash"
+	"hello-world/pkg/notify"
+)
+
+const (
+	// NotificationFormatText is the notification format of "Label: value" lines.
+	NotificationFormatText = "text"
+	// NotificationFormatJSON is the notification format of a NotificationDocument.
+	NotificationFormatJSON = "json"
+)
+
+// NotificationDocument is the notification body in the JSON format.
+type NotificationDocument struct {
+	ResourceType    string               `json:"resource_type"`
+	Action          string               `json:"action"`
+	MeasurementUUID string               `json:"measurement_uuid"`
+	Alerts          []string             `json:"alerts"`
+	Partial         bool                 `json:"partial,omitempty"`
+	Link            string               `json:"link,omitempty"`
+	Measurement     *intdash.Measurement `json:"measurement,omitempty"`
+	Channels        []*ChannelDocument   `json:"channels"`
+}
+
+// ChannelDocument is the result of a channel in a NotificationDocument.
+type ChannelDocument struct {
+	DataID string `json:"data_id"`
+	Unit   string `json:"unit,omitempty"`
+	// Statistics maps the notified statistics and "count" to their values.
+	// A value is null if it is not a number, e.g. the average of no data points.
+	Statistics map[string]*float64 `json:"statistics"`
+	// Analyses maps the names of the analyzers other than "statistics" to their results.
+	Analyses             map[string]json.RawMessage `json:"analyses,omitempty"`
+	DataQuality          *analyze.DataQuality       `json:"data_quality,omitempty"`
+	Alerts               []string                   `json:"alerts"`
+	Partial              bool                       `json:"partial,omitempty"`
+	PartialReason        string                     `json:"partial_reason,omitempty"`
+	ProcessedAt          time.Time                  `json:"processed_at"`
+	ProcessingTimeMillis int64                      `json:"processing_time_ms"`
+}
+
+// makeNotificationDocument makes a NotificationDocument of n from the given results.
+func makeNotificationDocument(n *notify.Notification, results []*AnalysisResult) *NotificationDocument {
+	doc := &NotificationDocument{
+		ResourceType:    n.Event.ResourceType,
+		Action:          n.Event.Action,
+		MeasurementUUID: n.Event.MeasurementUUID,
+		Alerts:          n.Alerts,
+		Partial:         n.Partial,
+		Link:            n.Link,
+		Measurement:     n.Measurement,
+		Channels:        make([]*ChannelDocument, 0, len(results)),
+	}
+	if doc.Alerts == nil {
+		doc.Alerts = []string{}
+	}
+	for _, result := range results {
+		ch := &ChannelDocument{
+			DataID:               result.DataID,
+			Unit:                 result.Unit,
+			Statistics:           map[string]*float64{},
+			DataQuality:          result.DataQuality,
+			Alerts:               result.Alerts,
+			Partial:              result.Partial,
+			PartialReason:        result.PartialReason,
+			ProcessedAt:          result.ProcessedAt,
+			ProcessingTimeMillis: result.ProcessingTimeMillis,
+		}
+		if ch.Alerts == nil {
+			ch.Alerts = []string{}
+		}
+		if stats := result.Statistics; stats != nil {
+			for _, name := range append([]string{"count"}, stats.Names()...) {
+				v := stats.Value(name)
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					ch.Statistics[name] = nil
+				} else {
+					ch.Statistics[name] = &v
+				}
+			}
+		}
+		for _, analysis := range result.Analyses {
+			if _, ok := analysis.Result.(*analyze.Statistics); ok {
+				continue
+			}
+			b, err := json.Marshal(analysis.Result)
+			if err != nil {
+				// e.g. NaN in a custom metric
+				slog.Error("Omitted the result from the JSON notification", "data_id", result.DataID, "analyzer", analysis.Analyzer, "error", err)
+				continue
+			}
+			if ch.Analyses == nil {
+				ch.Analyses = map[string]json.RawMessage{}
+			}
+			ch.Analyses[analysis.Analyzer] = b
+		}
+		doc.Channels = append(doc.Channels, ch)
+	}
+	return doc
+}
+
+// makeJSONNotificationBody makes a notification body of n in the JSON format.
+func makeJSONNotificationBody(n *notify.Notification, results []*AnalysisResult) (string, error) {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	// Keep alert rules such as "max > 120" readable.
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(makeNotificationDocument(n, results)); err != nil {
+		return "", fmt.Errorf("marshal notification document: %w", err)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"hello-world/pkg/intdash"
+	"hello-world/pkg/webhook"
+)
+
+// S3GetObjectAPI is the S3 API to get objects.
+type S3GetObjectAPI interface {
+	GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// NotificationTemplate renders the notification body and subject with text/template,
+// so that the wording and the fields of the notifications can be customized by configuration.
+// The templates are executed with NotificationTemplateData and can use the functions
+// stat (e.g. {{stat . "average"}} in a range of .Results), join, json and rfc3339.
+type NotificationTemplate struct {
+	// Body renders the notification body. If nil, the default body is used.
+	Body *template.Template
+	// Subject renders the notification subject. If nil, each notifier uses its default subject.
+	Subject *template.Template
+}
+
+// NotificationTemplateData is the data the notification templates are executed with.
+type NotificationTemplateData struct {
+	Event      *webhook.Body
+	DeliveryID string
+	// Alerts are the fired alert rules of all the channels.
+	Alerts []string
+	// Severity is SeverityAlert if any alert rule fired, and SeverityInfo otherwise.
+	Severity string
+	// Link is the link to the measurement in the intdash console, if configured.
+	Link string
+	// Measurement is the metadata of the measurement, if configured.
+	Measurement *intdash.Measurement
+	// Results are the analysis results of the channels.
+	Results []*AnalysisResult
+	// Default is the default notification body in the configured format.
+	Default string
+}
+
+var notificationTemplateFuncs = template.FuncMap{
+	// stat returns the statistic of the given name of a result, e.g. "average" or "p95".
+	"stat": func(result *AnalysisResult, name string) float64 {
+		if result.Statistics == nil {
+			return 0
+		}
+		return result.Statistics.Value(name)
+	},
+	"join": strings.Join,
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"rfc3339": func(t time.Time) string {
+		return t.Format(time.RFC3339Nano)
+	},
+}
+
+// ParseNotificationTemplate parses the templates of the notification body and subject.
+// Either of them may be empty to use the default.
+func ParseNotificationTemplate(body, subject string) (*NotificationTemplate, error) {
+	t := &NotificationTemplate{}
+	var err error
+	if body != "" {
+		if t.Body, err = template.New("body").Funcs(notificationTemplateFuncs).Parse(body); err != nil {
+			return nil, fmt.Errorf("parse body template: %w", err)
+		}
+	}
+	if subject != "" {
+		if t.Subject, err = template.New("subject").Funcs(notificationTemplateFuncs).Parse(subject); err != nil {
+			return nil, fmt.Errorf("parse subject template: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// Render renders the body and the subject. The body is data.Default if there is no body template,
+// and the subject is empty if there is no subject template.
+func (t *NotificationTemplate) Render(data *NotificationTemplateData) (body, subject string, err error) {
+	body = data.Default
+	if t.Body != nil {
+		var b strings.Builder
+		if err := t.Body.Execute(&b, data); err != nil {
+			return "", "", fmt.Errorf("render body template: %w", err)
+		}
+		body = b.String()
+	}
+	if t.Subject != nil {
+		var b strings.Builder
+		if err := t.Subject.Execute(&b, data); err != nil {
+			return "", "", fmt.Errorf("render subject template: %w", err)
+		}
+		subject = strings.TrimSpace(b.String())
+	}
+	return body, subject, nil
+}
+
+// loadS3Text gets the object of the given "s3://bucket/key" URI as text.
+func loadS3Text(ctx context.Context, api S3GetObjectAPI, uri string) (string, error) {
+	path := strings.TrimPrefix(uri, "s3://")
+	i := strings.Index(path, "/")
+	if path == uri || i <= 0 || i == len(path)-1 {
+		return "", fmt.Errorf("invalid S3 URI %q, want s3://bucket/key", uri)
+	}
+	out, err := api.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(path[:i]),
+		Key:    aws.String(path[i+1:]),
+	})
+	if err != nil {
+		return "", fmt.Errorf("get %s: %w", uri, err)
+	}
+	defer out.Body.Close()
+	b, err := io.ReadAll(out.Body)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", uri, err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"hello-world/pkg/notify"
+)
+
+// NotifyError is returned when some of the notifiers fail.
+type NotifyError struct {
+	// Failures maps the names of the failed notifiers to their errors.
+	Failures map[string]error
+	// Total is the number of notifiers invoked.
+	Total int
+}
+
+func (e *NotifyError) Error() string {
+	msgs := make([]string, 0, len(e.Failures))
+	for name, err := range e.Failures {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", name, err))
+	}
+	return fmt.Sprintf("%d of %d notifiers failed: %s", len(e.Failures), e.Total, strings.Join(msgs, "; "))
+}
+
+// Is reports whether the target is ErrPublishFailed, so that a NotifyError is classified as a publish failure,
+// or ErrCircuitOpen if all the failures are of open circuits.
+func (e *NotifyError) Is(target error) bool {
+	if target == ErrCircuitOpen {
+		for _, err := range e.Failures {
+			if !errors.Is(err, ErrCircuitOpen) {
+				return false
+			}
+		}
+		return len(e.Failures) > 0
+	}
+	return target == ErrPublishFailed
+}
+
+// notify sends the notification to all notifiers concurrently.
+// A failing notifier does not prevent the others from being notified.
+// If any of them fails, a *NotifyError is returned.
+// With h.IdempotencyStore, each notifier is claimed for the delivery, so that the retry of a delivery
+// is sent only to the notifiers that failed.
+func (h *Handler) notify(ctx context.Context, n *notify.Notification) error {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures = map[string]error{}
+	)
+	for _, notifier := range h.Notifiers {
+		wg.Add(1)
+		go func(notifier notify.Notifier) {
+			defer wg.Done()
+			ctx, end := h.startSpan(ctx, "notifier_"+notifier.Name())
+			err := h.notifyOnce(ctx, notifier, func() (err error) {
+				// A panic in a goroutine cannot be recovered by the caller.
+				defer func() {
+					if v := recover(); v != nil {
+						err = h.recovered(ctx, v)
+					}
+				}()
+				return notifier.Notify(ctx, n)
+			})
+			end(err)
+			if err != nil {
+				h.logger().ErrorContext(ctx, "Failed to notify", "notifier", notifier.Name(), "error", err)
+				mu.Lock()
+				failures[notifier.Name()] = err
+				mu.Unlock()
+			}
+		}(notifier)
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		return &NotifyError{Failures: failures, Total: len(h.Notifiers)}
+	}
+	return nil
+}
+
+// notifyOnce calls send unless the notifier has already been notified of the delivery being processed.
+// The notifier is claimed in h.IdempotencyStore as "<delivery ID>/<notifier name>", and released if send fails.
+func (h *Handler) notifyOnce(ctx context.Context, notifier notify.Notifier, send func() error) error {
+	deliveryID := deliveryIDFromContext(ctx)
+	if h.IdempotencyStore == nil || deliveryID == "" {
+		return send()
+	}
+	key := deliveryID + "/" + notifier.Name()
+	claimed, err := h.IdempotencyStore.Claim(ctx, key)
+	if err != nil {
+		return fmt.Errorf("claim notification %s: %w", key, err)
+	}
+	if !claimed {
+		h.logger().InfoContext(ctx, "Skipped already notified notifier", "notifier", notifier.Name())
+		return nil
+	}
+	if err := send(); err != nil {
+		// ctx may have been canceled by the deadline, but the notifier must be released for the retry.
+		releaseCtx, cancel := detachedContext(ctx, DefaultDeadlineMargin/2)
+		defer cancel()
+		if err := h.IdempotencyStore.Release(releaseCtx, key); err != nil {
+			h.logger().ErrorContext(ctx, "Failed to release notification", "notifier", notifier.Name(), "error", err)
+		}
+		return err
+	}
+	return nil
+}
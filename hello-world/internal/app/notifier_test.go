@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"hello-world/pkg/notify"
+)
+
+// memoryIdempotencyStore is an IdempotencyStore in memory.
+type memoryIdempotencyStore struct {
+	mu     sync.Mutex
+	claims map[string]bool
+}
+
+func (s *memoryIdempotencyStore) Claim(ctx context.Context, deliveryID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claims[deliveryID] {
+		return false, nil
+	}
+	if s.claims == nil {
+		s.claims = map[string]bool{}
+	}
+	s.claims[deliveryID] = true
+	return true, nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, deliveryID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.claims, deliveryID)
+	return nil
+}
+
+// countingNotifier counts the notifications, and fails while err is set.
+type countingNotifier struct {
+	name string
+	err  error
+
+	mu    sync.Mutex
+	count int
+}
+
+func (n *countingNotifier) Name() string {
+	return n.name
+}
+
+func (n *countingNotifier) Notify(ctx context.Context, notification *notify.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.count++
+	return n.err
+}
+
+func TestNotifyRetriesOnlyFailedNotifiers(t *testing.T) {
+	ok := &countingNotifier{name: "sns"}
+	failing := &countingNotifier{name: "webhook", err: errors.New("unavailable")}
+	h := &Handler{
+		Notifiers:        []notify.Notifier{ok, failing},
+		IdempotencyStore: &memoryIdempotencyStore{},
+	}
+	ctx := withDeliveryID(context.Background(), "delivery-1")
+
+	var notifyErr *NotifyError
+	if err := h.notify(ctx, &notify.Notification{}); !errors.As(err, &notifyErr) || len(notifyErr.Failures) != 1 {
+		t.Fatalf("notify() error = %v, want a failure of webhook", err)
+	}
+
+	failing.err = nil
+	if err := h.notify(ctx, &notify.Notification{}); err != nil {
+		t.Fatalf("notify() of the retry error = %v", err)
+	}
+	if err := h.notify(ctx, &notify.Notification{}); err != nil {
+		t.Fatalf("notify() of the duplicate error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		notifier *countingNotifier
+		want     int
+	}{
+		{notifier: ok, want: 1},
+		{notifier: failing, want: 2},
+	} {
+		if tt.notifier.count != tt.want {
+			t.Errorf("%s notified %d times, want %d", tt.notifier.name, tt.notifier.count, tt.want)
+		}
+	}
+}
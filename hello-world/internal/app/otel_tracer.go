@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelInstrumentationName is the name of the OpenTelemetry tracer and meter.
+const otelInstrumentationName = "github.com/aptpod/intdash-webhook-example"
+
+// OTelTracer is a Tracer that exports the spans, and the durations of the stages as metrics, with OpenTelemetry.
+// The spans and the metrics are attributed with the measurement UUID, the delivery ID and the data ID, if known.
+type OTelTracer struct {
+	TracerProvider *sdktrace.TracerProvider
+	MeterProvider  *sdkmetric.MeterProvider
+
+	tracer   trace.Tracer
+	duration otelmetric.Float64Histogram
+	errors   otelmetric.Int64Counter
+}
+
+// NewOTelTracer returns an OTelTracer that exports to the OTLP endpoint configured by the standard environment variables,
+// such as OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS and OTEL_SERVICE_NAME.
+// The protocol is OTEL_EXPORTER_OTLP_PROTOCOL, "grpc" or "http/protobuf" (default).
+func NewOTelTracer(ctx context.Context) (*OTelTracer, error) {
+	var (
+		traceExporter  sdktrace.SpanExporter
+		metricExporter sdkmetric.Exporter
+		err            error
+	)
+	switch protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol {
+	case "grpc":
+		if traceExporter, err = otlptracegrpc.New(ctx); err != nil {
+			return nil, fmt.Errorf("create OTLP trace exporter: %w", err)
+		}
+		if metricExporter, err = otlpmetricgrpc.New(ctx); err != nil {
+			return nil, fmt.Errorf("create OTLP metric exporter: %w", err)
+		}
+	case "", "http/protobuf":
+		if traceExporter, err = otlptracehttp.New(ctx); err != nil {
+			return nil, fmt.Errorf("create OTLP trace exporter: %w", err)
+		}
+		if metricExporter, err = otlpmetrichttp.New(ctx); err != nil {
+			return nil, fmt.Errorf("create OTLP metric exporter: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q", protocol)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.Environment())
+	if err != nil {
+		return nil, fmt.Errorf("create resource: %w", err)
+	}
+	t := &OTelTracer{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithBatcher(traceExporter), sdktrace.WithResource(res)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)), sdkmetric.WithResource(res)),
+	}
+	t.tracer = t.TracerProvider.Tracer(otelInstrumentationName)
+	t.duration, err = t.MeterProvider.Meter(otelInstrumentationName).Float64Histogram(
+		"webhook.stage.duration",
+		otelmetric.WithUnit("s"),
+		otelmetric.WithDescription("Duration of the processing stages of the webhook deliveries"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create duration histogram: %w", err)
+	}
+	t.errors, err = t.MeterProvider.Meter(otelInstrumentationName).Int64Counter(
+		"webhook.errors",
+		otelmetric.WithDescription("Number of the errors of the webhook deliveries, such as recovered panics"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create errors counter: %w", err)
+	}
+	return t, nil
+}
+
+// Start starts a span of the name, and records its duration to the "webhook.stage.duration" histogram when it ends.
+func (t *OTelTracer) Start(ctx context.Context, name string) (context.Context, func(err error)) {
+	start := time.Now()
+	attrs := otelAttributes(ctx)
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		// The IDs are left out of the metrics, so as not to make a time series per delivery.
+		t.duration.Record(ctx, time.Since(start).Seconds(), otelmetric.WithAttributes(
+			attribute.String("stage", name),
+			attribute.Bool("error", err != nil),
+		))
+	}
+}
+
+// CountError adds one to the "webhook.errors" counter with the attribute "error_type". OTelTracer is an ErrorCounter.
+func (t *OTelTracer) CountError(ctx context.Context, errorType string) error {
+	t.errors.Add(ctx, 1, otelmetric.WithAttributes(attribute.String("error_type", errorType)))
+	return nil
+}
+
+// Flush exports the buffered spans and metrics.
+func (t *OTelTracer) Flush(ctx context.Context) error {
+	return errors.Join(t.TracerProvider.ForceFlush(ctx), t.MeterProvider.ForceFlush(ctx))
+}
+
+// otelAttributes returns the correlation attributes of the context as OpenTelemetry attributes.
+func otelAttributes(ctx context.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if id := deliveryIDFromContext(ctx); id != "" {
+		attrs = append(attrs, attribute.String("delivery_id", id))
+	}
+	for _, a := range logAttrsFromContext(ctx) {
+		switch a.Key {
+		case "measurement_uuid", "data_id":
+			if a.Value.Kind() == slog.KindString {
+				attrs = append(attrs, attribute.String(a.Key, a.Value.String()))
+			}
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"context"
+	"net/http"
+
+	"hello-world/pkg/webhook"
+)
+
+// PingResponseBody is the JSON body of the response to a ping with a challenge.
+type PingResponseBody struct {
+	Challenge string `json:"challenge"`
+}
+
+// respondPing responds to a ping, the test delivery of the webhook on registration, so that the registration succeeds.
+// The challenge of the ping, if any, is echoed in the response.
+func (h *Handler) respondPing(ctx context.Context, body *webhook.Body) webhookResponse {
+	h.logger().InfoContext(ctx, "Got ping")
+	if body.Challenge == "" {
+		return webhookResponse{StatusCode: http.StatusNoContent}
+	}
+	return jsonResponse(http.StatusOK, &PingResponseBody{Challenge: body.Challenge})
+}
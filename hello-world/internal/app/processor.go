@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"sync"
+
+	"hello-world/pkg/webhook"
+)
+
+// Processor processes a webhook event of a specific resource type and action.
+type Processor interface {
+	Process(ctx context.Context, body *webhook.Body) error
+}
+
+// ProcessorFunc is an adapter to allow the use of ordinary functions as Processor.
+type ProcessorFunc func(ctx context.Context, body *webhook.Body) error
+
+// Process calls f(ctx, body).
+func (f ProcessorFunc) Process(ctx context.Context, body *webhook.Body) error {
+	return f(ctx, body)
+}
+
+type processorKey struct {
+	resourceType string
+	action       string
+}
+
+// ProcessorRegistry dispatches webhook events to the Processor registered for
+// their (resource_type, action) pair.
+type ProcessorRegistry struct {
+	mu         sync.RWMutex
+	processors map[processorKey]Processor
+}
+
+// NewProcessorRegistry returns an empty ProcessorRegistry.
+func NewProcessorRegistry() *ProcessorRegistry {
+	return &ProcessorRegistry{processors: map[processorKey]Processor{}}
+}
+
+// Register registers the processor for the given resource type and action.
+// A processor already registered for the pair is replaced.
+func (r *ProcessorRegistry) Register(resourceType, action string, p Processor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processors[processorKey{resourceType: resourceType, action: action}] = p
+}
+
+// Lookup returns the processor registered for the given resource type and action.
+func (r *ProcessorRegistry) Lookup(resourceType, action string) (Processor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.processors[processorKey{resourceType: resourceType, action: action}]
+	return p, ok
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// StartPprofServer serves the pprof endpoints under /debug/pprof/ on addr in the background,
+// e.g. to profile the analyzers on large measurements with `go tool pprof http://localhost:6060/debug/pprof/heap`.
+// They are served apart from the webhook, so that they can be bound to a private address such as "localhost:6060".
+func StartPprofServer(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		slog.Info("Serving pprof", "addr", addr)
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Failed to serve pprof", "error", err)
+		}
+	}()
+}
+
+// LogRuntimeStats logs the memory and the goroutines of the process every interval until ctx is done.
+func LogRuntimeStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		slog.InfoContext(ctx, "Runtime stats",
+			"goroutines", runtime.NumGoroutine(),
+			"heap_alloc", m.HeapAlloc,
+			"heap_inuse", m.HeapInuse,
+			"heap_objects", m.HeapObjects,
+			"total_alloc", m.TotalAlloc,
+			"sys", m.Sys,
+			"num_gc", m.NumGC,
+			"gc_pause_total", time.Duration(m.PauseTotalNs),
+		)
+	}
+}
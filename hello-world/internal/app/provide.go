@@ -0,0 +1,993 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-xray-sdk-go/instrumentation/awsv2"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"hello-world/pkg/analyze"
+	"hello-world/pkg/cache"
+	"hello-world/pkg/intdash"
+	"hello-world/pkg/notify"
+	"hello-world/pkg/webhook"
+)
+
+// RunReplay replays the recorded events at the given locations through h, provided by ProvideHandler.
+// The events are processed synchronously, even if they are old or have already been processed.
+func RunReplay(h *Handler, locations []string) error {
+	if len(locations) == 0 {
+		return fmt.Errorf("no event location is given")
+	}
+	if h.TimestampTolerance > 0 {
+		// The timestamps are still signed, but old deliveries are accepted.
+		h.TimestampTolerance = math.MaxInt64
+	}
+	h.AllowedSourceIPs = nil
+	h.IdempotencyStore = nil
+	h.EventQueue = nil
+	h.EventRecorder = nil
+	h.DeadLetters = nil
+	if h.awsClients == nil {
+		return fmt.Errorf("handler is not provided by ProvideHandler")
+	}
+	return h.ReplayEvents(context.Background(), h.awsClients.S3(), locations)
+}
+
+// Environment is the environment of the entry point the handler is provided for, which changes the defaults
+// of its configuration.
+type Environment struct {
+	// Secret is the webhook secret used if none of WEBHOOK_SECRET_ID, WEBHOOK_SECRET_CIPHERTEXT and WEBHOOK_SECRET
+	// is set, e.g. the one of a command-line flag. It takes precedence over WEBHOOK_SECRET_FILE.
+	Secret string
+	// Local is for the local development: the intdash API stub is used unless INTDASH_API_URL is set,
+	// and the notifications are printed to stdout unless NOTIFIERS is set.
+	Local bool
+}
+
+// defaults returns cfg with the defaults of the environment for the values not set.
+func (env Environment) defaults(cfg *Config) *Config {
+	defaults := map[string]string{}
+	if cfg.Get("WEBHOOK_SECRET") == "" && env.Secret != "" {
+		defaults["WEBHOOK_SECRET"] = env.Secret
+	}
+	if env.Local {
+		if cfg.Get("NOTIFIERS") == "" {
+			defaults["NOTIFIERS"] = "stdout"
+		}
+		if cfg.Get("INTDASH_API_URL") == "" {
+			defaults["INTDASH_API_STUB"] = "true"
+		}
+	}
+	return cfg.With(defaults)
+}
+
+// ProvideHandler provides the handler of the configuration: the environment variables, and the parameters under
+// CONFIG_SSM_PATH if set, with the defaults of env.
+func ProvideHandler(env Environment) (*Handler, error) {
+	awsCfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+
+	// The AWS clients are instrumented by the tracer, so it must be provided before them,
+	// including the SSM client that loads the configuration.
+	tracer, err := provideTracer(os.Getenv("TRACING"), &awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("provide tracer: %w", err)
+	}
+	clients := newAWSClients(awsCfg)
+
+	// The SSM client is created only if the configuration is loaded from SSM.
+	cfg, err := loadConfig(context.TODO(), clients.SSM(), os.Getenv("CONFIG_SSM_PATH"))
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	cfg = env.defaults(cfg)
+
+	notifiers, err := provideNotifiers(cfg, clients)
+	if err != nil {
+		return nil, fmt.Errorf("provide notifiers: %w", err)
+	}
+
+	intdashAPI, err := provideIntdashAPI(cfg, tracer)
+	if err != nil {
+		return nil, fmt.Errorf("provide intdash API: %w", err)
+	}
+
+	secretOpt, err := provideSecretKeys(cfg, clients)
+	if err != nil {
+		return nil, fmt.Errorf("provide webhook secret: %w", err)
+	}
+
+	channels, err := provideChannels(cfg, analyze.NewAnalyzerRegistry())
+	if err != nil {
+		return nil, fmt.Errorf("provide channels: %w", err)
+	}
+
+	routes, err := provideRoutes(cfg, clients, analyze.NewAnalyzerRegistry())
+	if err != nil {
+		return nil, fmt.Errorf("provide routes: %w", err)
+	}
+
+	h, err := NewHandler(
+		WithIntdashAPI(intdashAPI),
+		WithNotifiers(notifiers...),
+		WithChannels(channels...),
+		WithRoutes(routes...),
+		WithTracer(tracer),
+		secretOpt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if counter, ok := tracer.(ErrorCounter); ok {
+		h.ErrorCounters = append(h.ErrorCounters, counter)
+	}
+
+	switch format := cfg.Get("NOTIFICATION_FORMAT"); format {
+	case NotificationFormatText, NotificationFormatJSON:
+		h.NotificationFormat = format
+	case "":
+	default:
+		return nil, fmt.Errorf("unknown notification format %q", format)
+	}
+
+	if v := cfg.Get("NOTIFICATION_LOCALE"); v != "" {
+		locale, ok := analyze.Locales[v]
+		if !ok {
+			return nil, fmt.Errorf("unknown notification locale %q", v)
+		}
+		h.Locale = locale
+	}
+
+	h.IncludeMeasurement = cfg.Get("INCLUDE_MEASUREMENT_METADATA") == "true"
+	h.AllowPartialResults = cfg.Get("ALLOW_PARTIAL_RESULTS") == "true"
+	h.AllowUnknownFields = cfg.Get("WEBHOOK_ALLOW_UNKNOWN_FIELDS") == "true"
+	h.DryRun = cfg.Get("DRY_RUN") == "true"
+	if v := cfg.Get("WEBHOOK_SIGNATURE_ALGORITHM"); v != "" {
+		if h.SignatureAlgorithm, err = webhook.ParseAlgorithm(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := cfg.Get("WEBHOOK_MAX_BODY_SIZE"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse WEBHOOK_MAX_BODY_SIZE: %w", err)
+		}
+		h.MaxBodySize = n
+	}
+	if v := cfg.Get("WEBHOOK_ALLOWED_SOURCE_IPS"); v != "" {
+		prefixes, err := ParseCIDRs(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse WEBHOOK_ALLOWED_SOURCE_IPS: %w", err)
+		}
+		h.AllowedSourceIPs = prefixes
+	}
+	if v := cfg.Get("WEBHOOK_TENANT_SECRETS"); v != "" {
+		keys, err := ParseTenantSecrets(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse WEBHOOK_TENANT_SECRETS: %w", err)
+		}
+		h.TenantKeys = keys
+		if v := cfg.Get("WEBHOOK_TENANT_SOURCE"); v != "" {
+			if h.TenantSource, err = ParseTenantSource(v); err != nil {
+				return nil, fmt.Errorf("parse WEBHOOK_TENANT_SOURCE: %w", err)
+			}
+		}
+	}
+	if consoleURL := cfg.Get("INTDASH_CONSOLE_URL"); consoleURL != "" {
+		link, err := NewMeasurementLinkBuilder(consoleURL, cfg.Get("INTDASH_MEASUREMENT_LINK_TEMPLATE"))
+		if err != nil {
+			return nil, fmt.Errorf("provide measurement link: %w", err)
+		}
+		h.MeasurementLink = link
+	}
+
+	tmpl, err := provideNotificationTemplate(cfg, clients)
+	if err != nil {
+		return nil, fmt.Errorf("provide notification template: %w", err)
+	}
+	h.NotificationTemplate = tmpl
+
+	if v := cfg.Get("CHANNEL_CONCURRENCY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse CHANNEL_CONCURRENCY: %w", err)
+		}
+		h.ChannelConcurrency = n
+	}
+
+	if tableName := cfg.Get("RESULT_TABLE_NAME"); tableName != "" {
+		h.ResultStore = &DynamoDBResultStore{
+			DynamoDBAPI: clients.DynamoDB(),
+			TableName:   tableName,
+		}
+	}
+	if bucket := cfg.Get("RESULT_BUCKET"); bucket != "" {
+		archive, err := NewS3ResultArchive(clients.S3(), bucket, cfg.Get("RESULT_KEY_TEMPLATE"))
+		if err != nil {
+			return nil, fmt.Errorf("provide result archive: %w", err)
+		}
+		h.ResultArchives = append(h.ResultArchives, archive)
+	}
+	if database := cfg.Get("TIMESTREAM_DATABASE_NAME"); database != "" {
+		tableName := cfg.Get("TIMESTREAM_TABLE_NAME")
+		if tableName == "" {
+			return nil, fmt.Errorf("TIMESTREAM_TABLE_NAME is not set")
+		}
+		h.ResultArchives = append(h.ResultArchives, &TimestreamWriter{
+			TimestreamWriteRecordsAPI: clients.TimestreamWrite(),
+			DatabaseName:              database,
+			TableName:                 tableName,
+			MeasureName:               cfg.Get("TIMESTREAM_MEASURE_NAME"),
+		})
+	}
+	if stream := cfg.Get("FIREHOSE_DELIVERY_STREAM_NAME"); stream != "" {
+		h.ResultArchives = append(h.ResultArchives, &FirehosePublisher{
+			FirehosePutRecordBatchAPI: clients.Firehose(),
+			DeliveryStreamName:        stream,
+		})
+	}
+	switch mode := cfg.Get("METRICS_MODE"); mode {
+	case "emf":
+		publisher := &EMFMetricsPublisher{
+			Namespace: cfg.Get("METRICS_NAMESPACE"),
+		}
+		h.ResultArchives = append(h.ResultArchives, publisher)
+		h.ErrorCounters = append(h.ErrorCounters, publisher)
+	case "api":
+		publisher := &CloudWatchMetricsPublisher{
+			CloudWatchPutMetricDataAPI: clients.CloudWatch(),
+			Namespace:                  cfg.Get("METRICS_NAMESPACE"),
+		}
+		h.ResultArchives = append(h.ResultArchives, publisher)
+		h.ErrorCounters = append(h.ErrorCounters, publisher)
+	case "":
+	default:
+		return nil, fmt.Errorf("unknown metrics mode %q", mode)
+	}
+
+	tagStatistics := cfg.Get("INTDASH_WRITE_BACK_TAGS")
+	if markers := cfg.Get("INTDASH_WRITE_BACK_MARKERS") == "true"; tagStatistics != "" || markers {
+		api, ok := intdashAPI.(IntdashWriteBackAPI)
+		if !ok {
+			return nil, fmt.Errorf("intdash API does not support write-back")
+		}
+		h.WriteBack = &IntdashWriteBack{API: api, Markers: markers}
+		if tagStatistics != "" {
+			h.WriteBack.TagStatistics = strings.Split(tagStatistics, ",")
+		}
+	}
+
+	measurementCacheTTL := DefaultMeasurementCacheTTL
+	if v := cfg.Get("INTDASH_MEASUREMENT_CACHE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse INTDASH_MEASUREMENT_CACHE_TTL: %w", err)
+		}
+		measurementCacheTTL = d
+	}
+	if measurementCacheTTL > 0 {
+		h.MeasurementCache = cache.New[string, *intdash.Measurement](measurementCacheTTL)
+	}
+
+	if err := configureDeadlines(cfg, h); err != nil {
+		return nil, err
+	}
+
+	if err := configureTimestampValidation(cfg, h); err != nil {
+		return nil, err
+	}
+
+	if tableName := cfg.Get("IDEMPOTENCY_TABLE_NAME"); tableName != "" {
+		ttl := DefaultIdempotencyTTL
+		if v := cfg.Get("IDEMPOTENCY_TTL"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("parse IDEMPOTENCY_TTL: %w", err)
+			}
+			ttl = d
+		}
+		h.IdempotencyStore = &DynamoDBIdempotencyStore{
+			DynamoDBAPI: clients.DynamoDB(),
+			TableName:   tableName,
+			TTL:         ttl,
+		}
+		h.DeliveryIDHeader = cfg.Get("WEBHOOK_DELIVERY_ID_HEADER")
+	}
+
+	if tableName := cfg.Get("AUDIT_TABLE_NAME"); tableName != "" {
+		ttl := DefaultAuditTTL
+		if v := cfg.Get("AUDIT_TTL"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("parse AUDIT_TTL: %w", err)
+			}
+			ttl = d
+		}
+		h.AuditLog = &DynamoDBAuditLog{
+			DynamoDBAPI: clients.DynamoDB(),
+			TableName:   tableName,
+			TTL:         ttl,
+		}
+	}
+
+	if bucket := cfg.Get("EVENT_RECORD_BUCKET"); bucket != "" {
+		h.EventRecorder = &S3EventRecorder{
+			S3PutObjectAPI: clients.S3(),
+			Bucket:         bucket,
+			Prefix:         cfg.Get("EVENT_RECORD_PREFIX"),
+		}
+	}
+
+	if queueURL := cfg.Get("EVENT_QUEUE_URL"); queueURL != "" && cfg.Get("HANDLER_MODE") != "worker" {
+		h.EventQueue = &SQSEventQueue{
+			SQSSendMessageAPI: clients.SQS(),
+			QueueURL:          queueURL,
+		}
+	}
+	if arn := cfg.Get("STATE_MACHINE_ARN"); arn != "" && cfg.Get("HANDLER_MODE") != "stepfunctions" {
+		h.EventQueue = &StepFunctionsEventQueue{
+			SFNStartExecutionAPI: clients.SFN(),
+			StateMachineArn:      arn,
+		}
+	}
+	if queueURL := cfg.Get("DEAD_LETTER_QUEUE_URL"); queueURL != "" {
+		h.DeadLetters = &SQSDeadLetterQueue{
+			SQSSendMessageAPI: clients.SQS(),
+			QueueURL:          queueURL,
+		}
+	} else if bucket := cfg.Get("DEAD_LETTER_BUCKET"); bucket != "" {
+		h.DeadLetters = &S3DeadLetterQueue{
+			S3PutObjectAPI: clients.S3(),
+			Bucket:         bucket,
+			Prefix:         cfg.Get("DEAD_LETTER_PREFIX"),
+		}
+	}
+	if v := cfg.Get("DEAD_LETTER_MAX_RECEIVE_COUNT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse DEAD_LETTER_MAX_RECEIVE_COUNT: %w", err)
+		}
+		h.DeadLetterMaxReceiveCount = n
+	}
+	if bucket := cfg.Get("STAGING_BUCKET"); bucket != "" {
+		h.DataPointStaging = &S3DataPointStaging{
+			S3DataPointStagingAPI: clients.S3(),
+			Bucket:                bucket,
+			Prefix:                cfg.Get("STAGING_PREFIX"),
+		}
+	}
+	// The pool is applied first, so that the calls rejected by an open circuit do not wait for a worker.
+	poolSize := DefaultWorkerPoolSize
+	if v := cfg.Get("WORKER_POOL_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse WORKER_POOL_SIZE: %w", err)
+		}
+		poolSize = n
+	}
+	if poolSize > 0 {
+		h.useWorkerPool(NewWorkerPool(poolSize))
+	}
+	threshold := DefaultCircuitFailureThreshold
+	if v := cfg.Get("CIRCUIT_BREAKER_FAILURE_THRESHOLD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse CIRCUIT_BREAKER_FAILURE_THRESHOLD: %w", err)
+		}
+		threshold = n
+	}
+	var openTimeout time.Duration
+	if v := cfg.Get("CIRCUIT_BREAKER_OPEN_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse CIRCUIT_BREAKER_OPEN_TIMEOUT: %w", err)
+		}
+		openTimeout = d
+	}
+	if threshold > 0 {
+		h.useCircuitBreakers(func(name string) *CircuitBreaker {
+			return &CircuitBreaker{Name: name, FailureThreshold: threshold, OpenTimeout: openTimeout}
+		})
+	}
+
+	h.awsClients = clients
+	return h, nil
+}
+
+// provideSecretKeys provides the option of the webhook secret: the secret in Secrets Manager if WEBHOOK_SECRET_ID is set,
+// otherwise the KMS-encrypted WEBHOOK_SECRET_CIPHERTEXT, or WEBHOOK_SECRET, which defaults to the secret of the environment,
+// or else the file of WEBHOOK_SECRET_FILE.
+func provideSecretKeys(cfg *Config, clients *awsClients) (Option, error) {
+	if secretID := cfg.Get("WEBHOOK_SECRET_ID"); secretID != "" {
+		keySource, err := provideSecretsManagerKeySource(cfg, clients, secretID)
+		if err != nil {
+			return nil, err
+		}
+		return WithSHA256KeySource(keySource), nil
+	}
+	if ciphertext := cfg.Get("WEBHOOK_SECRET_CIPHERTEXT"); ciphertext != "" {
+		encryptionContext := map[string]string{}
+		if v := cfg.Get("WEBHOOK_SECRET_ENCRYPTION_CONTEXT"); v != "" {
+			for _, kv := range strings.Split(v, ",") {
+				key, value, ok := strings.Cut(strings.TrimSpace(kv), "=")
+				if !ok {
+					return nil, fmt.Errorf("parse WEBHOOK_SECRET_ENCRYPTION_CONTEXT: %q is not key=value", kv)
+				}
+				encryptionContext[key] = value
+			}
+		}
+		keys, err := webhook.DecryptKMSSecret(context.TODO(), clients.KMS(), ciphertext, encryptionContext)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt WEBHOOK_SECRET_CIPHERTEXT: %w", err)
+		}
+		return WithSHA256Keys(keys...), nil
+	}
+	secret := cfg.Get("WEBHOOK_SECRET")
+	if path := cfg.Get("WEBHOOK_SECRET_FILE"); secret == "" && path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read WEBHOOK_SECRET_FILE: %w", err)
+		}
+		secret = string(b)
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("none of WEBHOOK_SECRET_ID, WEBHOOK_SECRET_CIPHERTEXT, WEBHOOK_SECRET and WEBHOOK_SECRET_FILE is set")
+	}
+	return WithSHA256Keys(webhook.ParseSecretKeys([]byte(secret))...), nil
+}
+
+// provideRoutes provides the routes of ROUTES. Each route has the notifiers and the channels
+// of the configuration overridden by the route, and its own secret if overridden.
+func provideRoutes(cfg *Config, clients *awsClients, registry *analyze.AnalyzerRegistry) ([]*Route, error) {
+	v := cfg.Get("ROUTES")
+	if v == "" {
+		return nil, nil
+	}
+	configs, err := parseRouteConfigs(v)
+	if err != nil {
+		return nil, fmt.Errorf("parse ROUTES: %w", err)
+	}
+	var routes []*Route
+	for _, c := range configs {
+		routeCfg := cfg.With(c.Config)
+		route := &Route{Name: c.Name, ProjectUUIDs: c.ProjectUUIDs, EdgeUUIDs: c.EdgeUUIDs}
+		if secretID := c.Config["WEBHOOK_SECRET_ID"]; secretID != "" {
+			keySource, err := provideSecretsManagerKeySource(routeCfg, clients, secretID)
+			if err != nil {
+				return nil, fmt.Errorf("route %s: %w", c.Name, err)
+			}
+			route.KeySource = keySource
+		} else if secret := c.Config["WEBHOOK_SECRET"]; secret != "" {
+			route.KeySource = webhook.StaticKeys(webhook.ParseSecretKeys([]byte(secret)))
+		}
+		if route.Notifiers, err = provideNotifiers(routeCfg, clients); err != nil {
+			return nil, fmt.Errorf("route %s: provide notifiers: %w", c.Name, err)
+		}
+		if route.Channels, err = provideChannels(routeCfg, registry); err != nil {
+			return nil, fmt.Errorf("route %s: provide channels: %w", c.Name, err)
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// provideNotifiers provides the notifiers listed in NOTIFIERS (comma-separated, default "sns").
+func provideNotifiers(cfg *Config, clients *awsClients) ([]notify.Notifier, error) {
+	names := cfg.Get("NOTIFIERS")
+	if names == "" {
+		names = "sns"
+	}
+
+	var notifiers []notify.Notifier
+	for _, name := range strings.Split(names, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "sns":
+			topicArn := cfg.Get("SNS_TOPIC_ARN")
+			if topicArn == "" {
+				return nil, fmt.Errorf("SNS_TOPIC_ARN is not set")
+			}
+			notifier := &notify.SNSNotifier{
+				SNSPublishAPI:  clients.SNS(),
+				TopicArn:       topicArn,
+				MessageGroupID: cfg.Get("SNS_MESSAGE_GROUP_ID"),
+			}
+			if v := cfg.Get("SNS_MAX_ATTEMPTS"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil {
+					return nil, fmt.Errorf("parse SNS_MAX_ATTEMPTS: %w", err)
+				}
+				notifier.MaxAttempts = n
+			}
+			if v := cfg.Get("SNS_RETRY_BASE_DELAY"); v != "" {
+				d, err := time.ParseDuration(v)
+				if err != nil {
+					return nil, fmt.Errorf("parse SNS_RETRY_BASE_DELAY: %w", err)
+				}
+				notifier.RetryBaseDelay = d
+			}
+			notifier.Undelivered = provideUndeliveredStore(cfg, clients)
+			if bucket := cfg.Get("SNS_PAYLOAD_BUCKET"); bucket != "" {
+				notifier.PayloadBucket = bucket
+				notifier.S3PutObjectAPI = clients.S3()
+			}
+			notifiers = append(notifiers, notifier)
+		case "sqs":
+			queueURL := cfg.Get("SQS_NOTIFY_QUEUE_URL")
+			if queueURL == "" {
+				return nil, fmt.Errorf("SQS_NOTIFY_QUEUE_URL is not set")
+			}
+			notifiers = append(notifiers, &notify.SQSNotifier{
+				SQSSendMessageAPI: clients.SQS(),
+				QueueURL:          queueURL,
+				MessageGroupID:    cfg.Get("SQS_NOTIFY_MESSAGE_GROUP_ID"),
+			})
+		case "eventbridge":
+			notifiers = append(notifiers, &notify.EventBridgeNotifier{
+				EventBridgePutEventsAPI: clients.EventBridge(),
+				EventBusName:            cfg.Get("EVENTBRIDGE_BUS_NAME"),
+			})
+		case "teams":
+			webhookURL := cfg.Get("TEAMS_WEBHOOK_URL")
+			if webhookURL == "" {
+				return nil, fmt.Errorf("TEAMS_WEBHOOK_URL is not set")
+			}
+			notifiers = append(notifiers, &notify.TeamsNotifier{
+				WebhookURL: webhookURL,
+				HTTPClient: &http.Client{Timeout: 10 * time.Second},
+			})
+		case "webhook":
+			url := cfg.Get("WEBHOOK_NOTIFY_URL")
+			if url == "" {
+				return nil, fmt.Errorf("WEBHOOK_NOTIFY_URL is not set")
+			}
+			// The posted body is signed with the algorithm of the received webhooks.
+			algorithm := webhook.SHA256
+			if v := cfg.Get("WEBHOOK_SIGNATURE_ALGORITHM"); v != "" {
+				a, err := webhook.ParseAlgorithm(v)
+				if err != nil {
+					return nil, err
+				}
+				algorithm = a
+			}
+			notifiers = append(notifiers, &notify.WebhookNotifier{
+				URL:        url,
+				Secret:     []byte(cfg.Get("WEBHOOK_NOTIFY_SECRET")),
+				Algorithm:  algorithm,
+				HTTPClient: &http.Client{Timeout: 10 * time.Second},
+			})
+		case "ses":
+			from := cfg.Get("SES_FROM")
+			to := cfg.Get("SES_TO")
+			if from == "" || to == "" {
+				return nil, fmt.Errorf("SES_FROM or SES_TO is not set")
+			}
+			var recipients []string
+			for _, v := range strings.Split(to, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					recipients = append(recipients, v)
+				}
+			}
+			notifiers = append(notifiers, &notify.SESNotifier{
+				SESSendEmailAPI: clients.SES(),
+				From:            from,
+				To:              recipients,
+			})
+		case "kinesis":
+			stream := cfg.Get("KINESIS_STREAM_NAME")
+			if stream == "" {
+				return nil, fmt.Errorf("KINESIS_STREAM_NAME is not set")
+			}
+			notifiers = append(notifiers, &notify.KinesisNotifier{
+				KinesisPutRecordAPI: clients.Kinesis(),
+				Stream:              stream,
+			})
+		case "stdout":
+			notifiers = append(notifiers, &notify.StdoutNotifier{Writer: os.Stdout})
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown notifier %q", name)
+		}
+	}
+	if len(notifiers) == 0 {
+		return nil, fmt.Errorf("no notifier is configured")
+	}
+	return notifiers, nil
+}
+
+// provideNotificationTemplate provides the notification template, or nil if not configured.
+// The body template is NOTIFICATION_TEMPLATE, or the object at NOTIFICATION_TEMPLATE_S3_URI.
+func provideNotificationTemplate(cfg *Config, clients *awsClients) (*NotificationTemplate, error) {
+	body := cfg.Get("NOTIFICATION_TEMPLATE")
+	if uri := cfg.Get("NOTIFICATION_TEMPLATE_S3_URI"); uri != "" && body == "" {
+		text, err := loadS3Text(context.TODO(), clients.S3(), uri)
+		if err != nil {
+			return nil, err
+		}
+		body = text
+	}
+	subject := cfg.Get("NOTIFICATION_SUBJECT_TEMPLATE")
+	if body == "" && subject == "" {
+		return nil, nil
+	}
+	return ParseNotificationTemplate(body, subject)
+}
+
+// provideUndeliveredStore provides the store of the undelivered notifications, or nil if not configured.
+// UNDELIVERED_BUCKET takes precedence over UNDELIVERED_QUEUE_URL.
+func provideUndeliveredStore(cfg *Config, clients *awsClients) notify.UndeliveredStore {
+	if bucket := cfg.Get("UNDELIVERED_BUCKET"); bucket != "" {
+		return &notify.S3UndeliveredStore{
+			S3PutObjectAPI: clients.S3(),
+			Bucket:         bucket,
+		}
+	}
+	if queueURL := cfg.Get("UNDELIVERED_QUEUE_URL"); queueURL != "" {
+		return &notify.SQSUndeliveredStore{
+			SQSSendMessageAPI: clients.SQS(),
+			QueueURL:          queueURL,
+		}
+	}
+	return nil
+}
+
+// configureTimestampValidation configures the replay protection of the handler.
+func configureTimestampValidation(cfg *Config, h *Handler) error {
+	h.TimestampHeader = cfg.Get("WEBHOOK_TIMESTAMP_HEADER")
+	if v := cfg.Get("WEBHOOK_TIMESTAMP_TOLERANCE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("parse WEBHOOK_TIMESTAMP_TOLERANCE: %w", err)
+		}
+		h.TimestampTolerance = d
+	}
+	h.ClockSkew = DefaultClockSkew
+	if v := cfg.Get("WEBHOOK_CLOCK_SKEW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("parse WEBHOOK_CLOCK_SKEW: %w", err)
+		}
+		h.ClockSkew = d
+	}
+	return nil
+}
+
+// provideSecretsManagerKeySource provides a KeySource backed by Secrets Manager.
+// The secret is loaded once here so that a misconfiguration fails at init rather than at the first request.
+func provideSecretsManagerKeySource(cfg *Config, clients *awsClients, secretID string) (*webhook.SecretsManagerKeySource, error) {
+	refreshInterval := webhook.DefaultSecretRefreshInterval
+	if v := cfg.Get("WEBHOOK_SECRET_REFRESH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse WEBHOOK_SECRET_REFRESH_INTERVAL: %w", err)
+		}
+		refreshInterval = d
+	}
+
+	keySource := webhook.NewSecretsManagerKeySource(clients.SecretsManager(), secretID, refreshInterval)
+	if _, err := keySource.Keys(context.TODO()); err != nil {
+		return nil, err
+	}
+	return keySource, nil
+}
+
+// provideTracer provides the tracer of TRACING, and instruments the AWS clients of awsCfg with it.
+// It returns nil if TRACING is empty.
+func provideTracer(tracing string, awsCfg *aws.Config) (Tracer, error) {
+	switch tracing {
+	case "xray":
+		awsv2.AWSV2Instrumentor(&awsCfg.APIOptions)
+		return &XRayTracer{}, nil
+	case "otel":
+		tracer, err := NewOTelTracer(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		otelaws.AppendMiddlewares(&awsCfg.APIOptions, otelaws.WithTracerProvider(tracer.TracerProvider))
+		return tracer, nil
+	case "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown tracing %q", tracing)
+	}
+}
+
+// provideIntdashAPI provides the intdash API client, whose requests are traced by tracer if set.
+// The stub is used only when INTDASH_API_STUB is "true", for local testing.
+func provideIntdashAPI(cfg *Config, tracer Tracer) (IntdashAPI, error) {
+	if cfg.Get("INTDASH_API_STUB") == "true" {
+		if path := cfg.Get("INTDASH_API_STUB_FILE"); path != "" {
+			slog.Info("Using intdash API stub", "path", path)
+			return &IntdashAPIFileStub{Path: path}, nil
+		}
+		generator, err := provideStubDataGenerator(cfg)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Using intdash API stub", "distribution", generator.Distribution)
+		return &IntdashAPIStub{Generator: generator}, nil
+	}
+
+	baseURL := cfg.Get("INTDASH_API_URL")
+	if baseURL == "" {
+		return nil, fmt.Errorf("INTDASH_API_URL is not set")
+	}
+	if cfg.Get("CHANNELS") == "" && cfg.Get("INTDASH_DATA_ID") == "" {
+		return nil, fmt.Errorf("neither CHANNELS nor INTDASH_DATA_ID is set")
+	}
+	httpClient, err := provideIntdashHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client := &intdash.Client{
+		BaseURL:    baseURL,
+		HTTPClient: httpClient,
+	}
+	switch tracer := tracer.(type) {
+	case *XRayTracer:
+		client.HTTPClient = xray.Client(client.HTTPClient)
+	case *OTelTracer:
+		client.HTTPClient.Transport = otelhttp.NewTransport(client.HTTPClient.Transport,
+			otelhttp.WithTracerProvider(tracer.TracerProvider),
+			otelhttp.WithMeterProvider(tracer.MeterProvider),
+		)
+	}
+	if v := cfg.Get("INTDASH_PAGE_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse INTDASH_PAGE_SIZE: %w", err)
+		}
+		client.PageSize = n
+	}
+	if v := cfg.Get("INTDASH_MAX_DATA_POINTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse INTDASH_MAX_DATA_POINTS: %w", err)
+		}
+		client.MaxDataPoints = n
+	}
+
+	// OAuth2 client credentials take precedence over the API token.
+	clientID := cfg.Get("INTDASH_CLIENT_ID")
+	clientSecret := cfg.Get("INTDASH_CLIENT_SECRET")
+	if clientID != "" && clientSecret != "" {
+		client.TokenProvider = &intdash.ClientCredentialsTokenProvider{
+			BaseURL:      baseURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			HTTPClient:   client.HTTPClient,
+		}
+		return client, nil
+	}
+
+	client.APIToken = cfg.Get("INTDASH_API_TOKEN")
+	if client.APIToken == "" {
+		return nil, fmt.Errorf("neither INTDASH_CLIENT_ID/INTDASH_CLIENT_SECRET nor INTDASH_API_TOKEN is set")
+	}
+	return client, nil
+}
+
+// provideIntdashHTTPClient provides the HTTP client of the intdash API. It is created once per execution environment,
+// so that the warm invocations reuse its pooled connections instead of connecting and handshaking again.
+func provideIntdashHTTPClient(cfg *Config) (*http.Client, error) {
+	timeout := 20 * time.Second
+	if v := cfg.Get("INTDASH_HTTP_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse INTDASH_HTTP_TIMEOUT: %w", err)
+		}
+		timeout = d
+	}
+	maxIdleConns := DefaultWorkerPoolSize
+	if v := cfg.Get("INTDASH_HTTP_MAX_IDLE_CONNS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse INTDASH_HTTP_MAX_IDLE_CONNS: %w", err)
+		}
+		maxIdleConns = n
+	}
+	idleConnTimeout := 90 * time.Second
+	if v := cfg.Get("INTDASH_HTTP_IDLE_CONN_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse INTDASH_HTTP_IDLE_CONN_TIMEOUT: %w", err)
+		}
+		idleConnTimeout = d
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConns,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	if cfg.Get("INTDASH_HTTP2") == "false" {
+		// A non-nil empty map disables HTTP/2.
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// provideChannels provides the channels to analyze.
+// CHANNELS configures them per data ID; otherwise a single channel is configured
+// by INTDASH_DATA_ID, ANALYZERS, ALERT_RULES and SENTINEL_VALUES.
+func provideChannels(cfg *Config, registry *analyze.AnalyzerRegistry) ([]*Channel, error) {
+	v := cfg.Get("CHANNELS")
+	if v == "" {
+		ch, err := provideChannel(cfg, registry, cfg.Get("INTDASH_DATA_ID"), "", cfg.Get("ANALYZERS"))
+		if err != nil {
+			return nil, err
+		}
+		return []*Channel{ch}, nil
+	}
+
+	configs, err := parseChannelConfigs(v)
+	if err != nil {
+		return nil, fmt.Errorf("parse CHANNELS: %w", err)
+	}
+	var channels []*Channel
+	for _, c := range configs {
+		overrides := map[string]string{}
+		for k, v := range c.Config {
+			overrides[k] = v
+		}
+		if c.AlertRules != "" {
+			overrides["ALERT_RULES"] = c.AlertRules
+		}
+		ch, err := provideChannel(cfg.With(overrides), registry, c.DataID, c.Unit, strings.Join(c.Analyzers, ","))
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", c.DataID, err)
+		}
+		channels = append(channels, ch)
+	}
+	return channels, nil
+}
+
+func provideChannel(cfg *Config, registry *analyze.AnalyzerRegistry, dataID, unit, analyzers string) (*Channel, error) {
+	ch := &Channel{DataID: dataID, Unit: unit}
+	if v := cfg.Get("ALERT_RULES"); v != "" {
+		rules, err := analyze.ParseAlertRules(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse ALERT_RULES: %w", err)
+		}
+		ch.AlertRules = rules
+	}
+	if v := cfg.Get("SENTINEL_VALUES"); v != "" {
+		sentinels, err := analyze.ParseSentinelValues(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse SENTINEL_VALUES: %w", err)
+		}
+		ch.SentinelValues = sentinels
+	}
+	window, err := intdash.ParseTimeWindow(cfg.Get("FETCH_LAST"), cfg.Get("FETCH_OFFSET_RANGE"))
+	if err != nil {
+		return nil, err
+	}
+	ch.Window = window
+	if ch.Analyzers, err = provideAnalyzers(cfg, registry, analyzers); err != nil {
+		return nil, fmt.Errorf("provide analyzers: %w", err)
+	}
+	return ch, nil
+}
+
+func provideAnalyzers(cfg *Config, registry *analyze.AnalyzerRegistry, v string) ([]analyze.Analyzer, error) {
+	if v == "" {
+		v = "statistics"
+	}
+	var names []string
+	for _, name := range strings.Split(v, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return registry.Create(cfg, names)
+}
+
+// provideStubDataGenerator provides the data generator of the intdash API stub,
+// DefaultStubDataGenerator() overridden by the INTDASH_API_STUB_* parameters.
+func provideStubDataGenerator(cfg *Config) (*StubDataGenerator, error) {
+	g := DefaultStubDataGenerator()
+	if v := cfg.Get("INTDASH_API_STUB_DISTRIBUTION"); v != "" {
+		g.Distribution = v
+	}
+	for name, p := range map[string]*float64{
+		"INTDASH_API_STUB_MEAN":          &g.Mean,
+		"INTDASH_API_STUB_STDDEV":        &g.StdDev,
+		"INTDASH_API_STUB_OUTLIER_SIGMA": &g.OutlierSigma,
+	} {
+		if v := cfg.Get(name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", name, err)
+			}
+			*p = f
+		}
+	}
+	for name, p := range map[string]*int{
+		"INTDASH_API_STUB_COUNT":    &g.Count,
+		"INTDASH_API_STUB_PERIOD":   &g.Period,
+		"INTDASH_API_STUB_OUTLIERS": &g.Outliers,
+		"INTDASH_API_STUB_NANS":     &g.NaNs,
+	} {
+		if v := cfg.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", name, err)
+			}
+			*p = n
+		}
+	}
+	if v := cfg.Get("INTDASH_API_STUB_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse INTDASH_API_STUB_INTERVAL: %w", err)
+		}
+		g.Interval = d
+	}
+	if v := cfg.Get("INTDASH_API_STUB_SEED"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse INTDASH_API_STUB_SEED: %w", err)
+		}
+		g.Seed = n
+	}
+	// Validate the distribution at init rather than on the first delivery.
+	if _, err := (&StubDataGenerator{Distribution: g.Distribution, Count: 1}).values(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// configureDeadlines configures the margin before the Lambda deadline and the time shares of the stages.
+// STAGE_TIME_SHARES overrides the shares of DefaultStageTimeShares, e.g. "fetch=0.7,analyze=0.4".
+func configureDeadlines(cfg *Config, h *Handler) error {
+	if v := cfg.Get("DEADLINE_MARGIN"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("parse DEADLINE_MARGIN: %w", err)
+		}
+		h.DeadlineMargin = d
+	}
+	if v := cfg.Get("STAGE_TIME_SHARES"); v != "" {
+		h.StageTimeShares = map[string]float64{}
+		for stage, share := range DefaultStageTimeShares {
+			h.StageTimeShares[stage] = share
+		}
+		for _, kv := range strings.Split(v, ",") {
+			stage, value, ok := strings.Cut(strings.TrimSpace(kv), "=")
+			if !ok {
+				return fmt.Errorf("parse STAGE_TIME_SHARES: %q is not stage=share", kv)
+			}
+			share, err := strconv.ParseFloat(value, 64)
+			if err != nil || share <= 0 || share > 1 {
+				return fmt.Errorf("parse STAGE_TIME_SHARES: share of %s must be in (0, 1], got %q", stage, value)
+			}
+			h.StageTimeShares[stage] = share
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"hello-world/pkg/webhook"
+)
+
+type (
+	// EventQueue enqueues validated webhook events for asynchronous processing.
+	EventQueue interface {
+		Enqueue(ctx context.Context, event *QueuedEvent) error
+	}
+
+	SQSSendMessageAPI interface {
+		SendMessage(ctx context.Context, input *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	}
+)
+
+// QueuedEvent is the message body of a webhook event in the queue.
+type QueuedEvent struct {
+	DeliveryID string       `json:"delivery_id"`
+	Body       webhook.Body `json:"body"`
+}
+
+// SQSEventQueue is an EventQueue backed by an SQS queue.
+type SQSEventQueue struct {
+	SQSSendMessageAPI SQSSendMessageAPI
+	QueueURL          string
+}
+
+// Enqueue sends the event to the queue as JSON.
+func (q *SQSEventQueue) Enqueue(ctx context.Context, event *QueuedEvent) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal queued event: %w", err)
+	}
+	out, err := q.SQSSendMessageAPI.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.QueueURL),
+		MessageBody: aws.String(string(b)),
+	})
+	if err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	slog.InfoContext(ctx, "Enqueued delivery", "delivery_id", event.DeliveryID, "message_id", aws.ToString(out.MessageId))
+	return nil
+}
+
+// HandleSQS handles webhook events enqueued by HandleAPIGatewayProxy.
+// It fetches data points and publishes SNS like the synchronous mode does.
+// The failed records are reported as the batch item failures, so that only they are retried
+// and the events processed successfully are not notified again.
+// The event source mapping must have ReportBatchItemFailures in its function response types.
+// A message that fails on its last receive is sent to h.DeadLetters instead, if set.
+func (h *Handler) HandleSQS(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	defer h.flushTracer(ctx)
+	ctx, cancel := h.withProcessingDeadline(ctx)
+	defer cancel()
+	resp := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+	for _, record := range event.Records {
+		msgCtx, end := h.startSpan(ctx, "sqs_message")
+		err := h.handleSQSMessage(msgCtx, record)
+		end(err)
+		if err != nil {
+			h.logger().ErrorContext(ctx, "Failed to process message", "message_id", record.MessageId, "error", err)
+			if h.deadLetterSQSMessage(ctx, record, err) {
+				// The message is deleted, as it is in the dead letters.
+				continue
+			}
+			resp.BatchItemFailures = append(resp.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: record.MessageId})
+		}
+	}
+	if n := len(resp.BatchItemFailures); n > 0 {
+		h.logger().WarnContext(ctx, "Reported failed messages to be retried", "failed", n, "total", len(event.Records))
+	}
+	return resp, nil
+}
+
+func (h *Handler) handleSQSMessage(ctx context.Context, record events.SQSMessage) (err error) {
+	var event QueuedEvent
+	if err := json.Unmarshal([]byte(record.Body), &event); err != nil {
+		return fmt.Errorf("unmarshal queued event: %w", err)
+	}
+
+	audit := &AuditRecord{
+		DeliveryID:      event.DeliveryID,
+		MeasurementUUID: event.Body.MeasurementUUID,
+		ResourceType:    event.Body.ResourceType,
+		Action:          event.Body.Action,
+		RequestID:       record.MessageId,
+		ReceivedAt:      h.now(),
+	}
+	ctx = withAuditRecord(ctx, audit)
+	defer func() {
+		if err != nil {
+			c := classifyError(err)
+			h.countError(ctx, c.errorType)
+			audit.Outcome, audit.Error = c.code, err.Error()
+		}
+		h.recordAudit(ctx, audit)
+	}()
+	defer func() {
+		if v := recover(); v != nil {
+			err = h.recovered(ctx, v)
+		}
+	}()
+
+	processor, ok := h.lookupProcessor(&event.Body)
+	if !ok {
+		h.logger().InfoContext(ctx, "Dropped unsupported resource type or action", "resource_type", event.Body.ResourceType, "action", event.Body.Action)
+		audit.Outcome = AuditOutcomeDropped
+		return nil
+	}
+	return h.processOnce(ctx, event.DeliveryID, processor, &event.Body)
+}
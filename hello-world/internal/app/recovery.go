@@ -0,0 +1,34 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrorCounter counts the errors as a metric, e.g. "Errors" with the dimension "ErrorType".
+type ErrorCounter interface {
+	CountError(ctx context.Context, errorType string) error
+}
+
+// ErrorTypePanic is the error type of the errors metric counting the recovered panics.
+const ErrorTypePanic = "Panic"
+
+// recovered converts the value recovered from a panic to an error.
+// The panic is logged with the stack trace and counted by h.ErrorCounters,
+// so that a malformed payload does not end the invocation with an unexplained error.
+// It must be called in the deferred function that called recover.
+func (h *Handler) recovered(ctx context.Context, v interface{}) error {
+	h.logger().ErrorContext(ctx, "Recovered from panic", "panic", fmt.Sprint(v), "stack", string(debug.Stack()))
+	h.countError(ctx, ErrorTypePanic)
+	return fmt.Errorf("panic: %v", v)
+}
+
+// countError counts the error of the type with h.ErrorCounters. A failure is only logged.
+func (h *Handler) countError(ctx context.Context, errorType string) {
+	for _, c := range h.ErrorCounters {
+		if err := c.CountError(ctx, errorType); err != nil {
+			h.logger().ErrorContext(ctx, "Failed to count error", "error_type", errorType, "error", err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type (
+	S3ListObjectsV2API interface {
+		ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	}
+
+	// S3EventReplayAPI gets the recorded events from S3.
+	S3EventReplayAPI interface {
+		S3GetObjectAPI
+		S3ListObjectsV2API
+	}
+)
+
+// ReplayEvents replays the recorded events at the given locations through the handler, in the order of the locations
+// and of the keys. A location is an "s3://bucket/key.json" object, all the objects under an "s3://bucket/prefix/" prefix,
+// or a local file. Each event is validated and processed as when it was received, so the events are processed
+// with the current analyzers and configuration. The timestamp validation, the idempotency, the event queue
+// and the recording are to be disabled by the caller, as the events are old, have been processed, and are recorded.
+// A dead letter without the request, e.g. of a queued event, is processed from its validated event.
+// An event that is not processed successfully is logged and the rest are still replayed.
+func (h *Handler) ReplayEvents(ctx context.Context, api S3EventReplayAPI, locations []string) error {
+	var replayed, failed int
+	for _, location := range locations {
+		uris, err := listRecordedEvents(ctx, api, location)
+		if err != nil {
+			return err
+		}
+		for _, uri := range uris {
+			replayed++
+			event, err := loadRecordedEvent(ctx, api, uri)
+			if err != nil {
+				h.logger().ErrorContext(ctx, "Failed to load recorded event", "location", uri, "error", err)
+				failed++
+				continue
+			}
+			if event.Body == "" && event.Event != nil {
+				if err := h.replayQueuedEvent(ctx, event.Event); err != nil {
+					h.logger().ErrorContext(ctx, "Failed to replay event", "location", uri, "error", err)
+					failed++
+					continue
+				}
+				h.logger().InfoContext(ctx, "Replayed event", "location", uri, "received_at", event.ReceivedAt, "delivery_id", event.Event.DeliveryID)
+				continue
+			}
+			res := h.handleWebhook(ctx, event.request())
+			if res.StatusCode >= 300 {
+				h.logger().ErrorContext(ctx, "Failed to replay event", "location", uri, "status", res.StatusCode, "response", res.Body)
+				failed++
+				continue
+			}
+			h.logger().InfoContext(ctx, "Replayed event", "location", uri, "received_at", event.ReceivedAt, "status", res.StatusCode)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d events failed", failed, replayed)
+	}
+	h.logger().InfoContext(ctx, "Replayed events", "count", replayed)
+	return nil
+}
+
+// replayQueuedEvent processes the validated event of a dead letter whose request is not known.
+func (h *Handler) replayQueuedEvent(ctx context.Context, event *QueuedEvent) error {
+	processor, ok := h.lookupProcessor(&event.Body)
+	if !ok {
+		return fmt.Errorf("%w: %s %s", ErrUnsupportedEvent, event.Body.ResourceType, event.Body.Action)
+	}
+	return h.processOnce(ctx, event.DeliveryID, processor, &event.Body)
+}
+
+// listRecordedEvents returns the locations of the events at the location.
+// A location in S3 not ending with ".json" is a prefix, and the ".json" objects under it are listed in key order.
+func listRecordedEvents(ctx context.Context, api S3ListObjectsV2API, location string) ([]string, error) {
+	if !strings.HasPrefix(location, "s3://") || strings.HasSuffix(location, ".json") {
+		return []string{location}, nil
+	}
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid S3 URI %q, want s3://bucket/prefix", location)
+	}
+	var (
+		uris  []string
+		token *string
+	)
+	for {
+		out, err := api.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            aws.String(bucket),
+			Prefix:            aws.String(prefix),
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", location, err)
+		}
+		for _, obj := range out.Contents {
+			if key := aws.ToString(obj.Key); strings.HasSuffix(key, ".json") {
+				uris = append(uris, "s3://"+bucket+"/"+key)
+			}
+		}
+		if !aws.ToBool(out.IsTruncated) {
+			return uris, nil
+		}
+		token = out.NextContinuationToken
+	}
+}
+
+// loadRecordedEvent loads the event of the "s3://bucket/key" URI or the local file.
+func loadRecordedEvent(ctx context.Context, api S3GetObjectAPI, location string) (*RecordedEvent, error) {
+	var b []byte
+	if strings.HasPrefix(location, "s3://") {
+		text, err := loadS3Text(ctx, api, location)
+		if err != nil {
+			return nil, err
+		}
+		b = []byte(text)
+	} else {
+		var err error
+		if b, err = os.ReadFile(location); err != nil {
+			return nil, fmt.Errorf("read %s: %w", location, err)
+		}
+	}
+	var event RecordedEvent
+	if err := json.Unmarshal(b, &event); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", location, err)
+	}
+	return &event, nil
+}
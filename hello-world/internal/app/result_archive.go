@@ -0,0 +1,191 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"hello-world/pkg/analyze"
+	"hello-world/pkg/intdash"
+)
+
+const (
+	// DefaultS3ResultKeyTemplate is the default template of the S3 object key of an archived result.
+	DefaultS3ResultKeyTemplate = `results/{{.MeasurementUUID}}/{{.ProcessedAt.Format "20060102T150405Z"}}{{with .DataID}}_{{.}}{{end}}.json`
+)
+
+type (
+	// ResultArchive archives analysis results.
+	ResultArchive interface {
+		Archive(ctx context.Context, result *AnalysisResult) error
+	}
+
+	S3PutObjectAPI interface {
+		PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	}
+)
+
+// AnalysisResult is the analysis result of a measurement.
+type AnalysisResult struct {
+	MeasurementUUID string `json:"measurement_uuid"`
+	// DataID is the data ID of the analyzed channel.
+	DataID string `json:"data_id"`
+	// EdgeUUID is the UUID of the edge of the measurement, if the measurement is fetched.
+	EdgeUUID string `json:"edge_uuid,omitempty"`
+	// Unit is the unit of the values of the channel, if configured.
+	Unit        string    `json:"unit,omitempty"`
+	ProcessedAt time.Time `json:"processed_at"`
+	// ProcessingTimeMillis is the time taken to fetch and analyze the data points.
+	ProcessingTimeMillis int64               `json:"processing_time_ms"`
+	Statistics           *analyze.Statistics `json:"statistics"`
+	// DataPoints are the valid data points, without NaN, Inf and the sentinel values.
+	DataPoints []intdash.DataPoint `json:"data_points"`
+	// DataQuality is the data-quality report of the fetched data points.
+	DataQuality *analyze.DataQuality `json:"data_quality"`
+	// Alerts are the names of the fired alert rules.
+	Alerts []string `json:"alerts,omitempty"`
+	// Partial is true if the fetch failed mid-way and the result is of the data points fetched until then.
+	Partial bool `json:"partial,omitempty"`
+	// PartialReason is the error of the fetch of a partial result.
+	PartialReason string `json:"partial_reason,omitempty"`
+	// Analyses are the results of the analyzers, including the statistics.
+	Analyses []*analyze.Analysis `json:"analyses"`
+}
+
+// S3ResultArchive is a ResultArchive that writes each result to S3 as a JSON object.
+type S3ResultArchive struct {
+	S3PutObjectAPI S3PutObjectAPI
+	Bucket         string
+	// KeyTemplate is a text/template executed with the AnalysisResult to make the object key.
+	KeyTemplate *template.Template
+}
+
+// NewS3ResultArchive returns a S3ResultArchive with the key template parsed from keyTemplate.
+// If keyTemplate is empty, DefaultS3ResultKeyTemplate is used.
+func NewS3ResultArchive(api S3PutObjectAPI, bucket, keyTemplate string) (*S3ResultArchive, error) {
+	if keyTemplate == "" {
+		keyTemplate = DefaultS3ResultKeyTemplate
+	}
+	tmpl, err := template.New("key").Parse(keyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse key template: %w", err)
+	}
+	return &S3ResultArchive{
+		S3PutObjectAPI: api,
+		Bucket:         bucket,
+		KeyTemplate:    tmpl,
+	}, nil
+}
+
+// Archive puts the result as JSON.
+func (a *S3ResultArchive) Archive(ctx context.Context, result *AnalysisResult) error {
+	var key bytes.Buffer
+	if err := a.KeyTemplate.Execute(&key, result); err != nil {
+		return fmt.Errorf("execute key template: %w", err)
+	}
+	b, err := json.Marshal(newArchivedResult(ctx, result))
+	if err != nil {
+		return fmt.Errorf("marshal result: %w", err)
+	}
+
+	_, err = a.S3PutObjectAPI.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(a.Bucket),
+		Key:         aws.String(key.String()),
+		Body:        bytes.NewReader(b),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("put object s3://%s/%s: %w", a.Bucket, key.String(), err)
+	}
+	slog.InfoContext(ctx, "Archived result", "location", fmt.Sprintf("s3://%s/%s", a.Bucket, key.String()))
+	return nil
+}
+
+// archivedResult is the JSON of an archived AnalysisResult. JSON has no NaN and Inf, e.g. the average of a channel
+// without valid data points, so the statistics are null instead, and the analyses that cannot be encoded are omitted.
+type archivedResult struct {
+	*AnalysisResult
+	Statistics *archivedStatistics `json:"statistics"`
+	Analyses   []*archivedAnalysis `json:"analyses"`
+}
+
+// archivedAnalysis is analyze.Analysis with the statistics of archivedStatistics.
+type archivedAnalysis struct {
+	Analyzer string      `json:"analyzer"`
+	Result   interface{} `json:"result"`
+}
+
+// archivedStatistics is analyze.Statistics with nullable values.
+type archivedStatistics struct {
+	Count            int                 `json:"count"`
+	Average          *float64            `json:"average"`
+	UnbiasedVariance *float64            `json:"unbiased_variance"`
+	StdDev           *float64            `json:"stddev"`
+	Min              *float64            `json:"min"`
+	Max              *float64            `json:"max"`
+	Median           *float64            `json:"median"`
+	MinTime          time.Time           `json:"min_time"`
+	MaxTime          time.Time           `json:"max_time"`
+	Percentiles      map[string]*float64 `json:"percentiles,omitempty"`
+}
+
+func newArchivedResult(ctx context.Context, result *AnalysisResult) *archivedResult {
+	archived := &archivedResult{
+		AnalysisResult: result,
+		Statistics:     newArchivedStatistics(result.Statistics),
+		Analyses:       make([]*archivedAnalysis, 0, len(result.Analyses)),
+	}
+	for _, analysis := range result.Analyses {
+		if stats, ok := analysis.Result.(*analyze.Statistics); ok {
+			archived.Analyses = append(archived.Analyses, &archivedAnalysis{Analyzer: analysis.Analyzer, Result: newArchivedStatistics(stats)})
+			continue
+		}
+		if _, err := json.Marshal(analysis.Result); err != nil {
+			// e.g. NaN in a custom metric
+			slog.ErrorContext(ctx, "Omitted the result from the archive", "data_id", result.DataID, "analyzer", analysis.Analyzer, "error", err)
+			continue
+		}
+		archived.Analyses = append(archived.Analyses, &archivedAnalysis{Analyzer: analysis.Analyzer, Result: analysis.Result})
+	}
+	return archived
+}
+
+func newArchivedStatistics(stats *analyze.Statistics) *archivedStatistics {
+	if stats == nil {
+		return nil
+	}
+	archived := &archivedStatistics{
+		Count:            stats.Count,
+		Average:          finiteOrNil(stats.Average),
+		UnbiasedVariance: finiteOrNil(stats.UnbiasedVariance),
+		StdDev:           finiteOrNil(stats.StdDev),
+		Min:              finiteOrNil(stats.Min),
+		Max:              finiteOrNil(stats.Max),
+		Median:           finiteOrNil(stats.Median),
+		MinTime:          stats.MinTime,
+		MaxTime:          stats.MaxTime,
+	}
+	if stats.Percentiles != nil {
+		archived.Percentiles = make(map[string]*float64, len(stats.Percentiles))
+		for name, v := range stats.Percentiles {
+			archived.Percentiles[name] = finiteOrNil(v)
+		}
+	}
+	return archived
+}
+
+// finiteOrNil returns nil for NaN and Inf, which cannot be encoded in JSON.
+func finiteOrNil(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"hello-world/pkg/analyze"
+)
+
+// fakeS3PutObjectAPI records the bodies of the put objects.
+type fakeS3PutObjectAPI struct {
+	bodies map[string][]byte
+}
+
+func (f *fakeS3PutObjectAPI) PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	b, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	if f.bodies == nil {
+		f.bodies = map[string][]byte{}
+	}
+	f.bodies[*input.Key] = b
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestS3ResultArchiveEmptyChannel(t *testing.T) {
+	// A channel without valid data points has the statistics of NaN.
+	stats, err := (&analyze.StatisticsAnalyzer{Names: []string{"average"}}).Analyze(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	result := &AnalysisResult{
+		MeasurementUUID: "00000000-0000-0000-0000-000000000000",
+		DataID:          "float64:speed",
+		ProcessedAt:     time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+		Statistics:      stats.(*analyze.Statistics),
+		DataQuality:     &analyze.DataQuality{},
+		Analyses:        []*analyze.Analysis{{Analyzer: "statistics", Result: stats}},
+	}
+
+	api := &fakeS3PutObjectAPI{}
+	archive, err := NewS3ResultArchive(api, "bucket", "")
+	if err != nil {
+		t.Fatalf("NewS3ResultArchive() error = %v", err)
+	}
+	if err := archive.Archive(context.Background(), result); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+
+	const key = "results/00000000-0000-0000-0000-000000000000/20240115T120000Z_float64:speed.json"
+	var got struct {
+		DataID     string                   `json:"data_id"`
+		Statistics map[string]interface{}   `json:"statistics"`
+		Analyses   []map[string]interface{} `json:"analyses"`
+	}
+	if err := json.Unmarshal(api.bodies[key], &got); err != nil {
+		t.Fatalf("unmarshal archived result %q: %v", api.bodies[key], err)
+	}
+	if got.DataID != result.DataID {
+		t.Errorf("data_id = %q, want %q", got.DataID, result.DataID)
+	}
+	if v, ok := got.Statistics["average"]; !ok || v != nil {
+		t.Errorf("statistics.average = %v, want null", v)
+	}
+	if len(got.Analyses) != 1 {
+		t.Errorf("analyses = %v, want the statistics", got.Analyses)
+	}
+}
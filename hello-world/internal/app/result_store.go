@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"hello-world/pkg/webhook"
+)
+
+const (
+	dynamoDBMetadataSortKey     = "META"
+	dynamoDBResultSortKeyPrefix = "RESULT#"
+	// dynamoDBBatchWriteLimit is the maximum number of requests in a BatchWriteItem call.
+	dynamoDBBatchWriteLimit = 25
+)
+
+// DynamoDBResultStore is a ResultStore backed by a DynamoDB table.
+// The table must have the string partition key "measurement_uuid" and the string sort key "sk".
+// The metadata of a measurement is stored with sk "META", and each analysis result
+// with sk "RESULT#<processed_at>#<data_id>", so that the results of a measurement are queried in time order.
+type DynamoDBResultStore struct {
+	DynamoDBAPI DynamoDBAPI
+	TableName   string
+}
+
+// SaveMeasurement stores the event metadata of the measurement.
+func (s *DynamoDBResultStore) SaveMeasurement(ctx context.Context, body *webhook.Body) error {
+	_, err := s.DynamoDBAPI.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.TableName),
+		Item: map[string]types.AttributeValue{
+			"measurement_uuid": &types.AttributeValueMemberS{Value: body.MeasurementUUID},
+			"sk":               &types.AttributeValueMemberS{Value: dynamoDBMetadataSortKey},
+			"resource_type":    &types.AttributeValueMemberS{Value: body.ResourceType},
+			"last_action":      &types.AttributeValueMemberS{Value: body.Action},
+			"updated_at":       &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("put measurement item: %w", err)
+	}
+	return nil
+}
+
+// SaveResult stores the summary of the analysis result. The data points are not stored.
+func (s *DynamoDBResultStore) SaveResult(ctx context.Context, result *AnalysisResult) error {
+	processedAt := result.ProcessedAt.UTC().Format(time.RFC3339Nano)
+	sk := dynamoDBResultSortKeyPrefix + processedAt
+	if result.DataID != "" {
+		sk += "#" + result.DataID
+	}
+	item := map[string]types.AttributeValue{
+		"measurement_uuid":   &types.AttributeValueMemberS{Value: result.MeasurementUUID},
+		"sk":                 &types.AttributeValueMemberS{Value: sk},
+		"data_id":            &types.AttributeValueMemberS{Value: result.DataID},
+		"processed_at":       &types.AttributeValueMemberS{Value: processedAt},
+		"processing_time_ms": &types.AttributeValueMemberN{Value: strconv.FormatInt(result.ProcessingTimeMillis, 10)},
+		"count":              &types.AttributeValueMemberN{Value: strconv.Itoa(result.Statistics.Count)},
+	}
+	// DynamoDB numbers cannot be NaN or Inf, e.g. the average of no data points.
+	if v, ok := dynamoDBNumber(result.Statistics.Average); ok {
+		item["average"] = v
+	}
+	if v, ok := dynamoDBNumber(result.Statistics.UnbiasedVariance); ok {
+		item["unbiased_variance"] = v
+	}
+
+	_, err := s.DynamoDBAPI.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("put result item: %w", err)
+	}
+	return nil
+}
+
+// DeleteResults deletes the metadata and all results of the measurement.
+func (s *DynamoDBResultStore) DeleteResults(ctx context.Context, measurementUUID string) error {
+	var keys []map[string]types.AttributeValue
+	var startKey map[string]types.AttributeValue
+	for {
+		out, err := s.DynamoDBAPI.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(s.TableName),
+			KeyConditionExpression: aws.String("measurement_uuid = :uuid"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":uuid": &types.AttributeValueMemberS{Value: measurementUUID},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return fmt.Errorf("query items: %w", err)
+		}
+		for _, item := range out.Items {
+			keys = append(keys, map[string]types.AttributeValue{
+				"measurement_uuid": item["measurement_uuid"],
+				"sk":               item["sk"],
+			})
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		startKey = out.LastEvaluatedKey
+	}
+
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > dynamoDBBatchWriteLimit {
+			n = dynamoDBBatchWriteLimit
+		}
+		requests := make([]types.WriteRequest, 0, n)
+		for _, key := range keys[:n] {
+			requests = append(requests, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: key}})
+		}
+		keys = keys[n:]
+
+		out, err := s.DynamoDBAPI.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{s.TableName: requests},
+		})
+		if err != nil {
+			return fmt.Errorf("batch delete items: %w", err)
+		}
+		// Unprocessed items are retried with the next batch.
+		for _, r := range out.UnprocessedItems[s.TableName] {
+			if r.DeleteRequest != nil {
+				keys = append(keys, r.DeleteRequest.Key)
+			}
+		}
+	}
+	slog.InfoContext(ctx, "Deleted results of measurement", "measurement_uuid", measurementUUID)
+	return nil
+}
+
+func dynamoDBNumber(v float64) (types.AttributeValue, bool) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, false
+	}
+	return &types.AttributeValueMemberN{Value: strconv.FormatFloat(v, 'g', -1, 64)}, true
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"hello-world/pkg/intdash"
+	"hello-world/pkg/notify"
+	"hello-world/pkg/webhook"
+)
+
+// Route routes the deliveries of intdash projects or edges, e.g. of a team, to their own secret,
+// notifiers and channels, so that one handler serves multiple projects in isolation.
+type Route struct {
+	// Name identifies the route in the logs.
+	Name string
+	// ProjectUUIDs are the projects of the measurements routed.
+	ProjectUUIDs []string
+	// EdgeUUIDs are the edges of the measurements routed, if the project does not match any route.
+	// Matching the edge requires fetching the measurement.
+	EdgeUUIDs []string
+	// KeySource, if set, provides the HMAC keys of the deliveries of the projects, instead of those of the handler.
+	// The edges cannot have their own keys, as the edge is not known before the signature is validated.
+	KeySource webhook.KeySource
+	// Notifiers, if set, replace the notifiers of the handler.
+	Notifiers []notify.Notifier
+	// Channels, if set, replace the channels of the handler.
+	Channels []*Channel
+}
+
+// RouteConfig is an element of the ROUTES configuration, a JSON array such as
+//
+//	[
+//	  {"name": "team-a", "project_uuids": ["8c1b..."], "config": {"SNS_TOPIC_ARN": "arn:aws:sns:...:team-a", "WEBHOOK_SECRET_ID": "team-a"}},
+//	  {"name": "test-bench", "edge_uuids": ["0f4c..."], "config": {"CHANNELS": "[{\"data_id\": \"float64:torque\"}]"}}
+//	]
+//
+// Config overrides the configuration values of the notifiers, e.g. "NOTIFIERS" or "SNS_TOPIC_ARN",
+// and of the channels, e.g. "CHANNELS" or "ANALYZERS", for the route. The route has its own secret
+// if "WEBHOOK_SECRET" or "WEBHOOK_SECRET_ID" is overridden.
+type RouteConfig struct {
+	Name         string            `json:"name"`
+	ProjectUUIDs []string          `json:"project_uuids"`
+	EdgeUUIDs    []string          `json:"edge_uuids"`
+	Config       map[string]string `json:"config"`
+}
+
+// parseRouteConfigs parses the ROUTES configuration.
+func parseRouteConfigs(s string) ([]*RouteConfig, error) {
+	var configs []*RouteConfig
+	if err := json.Unmarshal([]byte(s), &configs); err != nil {
+		return nil, fmt.Errorf("unmarshal routes: %w", err)
+	}
+	for i, c := range configs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("name of route %d is empty", i)
+		}
+		if len(c.ProjectUUIDs) == 0 && len(c.EdgeUUIDs) == 0 {
+			return nil, fmt.Errorf("route %s has neither project_uuids nor edge_uuids", c.Name)
+		}
+		_, secret := c.Config["WEBHOOK_SECRET"]
+		_, secretID := c.Config["WEBHOOK_SECRET_ID"]
+		if (secret || secretID) && len(c.ProjectUUIDs) == 0 {
+			return nil, fmt.Errorf("route %s has a secret but no project_uuids", c.Name)
+		}
+	}
+	return configs, nil
+}
+
+// projectRoute returns the first route of the project, or nil if none.
+func (h *Handler) projectRoute(projectUUID string) *Route {
+	if projectUUID == "" {
+		return nil
+	}
+	for _, route := range h.Routes {
+		if slices.Contains(route.ProjectUUIDs, projectUUID) {
+			return route
+		}
+	}
+	return nil
+}
+
+// edgeRoute returns the first route of the edge of the measurement, or nil if none.
+func (h *Handler) edgeRoute(measurement *intdash.Measurement) *Route {
+	if measurement == nil || measurement.EdgeUUID == "" {
+		return nil
+	}
+	for _, route := range h.Routes {
+		if slices.Contains(route.EdgeUUIDs, measurement.EdgeUUID) {
+			return route
+		}
+	}
+	return nil
+}
+
+// hasEdgeRoutes reports whether any route matches edges.
+func (h *Handler) hasEdgeRoutes() bool {
+	for _, route := range h.Routes {
+		if len(route.EdgeUUIDs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// withRoute returns a copy of h with the notifiers and the channels of the route, or h if route is nil.
+func (h *Handler) withRoute(route *Route) *Handler {
+	if route == nil {
+		return h
+	}
+	rh := *h
+	if len(route.Notifiers) > 0 {
+		rh.Notifiers = route.Notifiers
+	}
+	if len(route.Channels) > 0 {
+		rh.Channels = route.Channels
+	}
+	return &rh
+}
+
+// keyRoute returns the route of the project whose keys validate the signature, or nil if the keys of the handler do.
+func (h *Handler) keyRoute(projectUUID string) *Route {
+	if route := h.projectRoute(projectUUID); route != nil && route.KeySource != nil {
+		return route
+	}
+	return nil
+}
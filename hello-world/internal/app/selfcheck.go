@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"hello-world/pkg/notify"
+	"hello-world/pkg/webhook"
+)
+
+// SelfCheckTimeout is the timeout of each check of the self-check.
+const SelfCheckTimeout = 10 * time.Second
+
+// SelfCheckReport is the result of the self-check of the handler, which checks the access to its dependencies
+// so that a misconfiguration is found at deploy time rather than at the first delivery.
+type SelfCheckReport struct {
+	// OK is true if none of the checks failed.
+	OK     bool          `json:"ok"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckResult is the result of a check of the self-check.
+type CheckResult struct {
+	// Name identifies the check, e.g. "secret", "intdash_api" or "notifier:sns".
+	Name string `json:"name"`
+	OK   bool   `json:"ok"`
+	// Skipped is true if the dependency cannot be checked, e.g. a notifier without a way to check its destination.
+	// A skipped check is OK.
+	Skipped  bool   `json:"skipped,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// errCheckSkipped is returned by a check that cannot be done.
+var errCheckSkipped = fmt.Errorf("check skipped")
+
+// SelfCheck checks that the webhook secret is available, that the intdash API is reachable and accepts the credentials,
+// and that the destinations of the notifiers are accessible, including those of the routes.
+func (h *Handler) SelfCheck(ctx context.Context) *SelfCheckReport {
+	report := &SelfCheckReport{OK: true}
+	check := func(name string, fn func(ctx context.Context) error) {
+		ctx, cancel := context.WithTimeout(ctx, SelfCheckTimeout)
+		defer cancel()
+		start := time.Now()
+		err := fn(ctx)
+		result := CheckResult{Name: name, OK: err == nil, Duration: time.Since(start).String()}
+		switch {
+		case err == errCheckSkipped:
+			result.OK = true
+			result.Skipped = true
+		case err != nil:
+			result.Error = err.Error()
+			report.OK = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	if h.SignatureValidator != nil {
+		check("secret", func(context.Context) error { return errCheckSkipped })
+	} else {
+		check("secret", func(ctx context.Context) error { return checkKeySource(ctx, h.keySource()) })
+	}
+	tenants := make([]string, 0, len(h.TenantKeys))
+	for tenant := range h.TenantKeys {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		source := h.TenantKeys[tenant]
+		check("secret:tenant:"+tenant, func(ctx context.Context) error { return checkKeySource(ctx, source) })
+	}
+	for _, r := range h.Routes {
+		if r.KeySource != nil {
+			source := r.KeySource
+			check("secret:route:"+r.Name, func(ctx context.Context) error { return checkKeySource(ctx, source) })
+		}
+	}
+
+	check("intdash_api", func(ctx context.Context) error {
+		checker, ok := h.IntdashAPI.(interface {
+			Check(ctx context.Context) error
+		})
+		if !ok {
+			return errCheckSkipped
+		}
+		return checker.Check(ctx)
+	})
+
+	checkNotifiers := func(prefix string, notifiers []notify.Notifier) {
+		for _, n := range notifiers {
+			n := n
+			check(prefix+n.Name(), func(ctx context.Context) error {
+				checker, ok := n.(notify.Checker)
+				if !ok {
+					return errCheckSkipped
+				}
+				return checker.Check(ctx)
+			})
+		}
+	}
+	checkNotifiers("notifier:", h.Notifiers)
+	for _, r := range h.Routes {
+		checkNotifiers("notifier:route:"+r.Name+":", r.Notifiers)
+	}
+	return report
+}
+
+// checkKeySource checks that the source provides at least a key.
+func checkKeySource(ctx context.Context, source webhook.KeySource) error {
+	keys, err := source.Keys(ctx)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no webhook secret is configured")
+	}
+	return nil
+}
+
+// HandleSelfCheck is the Lambda handler of the self-check, invoked with any event, e.g. a test event of the console.
+// It returns the report, and fails the invocation if any check failed.
+func (h *Handler) HandleSelfCheck(ctx context.Context) (*SelfCheckReport, error) {
+	report := h.SelfCheck(ctx)
+	if !report.OK {
+		b, _ := json.Marshal(report)
+		return report, fmt.Errorf("self-check failed: %s", b)
+	}
+	return report, nil
+}
+
+// RunSelfCheck runs the self-check of h and writes the report to w as JSON.
+// It returns an error if any check failed.
+func RunSelfCheck(h *Handler, w io.Writer) error {
+	report := h.SelfCheck(context.Background())
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	if !report.OK {
+		return fmt.Errorf("self-check failed")
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultServerAddr is the default address the standalone server listens on.
+	DefaultServerAddr = ":8080"
+	// serverShutdownTimeout is how long the standalone server waits for in-flight requests on shutdown.
+	serverShutdownTimeout = 30 * time.Second
+)
+
+// ServeHTTP handles the intdash webhook over plain net/http, and serves the status endpoints.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request := &webhookRequest{
+		RequestID:         r.Header.Get("X-Request-Id"),
+		MultiValueHeaders: r.Header,
+		Path:              r.URL.Path,
+		SourceIP:          remoteIP(r.RemoteAddr),
+	}
+
+	if res, ok := h.serveStatus(r.Method, r.URL.Path); ok {
+		writeResponse(w, res)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeResponse(w, errorResponse(request, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed, "Method not allowed"))
+		return
+	}
+
+	// One byte more than the limit is read, so that a body above it is rejected by handleWebhook.
+	body, err := io.ReadAll(io.LimitReader(r.Body, h.maxBodySize()+1))
+	if err != nil {
+		h.logger().ErrorContext(r.Context(), "Failed to read request body", "error", err)
+		writeResponse(w, errorResponse(request, http.StatusBadRequest, ErrorCodeInvalidBody, "Invalid request body"))
+		return
+	}
+	request.Body = string(body)
+	h.logger().InfoContext(r.Context(), "Got request", "method", r.Method, "path", r.URL.Path, "source_ip", r.RemoteAddr)
+
+	writeResponse(w, h.handleWebhook(r.Context(), request))
+}
+
+// writeResponse writes the webhook response to w.
+func writeResponse(w http.ResponseWriter, res webhookResponse) {
+	for k, v := range res.Headers {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(res.StatusCode)
+	if _, err := io.WriteString(w, res.Body); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+// RunServer serves the handler over HTTP, or HTTPS if both certFile and keyFile are given,
+// until SIGINT or SIGTERM is received. In-flight requests are drained before it returns.
+func RunServer(h http.Handler, addr, certFile, keyFile string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", addr)
+		var err error
+		if certFile != "" && keyFile != "" {
+			err = srv.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// ParseCIDRs parses the comma-separated CIDRs or IP addresses, e.g. "203.0.113.0/24,2001:db8::/32,198.51.100.7".
+func ParseCIDRs(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("parse IP address %q: %w", v, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse CIDR %q: %w", v, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// validateSourceIP validates the source IP of the request against h.AllowedSourceIPs, if set.
+// A request of an unknown source IP is rejected.
+func (h *Handler) validateSourceIP(request *webhookRequest) error {
+	if len(h.AllowedSourceIPs) == 0 {
+		return nil
+	}
+	if request.SourceIP == "" {
+		return fmt.Errorf("source IP is unknown")
+	}
+	addr, err := netip.ParseAddr(request.SourceIP)
+	if err != nil {
+		return fmt.Errorf("parse source IP: %w", err)
+	}
+	addr = addr.Unmap()
+	for _, prefix := range h.AllowedSourceIPs {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("source IP %s is not allowed", addr)
+}
+
+// albSourceIP returns the source IP of an ALB request, the last address of X-Forwarded-For, which is appended by the ALB.
+// The addresses before it are given by the client and cannot be trusted.
+func albSourceIP(request *webhookRequest) string {
+	values := strings.Split(request.header("X-Forwarded-For"), ",")
+	return strings.TrimSpace(values[len(values)-1])
+}
+
+// remoteIP returns the IP address of the remote address "host:port" of a net/http request.
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// The paths of the status endpoints, served without a signature in server and Function URL modes.
+const (
+	HealthPath  = "/healthz"
+	VersionPath = "/version"
+)
+
+// version is the version of the build, set with -ldflags "-X hello-world/internal/app.version=v1.2.3".
+var version = "dev"
+
+// VersionInfo is the JSON body of GET /version.
+type VersionInfo struct {
+	Version string `json:"version"`
+	// GitSHA and GitTime are the commit the binary was built from, and Modified reports uncommitted changes.
+	GitSHA    string        `json:"git_sha,omitempty"`
+	GitTime   string        `json:"git_time,omitempty"`
+	Modified  bool          `json:"modified,omitempty"`
+	GoVersion string        `json:"go_version"`
+	Config    ConfigSummary `json:"config"`
+}
+
+// ConfigSummary is the summary of the configuration of the handler, without the secrets and the URLs.
+type ConfigSummary struct {
+	Notifiers          []string `json:"notifiers"`
+	Channels           []string `json:"channels"`
+	Routes             []string `json:"routes,omitempty"`
+	Tenants            int      `json:"tenants,omitempty"`
+	SignatureAlgorithm string   `json:"signature_algorithm"`
+	NotificationFormat string   `json:"notification_format"`
+	Queue              bool     `json:"queue"`
+	Idempotency        bool     `json:"idempotency"`
+	Audit              bool     `json:"audit"`
+	EventRecording     bool     `json:"event_recording"`
+	TimestampTolerance string   `json:"timestamp_tolerance,omitempty"`
+	SourceIPAllowlist  bool     `json:"source_ip_allowlist"`
+	// MaxBodySize is negative if the size is not limited.
+	MaxBodySize int64 `json:"max_body_size"`
+	DryRun      bool  `json:"dry_run"`
+}
+
+// serveStatus serves GET or HEAD of the status endpoints, and reports whether the path is one of them.
+func (h *Handler) serveStatus(method, path string) (webhookResponse, bool) {
+	if path != HealthPath && path != VersionPath {
+		return webhookResponse{}, false
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		return webhookResponse{
+			StatusCode: http.StatusMethodNotAllowed,
+			Headers:    map[string]string{"Allow": http.MethodGet + ", " + http.MethodHead},
+		}, true
+	}
+	if path == HealthPath {
+		return jsonResponse(http.StatusOK, map[string]string{"status": "ok"}), true
+	}
+	return jsonResponse(http.StatusOK, h.versionInfo()), true
+}
+
+// versionInfo returns the build information and the configuration summary of the handler.
+func (h *Handler) versionInfo() *VersionInfo {
+	info := &VersionInfo{Version: version, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.GitSHA = s.Value
+			case "vcs.time":
+				info.GitTime = s.Value
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	c := &info.Config
+	for _, n := range h.Notifiers {
+		c.Notifiers = append(c.Notifiers, n.Name())
+	}
+	for _, ch := range h.Channels {
+		c.Channels = append(c.Channels, ch.DataID)
+	}
+	for _, r := range h.Routes {
+		c.Routes = append(c.Routes, r.Name)
+	}
+	c.Tenants = len(h.TenantKeys)
+	c.SignatureAlgorithm = h.signatureAlgorithm().Name
+	if h.SignatureValidator != nil {
+		c.SignatureAlgorithm = "custom"
+	}
+	c.NotificationFormat = h.NotificationFormat
+	if c.NotificationFormat == "" {
+		c.NotificationFormat = NotificationFormatText
+	}
+	c.Queue = h.EventQueue != nil
+	c.Idempotency = h.IdempotencyStore != nil
+	c.Audit = h.AuditLog != nil
+	c.EventRecording = h.EventRecorder != nil
+	if h.TimestampTolerance > 0 {
+		c.TimestampTolerance = h.TimestampTolerance.String()
+	}
+	c.SourceIPAllowlist = len(h.AllowedSourceIPs) > 0
+	c.MaxBodySize = h.maxBodySize()
+	if h.MaxBodySize < 0 {
+		c.MaxBodySize = -1
+	}
+	c.DryRun = h.DryRun
+	return info
+}
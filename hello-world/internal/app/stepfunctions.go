@@ -0,0 +1,305 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sfn/types"
+
+	"hello-world/pkg/intdash"
+	"hello-world/pkg/webhook"
+)
+
+// The steps of the state machine of the Step Functions mode, each a task of HandleStepFunctions.
+const (
+	// StepPrepare routes the event and fetches the measurement if needed. The events other than a finished measurement
+	// are processed in this step.
+	StepPrepare = "prepare"
+	// StepFetch fetches the data points of a channel and stages them in S3. It runs for each channel in a Map state.
+	StepFetch = "fetch"
+	// StepProcess analyzes the staged data points, and archives, writes back and notifies the results.
+	// The analysis and the notification are a step, as the analysis results cannot be passed between the states.
+	StepProcess = "process"
+)
+
+type (
+	SFNStartExecutionAPI interface {
+		StartExecution(ctx context.Context, input *sfn.StartExecutionInput, optFns ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error)
+	}
+
+	// S3DataPointStagingAPI is the S3 API to stage the data points.
+	S3DataPointStagingAPI interface {
+		S3GetObjectAPI
+		S3PutObjectAPI
+	}
+)
+
+// StepFunctionsEventQueue is an EventQueue that starts an execution of the state machine for each event,
+// so that a long analysis is not bounded by the timeout of a function. The execution is named after the delivery ID,
+// so a retried delivery does not start another execution.
+type StepFunctionsEventQueue struct {
+	SFNStartExecutionAPI SFNStartExecutionAPI
+	StateMachineArn      string
+}
+
+// Enqueue starts the execution with the event as the input.
+func (q *StepFunctionsEventQueue) Enqueue(ctx context.Context, event *QueuedEvent) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal queued event: %w", err)
+	}
+	out, err := q.SFNStartExecutionAPI.StartExecution(ctx, &sfn.StartExecutionInput{
+		StateMachineArn: aws.String(q.StateMachineArn),
+		Name:            aws.String(executionName(event.DeliveryID)),
+		Input:           aws.String(string(b)),
+	})
+	var exists *types.ExecutionAlreadyExists
+	if errors.As(err, &exists) {
+		slog.InfoContext(ctx, "Skipped already started delivery", "delivery_id", event.DeliveryID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("start execution: %w", err)
+	}
+	slog.InfoContext(ctx, "Started execution", "delivery_id", event.DeliveryID, "execution_arn", aws.ToString(out.ExecutionArn))
+	return nil
+}
+
+// executionName returns the name of the execution of the delivery, which may have up to 80 letters, digits, - and _.
+func executionName(deliveryID string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, deliveryID)
+	if len(name) > 80 {
+		sum := sha256.Sum256([]byte(deliveryID))
+		return hex.EncodeToString(sum[:])
+	}
+	return name
+}
+
+// StepFunctionsTask is the input of a task of the state machine.
+type StepFunctionsTask struct {
+	// Step is StepPrepare, StepFetch or StepProcess.
+	Step  string      `json:"step"`
+	Event QueuedEvent `json:"event"`
+	// Measurement is the measurement fetched by StepPrepare, if the route needs it.
+	Measurement *intdash.Measurement `json:"measurement"`
+	// DataID is the channel fetched by StepFetch.
+	DataID string `json:"data_id,omitempty"`
+	// Fetched are the channels staged by StepFetch, processed by StepProcess.
+	Fetched []*StagedChannel `json:"fetched,omitempty"`
+}
+
+// StepFunctionsState is the output of StepPrepare.
+type StepFunctionsState struct {
+	Event       QueuedEvent          `json:"event"`
+	Measurement *intdash.Measurement `json:"measurement"`
+	// DataIDs are the channels of the route of the measurement, each fetched by StepFetch.
+	DataIDs []string `json:"data_ids"`
+	// Done is true if the event has already been processed, e.g. an event other than a finished measurement.
+	Done bool `json:"done"`
+}
+
+// StagedChannel is the output of StepFetch, the data points of a channel staged in S3.
+type StagedChannel struct {
+	DataID string `json:"data_id"`
+	Key    string `json:"key"`
+	Count  int    `json:"count"`
+	// PartialReason is the error of the fetch if the data points are those fetched before it.
+	PartialReason string `json:"partial_reason,omitempty"`
+}
+
+// S3DataPointStaging stages the fetched data points in S3 between the steps, as they exceed the size limit of
+// the state of an execution. They are encoded with gob, which keeps NaN and Inf for the data-quality report.
+// The objects should be expired by a lifecycle rule of the bucket.
+type S3DataPointStaging struct {
+	S3DataPointStagingAPI S3DataPointStagingAPI
+	Bucket                string
+	// Prefix is the prefix of the keys, e.g. "staging/".
+	Prefix string
+}
+
+// Put stages the data points of the channel of the delivery and returns the key of the object.
+func (s *S3DataPointStaging) Put(ctx context.Context, deliveryID, dataID string, dataPoints []intdash.DataPoint) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(dataPoints); err != nil {
+		return "", fmt.Errorf("encode data points: %w", err)
+	}
+	key := s.Prefix + executionName(deliveryID) + "/" + url.PathEscape(dataID) + ".gob"
+	if _, err := s.S3DataPointStagingAPI.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(buf.Bytes()),
+		ContentType: aws.String("application/octet-stream"),
+	}); err != nil {
+		return "", fmt.Errorf("put staged data points: %w", err)
+	}
+	return key, nil
+}
+
+// Get gets the staged data points of the key.
+func (s *S3DataPointStaging) Get(ctx context.Context, key string) ([]intdash.DataPoint, error) {
+	out, err := s.S3DataPointStagingAPI.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get staged data points %s: %w", key, err)
+	}
+	defer out.Body.Close()
+	var dataPoints []intdash.DataPoint
+	if err := gob.NewDecoder(out.Body).Decode(&dataPoints); err != nil {
+		return nil, fmt.Errorf("decode staged data points %s: %w", key, err)
+	}
+	return dataPoints, nil
+}
+
+// stagedIntdashAPI is an IntdashAPI that returns the staged data points instead of fetching them.
+type stagedIntdashAPI struct {
+	IntdashAPI
+	channels map[string]*stagedDataPoints
+}
+
+type stagedDataPoints struct {
+	dataPoints    []intdash.DataPoint
+	partialReason string
+}
+
+// FetchFloat64DataPoints returns the staged data points of the data ID, with the error of the fetch if they are partial.
+func (a *stagedIntdashAPI) FetchFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr intdash.TimeRange) ([]intdash.DataPoint, error) {
+	c, ok := a.channels[dataID]
+	if !ok {
+		return nil, fmt.Errorf("data points of %s are not staged", dataID)
+	}
+	if c.partialReason != "" {
+		return c.dataPoints, errors.New(c.partialReason)
+	}
+	return c.dataPoints, nil
+}
+
+// HandleStepFunctions handles a task of the state machine started by StepFunctionsEventQueue.
+// The state machine runs StepPrepare, then StepFetch for each channel in a Map state, then StepProcess.
+func (h *Handler) HandleStepFunctions(ctx context.Context, task *StepFunctionsTask) (_ interface{}, err error) {
+	defer h.flushTracer(ctx)
+	ctx, cancel := h.withProcessingDeadline(ctx)
+	defer cancel()
+	defer func() {
+		if v := recover(); v != nil {
+			err = h.recovered(ctx, v)
+		}
+	}()
+	if h.DataPointStaging == nil {
+		return nil, fmt.Errorf("data point staging is not configured")
+	}
+	ctx = withDeliveryID(ctx, task.Event.DeliveryID)
+	ctx = withLogAttrs(ctx, "step", task.Step, "delivery_id", task.Event.DeliveryID, "measurement_uuid", task.Event.Body.MeasurementUUID)
+
+	switch task.Step {
+	case StepPrepare:
+		return h.prepareStep(ctx, task)
+	case StepFetch:
+		return h.fetchStep(ctx, task)
+	case StepProcess:
+		return nil, h.auditEvent(ctx, &task.Event, func(ctx context.Context) error {
+			// The step is retried by the state machine, so it is claimed not to notify again after a failure.
+			return h.processOnce(ctx, task.Event.DeliveryID, ProcessorFunc(func(ctx context.Context, body *webhook.Body) error {
+				return h.processStep(ctx, task)
+			}), &task.Event.Body)
+		})
+	}
+	return nil, fmt.Errorf("unknown step %q", task.Step)
+}
+
+// prepareStep routes the finished measurement and returns its channels.
+// An event other than a finished measurement is processed right away.
+func (h *Handler) prepareStep(ctx context.Context, task *StepFunctionsTask) (*StepFunctionsState, error) {
+	body := &task.Event.Body
+	state := &StepFunctionsState{Event: task.Event, DataIDs: []string{}}
+	if body.ResourceType != webhook.ResourceTypeMeasurement || body.Action != webhook.ActionFinished {
+		state.Done = true
+		return state, h.auditEvent(ctx, &task.Event, func(ctx context.Context) error {
+			processor, ok := h.lookupProcessor(body)
+			if !ok {
+				h.logger().InfoContext(ctx, "Dropped unsupported resource type or action", "resource_type", body.ResourceType, "action", body.Action)
+				setAuditOutcome(ctx, AuditOutcomeDropped)
+				return nil
+			}
+			return h.processOnce(ctx, task.Event.DeliveryID, processor, body)
+		})
+	}
+
+	_, rh, measurement, err := h.routeMeasurement(ctx, body, nil)
+	if err != nil {
+		return nil, err
+	}
+	state.Measurement = measurement
+	for _, ch := range rh.Channels {
+		state.DataIDs = append(state.DataIDs, ch.DataID)
+	}
+	return state, nil
+}
+
+// fetchStep fetches the data points of the channel of the task and stages them.
+func (h *Handler) fetchStep(ctx context.Context, task *StepFunctionsTask) (*StagedChannel, error) {
+	body := &task.Event.Body
+	ctx, rh, measurement, err := h.routeMeasurement(ctx, body, task.Measurement)
+	if err != nil {
+		return nil, err
+	}
+	var ch *Channel
+	for _, c := range rh.Channels {
+		if c.DataID == task.DataID {
+			ch = c
+			break
+		}
+	}
+	if ch == nil {
+		return nil, fmt.Errorf("unknown channel %s", task.DataID)
+	}
+	ctx = withLogAttrs(ctx, "data_id", ch.DataID)
+	dataPoints, partialReason, err := rh.fetchChannel(ctx, body.MeasurementUUID, ch, measurement)
+	if err != nil {
+		return nil, err
+	}
+	key, err := h.DataPointStaging.Put(ctx, task.Event.DeliveryID, ch.DataID, dataPoints)
+	if err != nil {
+		return nil, err
+	}
+	return &StagedChannel{DataID: ch.DataID, Key: key, Count: len(dataPoints), PartialReason: partialReason}, nil
+}
+
+// processStep analyzes the staged data points of the channels, and archives, writes back and notifies the results.
+// It is called by processOnce, which claims the delivery.
+func (h *Handler) processStep(ctx context.Context, task *StepFunctionsTask) error {
+	body := &task.Event.Body
+	ctx, rh, measurement, err := h.routeMeasurement(ctx, body, task.Measurement)
+	if err != nil {
+		return err
+	}
+	api := &stagedIntdashAPI{IntdashAPI: rh.IntdashAPI, channels: map[string]*stagedDataPoints{}}
+	for _, c := range task.Fetched {
+		dataPoints, err := h.DataPointStaging.Get(ctx, c.Key)
+		if err != nil {
+			return err
+		}
+		api.channels[c.DataID] = &stagedDataPoints{dataPoints: dataPoints, partialReason: c.PartialReason}
+	}
+	sh := *rh
+	sh.IntdashAPI = api
+	return sh.processMeasurement(ctx, body, measurement)
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"hello-world/pkg/webhook"
+)
+
+// DefaultTenantHeader is the default name of the header of the tenant of a delivery.
+const DefaultTenantHeader = "x-intdash-tenant"
+
+// Kinds of TenantSource.
+const (
+	TenantFromHeader = "header"
+	TenantFromPath   = "path"
+	TenantFromField  = "field"
+)
+
+// TenantSource is where the tenant of a delivery is taken from, e.g. the intdash organization
+// whose webhook points at the deployment shared with other organizations.
+type TenantSource struct {
+	// Kind is TenantFromHeader, TenantFromPath or TenantFromField.
+	Kind string
+	// Name is the name of the header, of the path parameter, or of the string field of the body.
+	// For the event sources without path parameters, the tenant is the last segment of the path.
+	Name string
+}
+
+// ParseTenantSource parses a tenant source of the form "<kind>:<name>",
+// e.g. "header:x-intdash-tenant", "path:tenant" or "field:project_uuid".
+func ParseTenantSource(s string) (*TenantSource, error) {
+	kind, name, ok := strings.Cut(s, ":")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("tenant source %q is not of the form <kind>:<name>", s)
+	}
+	switch kind {
+	case TenantFromHeader, TenantFromPath, TenantFromField:
+	default:
+		return nil, fmt.Errorf("unknown kind of tenant source %q", kind)
+	}
+	return &TenantSource{Kind: kind, Name: name}, nil
+}
+
+// tenant returns the tenant of the request, or "" if it has none.
+func (s *TenantSource) tenant(request *webhookRequest) string {
+	switch s.Kind {
+	case TenantFromHeader:
+		return request.header(s.Name)
+	case TenantFromPath:
+		return request.pathParameter(s.Name)
+	case TenantFromField:
+		return request.bodyField(s.Name)
+	}
+	return ""
+}
+
+// ParseTenantSecrets parses the secret map of the tenants, a JSON object of the tenants to their secrets,
+// e.g. `{"org-a": "secret-a", "org-b": ["new-secret-b", "old-secret-b"]}`. Each secret is parsed with webhook.ParseSecretKeys.
+func ParseTenantSecrets(s string) (map[string]webhook.KeySource, error) {
+	var secrets map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(s), &secrets); err != nil {
+		return nil, fmt.Errorf("unmarshal tenant secrets: %w", err)
+	}
+	keys := make(map[string]webhook.KeySource, len(secrets))
+	for tenant, raw := range secrets {
+		var secret string
+		if err := json.Unmarshal(raw, &secret); err != nil {
+			// A list of secrets for rotation.
+			secret = string(raw)
+		}
+		keys[tenant] = webhook.StaticKeys(webhook.ParseSecretKeys([]byte(secret)))
+	}
+	return keys, nil
+}
+
+// tenantKeySource returns the source of the keys of the tenant of the request, or nil if the request has no tenant.
+// An unknown tenant is an error.
+func (h *Handler) tenantKeySource(request *webhookRequest) (webhook.KeySource, error) {
+	if len(h.TenantKeys) == 0 {
+		return nil, nil
+	}
+	tenantSource := h.TenantSource
+	if tenantSource == nil {
+		tenantSource = &TenantSource{Kind: TenantFromHeader, Name: DefaultTenantHeader}
+	}
+	tenant := tenantSource.tenant(request)
+	if tenant == "" {
+		return nil, nil
+	}
+	source, ok := h.TenantKeys[tenant]
+	if !ok {
+		return nil, fmt.Errorf("unknown tenant %q", tenant)
+	}
+	request.tenant = tenant
+	return source, nil
+}
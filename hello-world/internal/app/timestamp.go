@@ -0,0 +1,70 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"hello-world/pkg/webhook"
+)
+
+const (
+	// DefaultTimestampHeader is the default name of the header that contains the delivery timestamp.
+	DefaultTimestampHeader = "x-intdash-timestamp"
+	// DefaultClockSkew is the default allowance for a delivery timestamp ahead of the local clock.
+	DefaultClockSkew = 30 * time.Second
+)
+
+// validateTimestamp rejects the request if its delivery timestamp is older than TimestampTolerance,
+// or ahead of the local clock by more than ClockSkew.
+// It does nothing if TimestampTolerance is zero.
+func (h *Handler) validateTimestamp(request *webhookRequest) error {
+	if h.TimestampTolerance <= 0 {
+		return nil
+	}
+
+	header := h.timestampHeader()
+	v := request.header(header)
+	if v == "" {
+		return fmt.Errorf("timestamp header %q is empty", header)
+	}
+	ts, err := parseTimestamp(v)
+	if err != nil {
+		return fmt.Errorf("parse timestamp %q: %w", v, err)
+	}
+
+	now := h.now()
+	if age := now.Sub(ts); age > h.TimestampTolerance {
+		return fmt.Errorf("timestamp %s is too old (age %s, tolerance %s)", ts.Format(time.RFC3339), age, h.TimestampTolerance)
+	}
+	if ahead := ts.Sub(now); ahead > h.ClockSkew {
+		return fmt.Errorf("timestamp %s is in the future (ahead %s, allowed skew %s)", ts.Format(time.RFC3339), ahead, h.ClockSkew)
+	}
+	return nil
+}
+
+// signedPayload returns the payload of the signature of the request: the body, or "<timestamp>.<body>"
+// if the timestamp is validated. The timestamp is signed along with the body, so that a captured delivery
+// cannot be replayed with a fresh timestamp.
+func (h *Handler) signedPayload(request *webhookRequest) []byte {
+	if h.TimestampTolerance <= 0 {
+		return []byte(request.Body)
+	}
+	return webhook.TimestampedPayload(request.header(h.timestampHeader()), []byte(request.Body))
+}
+
+// timestampHeader returns the name of the delivery timestamp header.
+func (h *Handler) timestampHeader() string {
+	if h.TimestampHeader == "" {
+		return DefaultTimestampHeader
+	}
+	return h.TimestampHeader
+}
+
+// parseTimestamp parses a timestamp in Unix seconds or RFC 3339 format.
+func parseTimestamp(v string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
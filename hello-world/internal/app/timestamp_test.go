@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"hello-world/pkg/webhook"
+)
+
+func TestValidateSignatureWithTimestamp(t *testing.T) {
+	const body = `{"resource_type":"measurement","action":"finished","measurement_uuid":"00000000-0000-0000-0000-000000000000"}`
+	key := []byte("secret")
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	h := &Handler{
+		SHA256Keys:         [][]byte{key},
+		TimestampTolerance: 5 * time.Minute,
+		ClockSkew:          DefaultClockSkew,
+		Clock:              func() time.Time { return now },
+	}
+	captured := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	sign := func(payload []byte) string {
+		return base64.StdEncoding.EncodeToString(webhook.Sign(key, payload))
+	}
+
+	for _, tt := range []struct {
+		name      string
+		timestamp string
+		signature string
+		wantErr   error
+	}{
+		{
+			name:      "signed timestamp",
+			timestamp: fresh,
+			signature: sign(webhook.TimestampedPayload(fresh, []byte(body))),
+		},
+		{
+			name:      "replayed with fresh timestamp",
+			timestamp: fresh,
+			signature: sign(webhook.TimestampedPayload(captured, []byte(body))),
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "signed body only",
+			timestamp: fresh,
+			signature: sign([]byte(body)),
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "signed old timestamp",
+			timestamp: captured,
+			signature: sign(webhook.TimestampedPayload(captured, []byte(body))),
+			wantErr:   ErrInvalidTimestamp,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &webhookRequest{
+				Headers: map[string]string{
+					webhook.SignatureHeader: tt.signature,
+					DefaultTimestampHeader:  tt.timestamp,
+				},
+				Body: body,
+			}
+			err := h.validateSignature(context.Background(), request)
+			if err == nil {
+				if err = h.validateTimestamp(request); err != nil {
+					err = errors.Join(ErrInvalidTimestamp, err)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/timestreamwrite"
+	"github.com/aws/aws-sdk-go-v2/service/timestreamwrite/types"
+)
+
+const (
+	// TimestreamMaxRecordsPerWrite is the maximum number of records in a WriteRecords request.
+	TimestreamMaxRecordsPerWrite = 100
+
+	// DefaultTimestreamMeasureName is the default measure name of the written records.
+	DefaultTimestreamMeasureName = "value"
+)
+
+type TimestreamWriteRecordsAPI interface {
+	WriteRecords(ctx context.Context, params *timestreamwrite.WriteRecordsInput, optFns ...func(*timestreamwrite.Options)) (*timestreamwrite.WriteRecordsOutput, error)
+}
+
+// TimestreamWriter is a ResultArchive that writes the data points to an Amazon Timestream table.
+// Each data point is a record with the measurement UUID and the data ID as dimensions.
+type TimestreamWriter struct {
+	TimestreamWriteRecordsAPI TimestreamWriteRecordsAPI
+	DatabaseName              string
+	TableName                 string
+	// MeasureName is the measure name of the records. Defaults to DefaultTimestreamMeasureName.
+	MeasureName string
+}
+
+// Archive writes the data points of the result in batches of TimestreamMaxRecordsPerWrite.
+func (w *TimestreamWriter) Archive(ctx context.Context, result *AnalysisResult) error {
+	measureName := w.MeasureName
+	if measureName == "" {
+		measureName = DefaultTimestreamMeasureName
+	}
+	common := &types.Record{
+		Dimensions: []types.Dimension{
+			{Name: aws.String("measurement_uuid"), Value: aws.String(result.MeasurementUUID)},
+			{Name: aws.String("data_id"), Value: aws.String(result.DataID)},
+		},
+		MeasureName:      aws.String(measureName),
+		MeasureValueType: types.MeasureValueTypeDouble,
+		TimeUnit:         types.TimeUnitMicroseconds,
+	}
+
+	for start := 0; start < len(result.DataPoints); start += TimestreamMaxRecordsPerWrite {
+		end := start + TimestreamMaxRecordsPerWrite
+		if end > len(result.DataPoints) {
+			end = len(result.DataPoints)
+		}
+		records := make([]types.Record, 0, end-start)
+		for _, dp := range result.DataPoints[start:end] {
+			records = append(records, types.Record{
+				MeasureValue: aws.String(strconv.FormatFloat(dp.Value, 'g', -1, 64)),
+				Time:         aws.String(strconv.FormatInt(dp.Time.UnixNano()/int64(1000), 10)),
+			})
+		}
+
+		_, err := w.TimestreamWriteRecordsAPI.WriteRecords(ctx, &timestreamwrite.WriteRecordsInput{
+			DatabaseName:     aws.String(w.DatabaseName),
+			TableName:        aws.String(w.TableName),
+			CommonAttributes: common,
+			Records:          records,
+		})
+		if err != nil {
+			return fmt.Errorf("write records %d-%d to %s.%s: %w", start, end, w.DatabaseName, w.TableName, err)
+		}
+	}
+	slog.InfoContext(ctx, "Wrote records to Timestream", "records", len(result.DataPoints), "database", w.DatabaseName, "table", w.TableName)
+	return nil
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"context"
+)
+
+// Tracer traces the processing stages of the deliveries, so that the time spent in each stage can be seen.
+type Tracer interface {
+	// Start starts a span of the name as a child of the span in ctx, if any.
+	// end ends the span, marking it as failed if err is not nil.
+	Start(ctx context.Context, name string) (_ context.Context, end func(err error))
+}
+
+// startSpan starts a span of the name with h.Tracer, if set.
+func (h *Handler) startSpan(ctx context.Context, name string) (context.Context, func(err error)) {
+	if h.Tracer == nil {
+		return ctx, func(error) {}
+	}
+	return h.Tracer.Start(ctx, name)
+}
+
+// startStage starts the processing stage of the name, which is the "stage" of the logs and the name of the span.
+// The stage times out after its share of the remaining processing time.
+func (h *Handler) startStage(ctx context.Context, stage string) (context.Context, func(err error)) {
+	ctx, cancel := h.withStageTimeout(ctx, stage)
+	ctx, end := h.startSpan(withLogAttrs(ctx, "stage", stage), stage)
+	return ctx, func(err error) {
+		end(err)
+		cancel()
+	}
+}
+
+// tracerFlusher is a Tracer that buffers the telemetry. It must be flushed before a Lambda invocation returns,
+// as the execution environment may be frozen until the next invocation.
+type tracerFlusher interface {
+	Flush(ctx context.Context) error
+}
+
+// flushTracer flushes h.Tracer if it buffers the telemetry.
+func (h *Handler) flushTracer(ctx context.Context) {
+	f, ok := h.Tracer.(tracerFlusher)
+	if !ok {
+		return
+	}
+	if err := f.Flush(ctx); err != nil {
+		h.logger().ErrorContext(ctx, "Failed to flush telemetry", "error", err)
+	}
+}
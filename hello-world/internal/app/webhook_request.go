@@ -0,0 +1,100 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"hello-world/pkg/webhook"
+)
+
+// webhookRequest is a webhook delivery, independent of the event source that carried it.
+type webhookRequest struct {
+	// RequestID identifies the request in error responses and logs.
+	RequestID         string
+	Headers           map[string]string
+	MultiValueHeaders map[string][]string
+	Body              string
+	IsBase64Encoded   bool
+	// Path is the request path, and PathParameters are its parameters extracted by API Gateway, if any.
+	Path           string
+	PathParameters map[string]string
+	// SourceIP is the IP address of the caller, if known.
+	SourceIP string
+
+	// keyRoute is the route whose keys validated the signature, or nil if the keys of the handler did.
+	keyRoute *Route
+	// tenant is the tenant whose keys validated the signature, if any.
+	tenant string
+}
+
+// header returns the first value of the given header, matching the name case-insensitively.
+func (r *webhookRequest) header(name string) string {
+	return webhook.LookupHeader(r.Headers, r.MultiValueHeaders, name)
+}
+
+// pathParameter returns the given path parameter, or the last segment of the path
+// for the event sources without path parameters.
+func (r *webhookRequest) pathParameter(name string) string {
+	if r.PathParameters != nil {
+		return r.PathParameters[name]
+	}
+	path := strings.TrimRight(r.Path, "/")
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// bodyField returns the string field of the raw body before it is validated, e.g. to select the keys of the signature.
+// For a batch, it is the value common to all the events, or "" if they differ.
+func (r *webhookRequest) bodyField(name string) string {
+	var events []map[string]json.RawMessage
+	if webhook.IsBatch([]byte(r.Body)) {
+		if err := json.Unmarshal([]byte(r.Body), &events); err != nil {
+			return ""
+		}
+	} else {
+		var event map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(r.Body), &event); err != nil {
+			return ""
+		}
+		events = append(events, event)
+	}
+	var value string
+	for i, event := range events {
+		var v string
+		if err := json.Unmarshal(event[name], &v); err != nil || (i > 0 && v != value) {
+			return ""
+		}
+		value = v
+	}
+	return value
+}
+
+// bodySize returns the size of the raw body, without decoding it if it is base64-encoded.
+func (r *webhookRequest) bodySize() int64 {
+	if r.IsBase64Encoded {
+		return int64(base64.StdEncoding.DecodedLen(len(r.Body)))
+	}
+	return int64(len(r.Body))
+}
+
+// decodeBody replaces the body with the raw body, if the event source marked it as base64-encoded.
+func (r *webhookRequest) decodeBody() error {
+	if !r.IsBase64Encoded {
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(r.Body)
+	if err != nil {
+		return fmt.Errorf("decode base64 body: %w", err)
+	}
+	r.Body = string(b)
+	r.IsBase64Encoded = false
+	return nil
+}
+
+// webhookResponse is the response to a webhook delivery, independent of the event source.
+type webhookResponse struct {
+	StatusCode int
+	Headers    map[string]string
+	Body       string
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"hello-world/pkg/intdash"
+	"hello-world/pkg/notify"
+)
+
+// DefaultWorkerPoolSize is the default maximum number of the concurrent calls to the intdash API and the notifiers.
+const DefaultWorkerPoolSize = 8
+
+// WorkerPool bounds the number of the concurrent calls to the downstream services, shared by all the deliveries
+// processed at once, e.g. by the standalone server or in the warm execution environment, so that a burst of
+// measurement completions does not exhaust the memory or the rate limits of intdash.
+type WorkerPool struct {
+	slots chan struct{}
+}
+
+// NewWorkerPool returns a WorkerPool of at most size concurrent calls.
+func NewWorkerPool(size int) *WorkerPool {
+	return &WorkerPool{slots: make(chan struct{}, size)}
+}
+
+// Do calls fn once a slot is free, or returns the error of ctx if it is done first.
+func (p *WorkerPool) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	select {
+	case p.slots <- struct{}{}:
+		defer func() { <-p.slots }()
+	case <-ctx.Done():
+		return fmt.Errorf("wait for worker: %w", ctx.Err())
+	}
+	return fn(ctx)
+}
+
+// PooledIntdashAPI is an IntdashAPI whose calls are bounded by a WorkerPool.
+// The pages of the data points of a channel are fetched one after another in a slot.
+type PooledIntdashAPI struct {
+	IntdashAPI
+	Pool *WorkerPool
+}
+
+// FetchFloat64DataPoints fetches the data points in a slot of the pool.
+func (a *PooledIntdashAPI) FetchFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr intdash.TimeRange) (dataPoints []intdash.DataPoint, err error) {
+	err = a.Pool.Do(ctx, func(ctx context.Context) (err error) {
+		dataPoints, err = a.IntdashAPI.FetchFloat64DataPoints(ctx, measurementUUID, dataID, tr)
+		return err
+	})
+	return dataPoints, err
+}
+
+// StreamFloat64DataPoints streams the data points in a slot of the pool.
+func (a *PooledIntdashAPI) StreamFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr intdash.TimeRange, fn func(intdash.DataPoint) error) error {
+	return a.Pool.Do(ctx, func(ctx context.Context) error {
+		return streamFloat64DataPoints(ctx, a.IntdashAPI, measurementUUID, dataID, tr, fn)
+	})
+}
+
+// FetchMeasurement fetches the measurement in a slot of the pool.
+func (a *PooledIntdashAPI) FetchMeasurement(ctx context.Context, measurementUUID string) (measurement *intdash.Measurement, err error) {
+	err = a.Pool.Do(ctx, func(ctx context.Context) (err error) {
+		measurement, err = a.IntdashAPI.FetchMeasurement(ctx, measurementUUID)
+		return err
+	})
+	return measurement, err
+}
+
+// Check checks the intdash API outside the pool, if it can be checked.
+func (a *PooledIntdashAPI) Check(ctx context.Context) error {
+	checker, ok := a.IntdashAPI.(interface {
+		Check(ctx context.Context) error
+	})
+	if !ok {
+		return errCheckSkipped
+	}
+	return checker.Check(ctx)
+}
+
+// PooledNotifier is a notifier whose calls are bounded by a WorkerPool.
+type PooledNotifier struct {
+	notify.Notifier
+	Pool *WorkerPool
+}
+
+// Notify sends the notification in a slot of the pool.
+func (n *PooledNotifier) Notify(ctx context.Context, notification *notify.Notification) error {
+	return n.Pool.Do(ctx, func(ctx context.Context) error {
+		return n.Notifier.Notify(ctx, notification)
+	})
+}
+
+// Check checks the destination outside the pool, if it can be checked.
+func (n *PooledNotifier) Check(ctx context.Context) error {
+	checker, ok := n.Notifier.(notify.Checker)
+	if !ok {
+		return errCheckSkipped
+	}
+	return checker.Check(ctx)
+}
+
+// useWorkerPool bounds the calls to the intdash API and each notifier of the handler and of its routes by the pool.
+func (h *Handler) useWorkerPool(pool *WorkerPool) {
+	if h.IntdashAPI != nil {
+		h.IntdashAPI = &PooledIntdashAPI{IntdashAPI: h.IntdashAPI, Pool: pool}
+	}
+	wrap := func(notifiers []notify.Notifier) {
+		for i, n := range notifiers {
+			notifiers[i] = &PooledNotifier{Notifier: n, Pool: pool}
+		}
+	}
+	wrap(h.Notifiers)
+	for _, r := range h.Routes {
+		wrap(r.Notifiers)
+	}
+}
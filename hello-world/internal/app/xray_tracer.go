@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// XRayTracer is a Tracer that records the spans as AWS X-Ray subsegments.
+// In Lambda, the subsegments are children of the segment of the invocation, when active tracing is enabled.
+// The subsegments are annotated with the measurement UUID, the delivery ID and the data ID, if known,
+// so that the traces can be searched by them.
+type XRayTracer struct{}
+
+// Start begins a subsegment of the name.
+func (t *XRayTracer) Start(ctx context.Context, name string) (context.Context, func(err error)) {
+	ctx, seg := xray.BeginSubsegment(ctx, name)
+	if seg == nil {
+		// No segment in ctx, e.g. tracing is disabled.
+		return ctx, func(error) {}
+	}
+	if id := deliveryIDFromContext(ctx); id != "" {
+		seg.AddAnnotation("delivery_id", id)
+	}
+	for _, a := range logAttrsFromContext(ctx) {
+		switch a.Key {
+		case "measurement_uuid", "data_id":
+			if a.Value.Kind() == slog.KindString {
+				seg.AddAnnotation(a.Key, a.Value.String())
+			}
+		}
+	}
+	return ctx, func(err error) { seg.Close(err) }
+}
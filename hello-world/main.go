@@ -1,51 +1,60 @@
 package main
 
 import (
-	"context"
-	_ "embed"
-	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/sns"
-)
-
-var (
-	//go:embed intdash-webhook-secret
-	intdashWebhookSecret string
 
-	handler *Handler
+	"hello-world/internal/app"
 )
 
-func init() {
-	var err error
-	handler, err = provideLambdaHandler()
-	if err != nil {
-		log.Fatalf("[Error] Failed to provide lambda handler: %v", err)
-	}
-}
-
 func main() {
-	lambda.Start(handler.HandleAPIGatewayProxy)
-}
-
-func provideLambdaHandler() (*Handler, error) {
-	snsTopicArn := os.Getenv("SNS_TOPIC_ARN")
-	if snsTopicArn == "" {
-		return nil, fmt.Errorf("SNS_TOPIC_ARN is not set")
+	redactor := app.NewLogRedactor(strings.Split(os.Getenv("LOG_REDACT_FIELDS"), ",")...)
+	logger, err := app.NewLogger(os.Stdout, os.Getenv("LOG_LEVEL"), redactor)
+	if err != nil {
+		log.Fatalf("Failed to provide logger: %v", err)
 	}
+	slog.SetDefault(logger)
 
-	awsCfg, err := config.LoadDefaultConfig(context.TODO())
+	handler, err := app.ProvideHandler(app.Environment{})
 	if err != nil {
-		return nil, fmt.Errorf("load AWS config: %w", err)
+		slog.Error("Failed to provide lambda handler", "error", err)
+		os.Exit(1)
+	}
+	handler.LogRedactor = redactor
+
+	if len(os.Args) > 1 && os.Args[1] == "--selfcheck" {
+		if err := app.RunSelfCheck(handler, os.Stdout); err != nil {
+			slog.Error("Failed to pass self-check", "error", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	return &Handler{
-		IntdashAPI:    &IntdashAPIStub{},
-		SHA256Key:     []byte(intdashWebhookSecret),
-		SNSTopicArn:   snsTopicArn,
-		SNSPublishAPI: sns.NewFromConfig(awsCfg),
-	}, nil
+	switch os.Getenv("HANDLER_MODE") {
+	case "worker":
+		lambda.Start(handler.HandleSQS)
+	case "stepfunctions":
+		lambda.Start(handler.HandleStepFunctions)
+	case "eventbridge":
+		lambda.Start(handler.HandleEventBridge)
+	case "apigatewayv2":
+		lambda.Start(handler.HandleAPIGatewayV2HTTP)
+	case "functionurl":
+		lambda.Start(handler.HandleLambdaFunctionURL)
+	case "alb":
+		lambda.Start(handler.HandleALBTargetGroup)
+	case "selfcheck":
+		lambda.Start(handler.HandleSelfCheck)
+	case "replay":
+		if err := app.RunReplay(handler, os.Args[1:]); err != nil {
+			slog.Error("Failed to replay events", "error", err)
+			os.Exit(1)
+		}
+	default:
+		lambda.Start(handler.HandleAPIGatewayProxy)
+	}
 }
@@ -0,0 +1,74 @@
+package analyze
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// alertRulePattern matches an alert rule such as "average > 120".
+var alertRulePattern = regexp.MustCompile(`^([a-z_0-9.]+)\s*(>=|<=|>|<)\s*(\S+)$`)
+
+// AlertRule is a threshold on a statistic. It fires when the statistic compared with the threshold holds.
+type AlertRule struct {
+	// Statistic is the statistic name, e.g. "average" or "p95".
+	Statistic string
+	// Operator is one of ">", ">=", "<" and "<=".
+	Operator  string
+	Threshold float64
+}
+
+// String returns the rule in the form of "average > 120", which names the rule in notifications.
+func (r AlertRule) String() string {
+	return fmt.Sprintf("%s %s %s", r.Statistic, r.Operator, strconv.FormatFloat(r.Threshold, 'g', -1, 64))
+}
+
+// Fires reports whether the rule fires for the given statistics.
+func (r AlertRule) Fires(stats *Statistics) bool {
+	v := stats.Value(r.Statistic)
+	switch r.Operator {
+	case ">":
+		return v > r.Threshold
+	case ">=":
+		return v >= r.Threshold
+	case "<":
+		return v < r.Threshold
+	case "<=":
+		return v <= r.Threshold
+	}
+	return false
+}
+
+// ParseAlertRules parses comma-separated alert rules, e.g. "average > 120, max >= 180".
+func ParseAlertRules(s string) ([]AlertRule, error) {
+	var rules []AlertRule
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		m := alertRulePattern.FindStringSubmatch(v)
+		if m == nil {
+			return nil, fmt.Errorf("invalid alert rule %q", v)
+		}
+		if _, err := parseStatistics(m[1]); err != nil {
+			return nil, fmt.Errorf("invalid alert rule %q: %w", v, err)
+		}
+		threshold, err := strconv.ParseFloat(m[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alert rule %q: parse threshold: %w", v, err)
+		}
+		rules = append(rules, AlertRule{Statistic: m[1], Operator: m[2], Threshold: threshold})
+	}
+	return rules, nil
+}
+
+// AlertStatistics returns the statistics of the given rules.
+func AlertStatistics(rules []AlertRule) []string {
+	var names []string
+	for _, rule := range rules {
+		names = append(names, rule.Statistic)
+	}
+	return names
+}
@@ -0,0 +1,78 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"hello-world/pkg/intdash"
+)
+
+// The benchmarks guide the memory sizing of the function: the allocations of an analyzer per size of the series
+// add to the memory of the data points themselves, 32 bytes each. Run them with
+//
+//	go test -run '^$' -bench . ./pkg/analyze
+//
+// The series of 1e6 points and more are skipped with -short.
+
+// benchmarkSizes are the numbers of the data points of the benchmarked series.
+var benchmarkSizes = []int{1e3, 1e4, 1e5, 1e6, 1e7}
+
+// benchmarkSamplingRate is the sampling rate of the benchmarked series in Hz.
+const benchmarkSamplingRate = 100
+
+// benchmarkDataPoints returns n data points sampled at benchmarkSamplingRate:
+// sine waves of 5 Hz and 12 Hz around 100 with Gaussian noise.
+func benchmarkDataPoints(n int) []intdash.DataPoint {
+	rnd := rand.New(rand.NewSource(1))
+	basetime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dps := make([]intdash.DataPoint, n)
+	for i := range dps {
+		t := float64(i) / benchmarkSamplingRate
+		dps[i] = intdash.DataPoint{
+			Time:  basetime.Add(time.Duration(t * float64(time.Second))),
+			Value: 100 + 20*math.Sin(2*math.Pi*5*t) + 5*math.Sin(2*math.Pi*12*t) + rnd.NormFloat64(),
+		}
+	}
+	return dps
+}
+
+// benchmarkAnalyzer benchmarks the analyzer on the series of each of benchmarkSizes, reporting the allocations.
+func benchmarkAnalyzer(b *testing.B, a Analyzer) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			if testing.Short() && n >= 1e6 {
+				b.Skip("skipped with -short")
+			}
+			dps := benchmarkDataPoints(n)
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := a.Analyze(ctx, dps); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(n)*float64(b.N)/b.Elapsed().Seconds(), "points/s")
+		})
+	}
+}
+
+func BenchmarkStatistics(b *testing.B) {
+	benchmarkAnalyzer(b, &StatisticsAnalyzer{Names: []string{"average", "unbiased_variance", "min", "max"}})
+}
+
+func BenchmarkPercentiles(b *testing.B) {
+	benchmarkAnalyzer(b, &StatisticsAnalyzer{Names: []string{"median", "p95", "p99"}})
+}
+
+func BenchmarkHistogram(b *testing.B) {
+	benchmarkAnalyzer(b, &HistogramAnalyzer{Buckets: DefaultHistogramBuckets})
+}
+
+func BenchmarkFFT(b *testing.B) {
+	benchmarkAnalyzer(b, &FFTAnalyzer{SamplingRate: benchmarkSamplingRate})
+}
@@ -0,0 +1,115 @@
+// Package analyze analyzes the data points of measurements and writes the results as notification text.
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"hello-world/pkg/intdash"
+)
+
+type (
+	// Analyzer analyzes the data points of a measurement.
+	Analyzer interface {
+		// Name identifies the analyzer in configurations and archived results, e.g. "histogram".
+		Name() string
+		Analyze(ctx context.Context, points []intdash.DataPoint) (AnalyzerResult, error)
+	}
+
+	// AnalyzerResult is the result of an Analyzer.
+	// It is archived as JSON and written to the notification body as text.
+	AnalyzerResult interface {
+		// WriteText writes the result to the notification body as "Label: value" lines.
+		WriteText(w *TextWriter)
+	}
+
+	// StreamingAnalyzer is an Analyzer that can also analyze the data points one by one as they are fetched,
+	// so that a large measurement is analyzed without holding all of its data points.
+	StreamingAnalyzer interface {
+		Analyzer
+		// NewAccumulator returns an Accumulator of a new analysis, or false if the analysis needs
+		// all the data points with the configuration of the analyzer, e.g. for the percentiles.
+		NewAccumulator() (Accumulator, bool)
+	}
+
+	// Accumulator analyzes the data points added one by one.
+	Accumulator interface {
+		Add(dp intdash.DataPoint)
+		// Result returns the result of the data points added so far.
+		Result() AnalyzerResult
+	}
+
+	// AnalyzerFactory creates an Analyzer from the configuration.
+	AnalyzerFactory func(cfg Config) (Analyzer, error)
+
+	// Config looks up the configuration values of the analyzers by name, e.g. "HISTOGRAM_BUCKETS".
+	Config interface {
+		Get(name string) string
+	}
+)
+
+// Analysis is the result of an analyzer with the name of the analyzer.
+type Analysis struct {
+	Analyzer string         `json:"analyzer"`
+	Result   AnalyzerResult `json:"result"`
+}
+
+// AnalyzerRegistry maps analyzer names to their factories,
+// so that the analyzers of a deployment can be selected by configuration.
+type AnalyzerRegistry struct {
+	mu        sync.RWMutex
+	factories map[string]AnalyzerFactory
+}
+
+// NewAnalyzerRegistry returns an AnalyzerRegistry with the built-in analyzers registered.
+func NewAnalyzerRegistry() *AnalyzerRegistry {
+	r := &AnalyzerRegistry{factories: map[string]AnalyzerFactory{}}
+	r.Register("statistics", newStatisticsAnalyzer)
+	r.Register("outliers", newOutlierAnalyzer)
+	r.Register("histogram", newHistogramAnalyzer)
+	r.Register("fft", newFFTAnalyzer)
+	r.Register("custom", newCELAnalyzer)
+	r.Register("crossings", newCrossingAnalyzer)
+	r.Register("gaps", newGapAnalyzer)
+	return r
+}
+
+// Register registers the factory of the analyzer of the given name.
+// A factory already registered for the name is replaced.
+func (r *AnalyzerRegistry) Register(name string, factory AnalyzerFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[name] = factory
+}
+
+// Create creates the analyzers of the given names in order.
+func (r *AnalyzerRegistry) Create(cfg Config, names []string) ([]Analyzer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var analyzers []Analyzer
+	for _, name := range names {
+		factory, ok := r.factories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown analyzer %q, available: %s", name, strings.Join(r.names(), ", "))
+		}
+		a, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("create analyzer %s: %w", name, err)
+		}
+		analyzers = append(analyzers, a)
+	}
+	return analyzers, nil
+}
+
+// names returns the sorted names of the registered analyzers. The caller must hold r.mu.
+func (r *AnalyzerRegistry) names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
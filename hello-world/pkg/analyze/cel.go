@@ -0,0 +1,166 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+
+	"hello-world/pkg/intdash"
+)
+
+// CELAnalyzer is an Analyzer that evaluates custom metrics defined as CEL expressions.
+// The expressions can refer to the values as x (list of double) and to the statistics as stats
+// (map of double keyed by "count", "average", "unbiased_variance", "stddev", "min" and "max").
+// The function sum(list of double) is also available, e.g.
+//
+//	ratio_over_120 = double(x.filter(v, v > 120).size()) / double(x.size())
+//	range = stats.max - stats.min
+type CELAnalyzer struct {
+	metrics []*celMetric
+}
+
+type celMetric struct {
+	name       string
+	expression string
+	program    cel.Program
+}
+
+func newCELAnalyzer(cfg Config) (Analyzer, error) {
+	return NewCELAnalyzer(cfg.Get("CUSTOM_METRICS"))
+}
+
+// NewCELAnalyzer returns a CELAnalyzer of the metrics defined in defs,
+// which are "name = expression" separated by semicolons or new lines.
+func NewCELAnalyzer(defs string) (*CELAnalyzer, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("x", cel.ListType(cel.DoubleType)),
+		cel.Variable("stats", cel.MapType(cel.StringType, cel.DoubleType)),
+		cel.Function("sum",
+			cel.Overload("sum_list_double", []*cel.Type{cel.ListType(cel.DoubleType)}, cel.DoubleType,
+				cel.UnaryBinding(celSum),
+			),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create CEL environment: %w", err)
+	}
+
+	a := &CELAnalyzer{}
+	for _, def := range strings.FieldsFunc(defs, func(r rune) bool { return r == ';' || r == '\n' }) {
+		if strings.TrimSpace(def) == "" {
+			continue
+		}
+		kv := strings.SplitN(def, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid custom metric %q, want \"name = expression\"", def)
+		}
+		m := &celMetric{
+			name:       strings.TrimSpace(kv[0]),
+			expression: strings.TrimSpace(kv[1]),
+		}
+		ast, iss := env.Compile(m.expression)
+		if iss != nil && iss.Err() != nil {
+			return nil, fmt.Errorf("compile custom metric %s: %w", m.name, iss.Err())
+		}
+		m.program, err = env.Program(ast, cel.InterruptCheckFrequency(1000))
+		if err != nil {
+			return nil, fmt.Errorf("create program of custom metric %s: %w", m.name, err)
+		}
+		a.metrics = append(a.metrics, m)
+	}
+	if len(a.metrics) == 0 {
+		return nil, fmt.Errorf("CUSTOM_METRICS is not set")
+	}
+	return a, nil
+}
+
+// celSum returns the sum of a list of double.
+func celSum(arg ref.Val) ref.Val {
+	list, ok := arg.(traits.Lister)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(arg)
+	}
+	var sum types.Double
+	for it := list.Iterator(); it.HasNext() == types.True; {
+		v, ok := it.Next().(types.Double)
+		if !ok {
+			return types.NewErr("sum: list element is not a double")
+		}
+		sum += v
+	}
+	return sum
+}
+
+// Name returns "custom".
+func (a *CELAnalyzer) Name() string {
+	return "custom"
+}
+
+// Analyze evaluates the custom metrics.
+func (a *CELAnalyzer) Analyze(ctx context.Context, points []intdash.DataPoint) (AnalyzerResult, error) {
+	values := make([]float64, len(points))
+	for i, dp := range points {
+		values[i] = dp.Value
+	}
+	stats := CalculateStatistics(points, nil)
+	vars := map[string]interface{}{
+		"x": values,
+		"stats": map[string]float64{
+			"count":             float64(stats.Count),
+			"average":           stats.Average,
+			"unbiased_variance": stats.UnbiasedVariance,
+			"stddev":            stats.StdDev,
+			"min":               stats.Min,
+			"max":               stats.Max,
+		},
+	}
+
+	res := &CustomMetrics{Metrics: make([]CustomMetric, 0, len(a.metrics))}
+	for _, m := range a.metrics {
+		out, _, err := m.program.ContextEval(ctx, vars)
+		if err != nil {
+			return nil, fmt.Errorf("evaluate custom metric %s: %w", m.name, err)
+		}
+		var v float64
+		switch native := out.Value().(type) {
+		case float64:
+			v = native
+		case int64:
+			v = float64(native)
+		case uint64:
+			v = float64(native)
+		case bool:
+			if native {
+				v = 1
+			}
+		default:
+			return nil, fmt.Errorf("evaluate custom metric %s: result is not a number but %T", m.name, native)
+		}
+		res.Metrics = append(res.Metrics, CustomMetric{Name: m.name, Expression: m.expression, Value: v})
+	}
+	return res, nil
+}
+
+// CustomMetrics is the result of a CELAnalyzer.
+type CustomMetrics struct {
+	Metrics []CustomMetric `json:"metrics"`
+}
+
+// CustomMetric is the value of a custom metric.
+type CustomMetric struct {
+	Name       string  `json:"name"`
+	Expression string  `json:"expression"`
+	Value      float64 `json:"value"`
+}
+
+// WriteText writes a line for each of the custom metrics.
+func (m *CustomMetrics) WriteText(w *TextWriter) {
+	for _, metric := range m.Metrics {
+		w.Printf("%s: %f\n", metric.Name, metric.Value)
+	}
+}
@@ -0,0 +1,115 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hello-world/pkg/intdash"
+)
+
+const (
+	// DefaultMaxCrossings is the default maximum number of crossings listed per threshold.
+	DefaultMaxCrossings = 10
+)
+
+// CrossingAnalyzer is an Analyzer that reports when the values cross the thresholds.
+type CrossingAnalyzer struct {
+	Thresholds []float64
+	// MaxCrossings is the maximum number of crossings listed per threshold. Defaults to DefaultMaxCrossings.
+	MaxCrossings int
+}
+
+func newCrossingAnalyzer(cfg Config) (Analyzer, error) {
+	v := cfg.Get("CROSSING_THRESHOLDS")
+	if v == "" {
+		return nil, fmt.Errorf("CROSSING_THRESHOLDS is not set")
+	}
+	a := &CrossingAnalyzer{}
+	for _, s := range strings.Split(v, ",") {
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse CROSSING_THRESHOLDS: %w", err)
+		}
+		a.Thresholds = append(a.Thresholds, threshold)
+	}
+	if v := cfg.Get("CROSSING_MAX_LISTED"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse CROSSING_MAX_LISTED: %w", err)
+		}
+		a.MaxCrossings = n
+	}
+	return a, nil
+}
+
+// Name returns "crossings".
+func (a *CrossingAnalyzer) Name() string {
+	return "crossings"
+}
+
+// Analyze finds the crossings of each threshold. A crossing is at the first data point
+// on the other side of the threshold; touching the threshold is not a crossing.
+func (a *CrossingAnalyzer) Analyze(ctx context.Context, points []intdash.DataPoint) (AnalyzerResult, error) {
+	maxCrossings := a.MaxCrossings
+	if maxCrossings == 0 {
+		maxCrossings = DefaultMaxCrossings
+	}
+	report := &CrossingReport{}
+	for _, threshold := range a.Thresholds {
+		tc := ThresholdCrossings{Threshold: threshold, Crossings: []Crossing{}}
+		above, known := false, false
+		for _, dp := range points {
+			if dp.Value == threshold {
+				continue
+			}
+			if known && above != (dp.Value > threshold) {
+				tc.Count++
+				if len(tc.Crossings) < maxCrossings {
+					direction := "up"
+					if above {
+						direction = "down"
+					}
+					tc.Crossings = append(tc.Crossings, Crossing{Time: dp.Time, Value: dp.Value, Direction: direction})
+				}
+			}
+			above, known = dp.Value > threshold, true
+		}
+		report.Thresholds = append(report.Thresholds, tc)
+	}
+	return report, nil
+}
+
+// CrossingReport is the result of a CrossingAnalyzer.
+type CrossingReport struct {
+	Thresholds []ThresholdCrossings `json:"thresholds"`
+}
+
+// ThresholdCrossings are the crossings of a threshold.
+type ThresholdCrossings struct {
+	Threshold float64 `json:"threshold"`
+	// Count is the total number of the crossings.
+	Count int `json:"count"`
+	// Crossings are the first crossings, at most the configured maximum.
+	Crossings []Crossing `json:"crossings"`
+}
+
+// Crossing is a data point where the values cross a threshold.
+type Crossing struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+	// Direction is "up" or "down".
+	Direction string `json:"direction"`
+}
+
+// WriteText writes the number of the crossings of each threshold and a line for each of the listed crossings.
+func (r *CrossingReport) WriteText(w *TextWriter) {
+	for _, tc := range r.Thresholds {
+		w.Printf("Crossings of %g: %d\n", tc.Threshold, tc.Count)
+		for _, c := range tc.Crossings {
+			w.Printf("Crossing %s of %g: %f at %s\n", w.T(c.Direction), tc.Threshold, c.Value, c.Time.Format(time.RFC3339Nano))
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"strconv"
+
+	"hello-world/pkg/intdash"
+)
+
+const (
+	// DefaultFFTTopN is the default number of dominant frequencies reported.
+	DefaultFFTTopN = 5
+)
+
+// FFTAnalyzer is an Analyzer that reports the dominant frequencies of the values.
+type FFTAnalyzer struct {
+	// SamplingRate is the sampling rate of the data points in Hz.
+	SamplingRate float64
+	// TopN is the number of dominant frequencies reported. Defaults to DefaultFFTTopN.
+	TopN int
+}
+
+// SpectrumReport is the report of the dominant frequencies of the data points.
+type SpectrumReport struct {
+	SamplingRate float64 `json:"sampling_rate"`
+	// Peaks are the dominant frequencies in descending order of magnitude.
+	Peaks []FrequencyPeak `json:"peaks"`
+}
+
+// FrequencyPeak is a peak of the amplitude spectrum.
+type FrequencyPeak struct {
+	// Frequency is in Hz.
+	Frequency float64 `json:"frequency"`
+	// Magnitude is the amplitude of the frequency component in the unit of the data points.
+	Magnitude float64 `json:"magnitude"`
+}
+
+func newFFTAnalyzer(cfg Config) (Analyzer, error) {
+	v := cfg.Get("FFT_SAMPLING_RATE")
+	rate, err := strconv.ParseFloat(v, 64)
+	if err != nil || rate <= 0 {
+		return nil, fmt.Errorf("FFT_SAMPLING_RATE must be a positive number: %q", v)
+	}
+	a := &FFTAnalyzer{SamplingRate: rate}
+	if v := cfg.Get("FFT_TOP_N"); v != "" {
+		if a.TopN, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("parse FFT_TOP_N: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// Name returns "fft".
+func (a *FFTAnalyzer) Name() string {
+	return "fft"
+}
+
+// Analyze runs an FFT on the values and returns the top-N peaks of the amplitude spectrum.
+// The values are assumed to be sampled at a.SamplingRate. The mean is removed before the FFT,
+// and the values are zero-padded to a power of two.
+func (a *FFTAnalyzer) Analyze(ctx context.Context, dataPoints []intdash.DataPoint) (AnalyzerResult, error) {
+	topN := a.TopN
+	if topN == 0 {
+		topN = DefaultFFTTopN
+	}
+	report := &SpectrumReport{SamplingRate: a.SamplingRate, Peaks: []FrequencyPeak{}}
+	if len(dataPoints) < 2 {
+		return report, nil
+	}
+	stats := CalculateStatistics(dataPoints, nil)
+
+	n := 1
+	for n < len(dataPoints) {
+		n <<= 1
+	}
+	x := make([]complex128, n)
+	for i, dp := range dataPoints {
+		x[i] = complex(dp.Value-stats.Average, 0)
+	}
+	fft(x)
+
+	// amplitude spectrum of the positive frequencies, excluding DC
+	magnitudes := make([]float64, n/2+1)
+	for k := 1; k <= n/2; k++ {
+		magnitudes[k] = 2 * cmplx.Abs(x[k]) / float64(len(dataPoints))
+	}
+	var peaks []FrequencyPeak
+	for k := 1; k <= n/2; k++ {
+		if magnitudes[k] <= magnitudes[k-1] || (k < n/2 && magnitudes[k] < magnitudes[k+1]) {
+			continue
+		}
+		peaks = append(peaks, FrequencyPeak{
+			Frequency: float64(k) * a.SamplingRate / float64(n),
+			Magnitude: magnitudes[k],
+		})
+	}
+	sort.Slice(peaks, func(i, j int) bool { return peaks[i].Magnitude > peaks[j].Magnitude })
+	if len(peaks) > topN {
+		peaks = peaks[:topN]
+	}
+	report.Peaks = append(report.Peaks, peaks...)
+	return report, nil
+}
+
+// fft computes the discrete Fourier transform of x in place with the iterative radix-2 Cooley-Tukey algorithm.
+// The length of x must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	// bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u := x[start+k]
+				v := x[start+k+size/2] * wk
+				x[start+k] = u + v
+				x[start+k+size/2] = u - v
+				wk *= w
+			}
+		}
+	}
+}
+
+// WriteText writes a line for each of the dominant frequencies.
+func (r *SpectrumReport) WriteText(w *TextWriter) {
+	for i, p := range r.Peaks {
+		w.Printf("Dominant Frequency #%d: %.4g Hz (magnitude %f)\n", i+1, p.Frequency, p.Magnitude)
+	}
+}
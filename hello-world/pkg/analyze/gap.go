@@ -0,0 +1,111 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"hello-world/pkg/intdash"
+)
+
+const (
+	// DefaultGapToleranceFactor is the default factor of the expected interval above which an interval is a gap.
+	DefaultGapToleranceFactor = 1.5
+	// DefaultMaxGaps is the default maximum number of gaps listed.
+	DefaultMaxGaps = 10
+)
+
+// GapAnalyzer is an Analyzer that detects gaps in the data points,
+// that is, intervals between consecutive data points longer than expected.
+type GapAnalyzer struct {
+	// ExpectedInterval is the sampling interval of the data points.
+	ExpectedInterval time.Duration
+	// ToleranceFactor is the factor of ExpectedInterval above which an interval is a gap.
+	// Defaults to DefaultGapToleranceFactor.
+	ToleranceFactor float64
+	// MaxGaps is the maximum number of gaps listed. Defaults to DefaultMaxGaps.
+	MaxGaps int
+}
+
+func newGapAnalyzer(cfg Config) (Analyzer, error) {
+	v := cfg.Get("GAP_EXPECTED_INTERVAL")
+	if v == "" {
+		return nil, fmt.Errorf("GAP_EXPECTED_INTERVAL is not set")
+	}
+	interval, err := time.ParseDuration(v)
+	if err != nil || interval <= 0 {
+		return nil, fmt.Errorf("GAP_EXPECTED_INTERVAL must be a positive duration: %q", v)
+	}
+	a := &GapAnalyzer{ExpectedInterval: interval}
+	if v := cfg.Get("GAP_TOLERANCE_FACTOR"); v != "" {
+		if a.ToleranceFactor, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("parse GAP_TOLERANCE_FACTOR: %w", err)
+		}
+	}
+	if v := cfg.Get("GAP_MAX_LISTED"); v != "" {
+		if a.MaxGaps, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("parse GAP_MAX_LISTED: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// Name returns "gaps".
+func (a *GapAnalyzer) Name() string {
+	return "gaps"
+}
+
+// Analyze detects the gaps. The data points are assumed to be in time order.
+func (a *GapAnalyzer) Analyze(ctx context.Context, points []intdash.DataPoint) (AnalyzerResult, error) {
+	factor := a.ToleranceFactor
+	if factor == 0 {
+		factor = DefaultGapToleranceFactor
+	}
+	maxGaps := a.MaxGaps
+	if maxGaps == 0 {
+		maxGaps = DefaultMaxGaps
+	}
+	limit := time.Duration(float64(a.ExpectedInterval) * factor)
+
+	report := &GapReport{ExpectedInterval: a.ExpectedInterval.String(), Gaps: []Gap{}}
+	for i := 1; i < len(points); i++ {
+		d := points[i].Time.Sub(points[i-1].Time)
+		if d <= limit {
+			continue
+		}
+		report.Count++
+		report.TotalDuration += d
+		if len(report.Gaps) < maxGaps {
+			report.Gaps = append(report.Gaps, Gap{Start: points[i-1].Time, End: points[i].Time, Duration: d.String()})
+		}
+	}
+	return report, nil
+}
+
+// GapReport is the result of a GapAnalyzer.
+type GapReport struct {
+	ExpectedInterval string `json:"expected_interval"`
+	// Count is the total number of the gaps.
+	Count int `json:"count"`
+	// TotalDuration is the sum of the durations of all the gaps.
+	TotalDuration time.Duration `json:"total_duration_ns"`
+	// Gaps are the first gaps, at most the configured maximum.
+	Gaps []Gap `json:"gaps"`
+}
+
+// Gap is an interval without data points.
+type Gap struct {
+	// Start and End are the times of the data points before and after the gap.
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration string    `json:"duration"`
+}
+
+// WriteText writes the number of the gaps and a line for each of the listed gaps.
+func (r *GapReport) WriteText(w *TextWriter) {
+	w.Printf("Gaps (expected interval %s): %d, %s in total\n", r.ExpectedInterval, r.Count, r.TotalDuration)
+	for i, g := range r.Gaps {
+		w.Printf("Gap #%d: %s from %s to %s\n", i+1, g.Duration, g.Start.Format(time.RFC3339Nano), g.End.Format(time.RFC3339Nano))
+	}
+}
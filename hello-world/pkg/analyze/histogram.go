@@ -0,0 +1,179 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"hello-world/pkg/intdash"
+)
+
+const (
+	// DefaultHistogramBuckets is the default number of buckets of a histogram.
+	DefaultHistogramBuckets = 10
+
+	// histogramBarWidth is the width of the longest bar of the ASCII bar chart.
+	histogramBarWidth = 40
+)
+
+// HistogramAnalyzer is an Analyzer that builds a fixed-bucket histogram of the values.
+type HistogramAnalyzer struct {
+	// Buckets is the number of buckets of equal width. Defaults to DefaultHistogramBuckets.
+	Buckets int
+	// Min and Max are the range of the buckets. If both are zero, the range is from the minimum to the maximum of the values.
+	Min float64
+	Max float64
+	// Bars enables the ASCII bar chart.
+	Bars bool
+}
+
+// Histogram is a fixed-bucket histogram of data point values.
+type Histogram struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+	// Counts are the numbers of values in each bucket. The i-th bucket is [Min+i*width, Min+(i+1)*width),
+	// except that the last bucket includes Max.
+	Counts []int `json:"counts"`
+	// Underflow and Overflow are the numbers of values below Min and above Max.
+	Underflow int `json:"underflow"`
+	Overflow  int `json:"overflow"`
+
+	bars bool
+}
+
+func newHistogramAnalyzer(cfg Config) (Analyzer, error) {
+	a := &HistogramAnalyzer{
+		Bars: cfg.Get("HISTOGRAM_BARS") == "true",
+	}
+	if v := cfg.Get("HISTOGRAM_BUCKETS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("HISTOGRAM_BUCKETS must be a positive integer: %q", v)
+		}
+		a.Buckets = n
+	}
+	if v := cfg.Get("HISTOGRAM_RANGE"); v != "" {
+		bounds := strings.Split(v, ",")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("HISTOGRAM_RANGE must be \"min,max\": %q", v)
+		}
+		var err error
+		if a.Min, err = strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64); err != nil {
+			return nil, fmt.Errorf("parse HISTOGRAM_RANGE: %w", err)
+		}
+		if a.Max, err = strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64); err != nil {
+			return nil, fmt.Errorf("parse HISTOGRAM_RANGE: %w", err)
+		}
+		if a.Min >= a.Max {
+			return nil, fmt.Errorf("HISTOGRAM_RANGE min must be less than max: %q", v)
+		}
+	}
+	return a, nil
+}
+
+// Name returns "histogram".
+func (a *HistogramAnalyzer) Name() string {
+	return "histogram"
+}
+
+// Analyze builds the histogram of the values.
+func (a *HistogramAnalyzer) Analyze(ctx context.Context, points []intdash.DataPoint) (AnalyzerResult, error) {
+	buckets := a.Buckets
+	if buckets == 0 {
+		buckets = DefaultHistogramBuckets
+	}
+	hist := &Histogram{
+		Min:    a.Min,
+		Max:    a.Max,
+		Counts: make([]int, buckets),
+		bars:   a.Bars,
+	}
+	if a.Min == 0 && a.Max == 0 {
+		stats := CalculateStatistics(points, nil)
+		hist.Min, hist.Max = stats.Min, stats.Max
+	}
+	for _, dp := range points {
+		hist.add(dp.Value)
+	}
+	return hist, nil
+}
+
+// NewAccumulator returns an Accumulator of the histogram if its range is configured.
+// Otherwise, the range is of the values, so they are all needed first.
+func (a *HistogramAnalyzer) NewAccumulator() (Accumulator, bool) {
+	if a.Min == 0 && a.Max == 0 {
+		return nil, false
+	}
+	buckets := a.Buckets
+	if buckets == 0 {
+		buckets = DefaultHistogramBuckets
+	}
+	return &histogramAccumulator{hist: &Histogram{
+		Min:    a.Min,
+		Max:    a.Max,
+		Counts: make([]int, buckets),
+		bars:   a.Bars,
+	}}, true
+}
+
+type histogramAccumulator struct {
+	hist *Histogram
+}
+
+func (a *histogramAccumulator) Add(dp intdash.DataPoint) {
+	a.hist.add(dp.Value)
+}
+
+func (a *histogramAccumulator) Result() AnalyzerResult {
+	return a.hist
+}
+
+// add counts the value in its bucket.
+func (h *Histogram) add(v float64) {
+	width := (h.Max - h.Min) / float64(len(h.Counts))
+	switch {
+	case v < h.Min:
+		h.Underflow++
+	case v > h.Max:
+		h.Overflow++
+	case width == 0:
+		h.Counts[0]++
+	default:
+		i := int(math.Floor((v - h.Min) / width))
+		if i >= len(h.Counts) {
+			i = len(h.Counts) - 1
+		}
+		h.Counts[i]++
+	}
+}
+
+// WriteText writes a line for each bucket.
+func (h *Histogram) WriteText(w *TextWriter) {
+	maxCount := 0
+	for _, c := range h.Counts {
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+	width := (h.Max - h.Min) / float64(len(h.Counts))
+	for i, c := range h.Counts {
+		lower := h.Min + float64(i)*width
+		upper, closing := lower+width, ")"
+		if i == len(h.Counts)-1 {
+			upper, closing = h.Max, "]"
+		}
+		w.Printf("Histogram [%.6g, %.6g%s: %d", lower, upper, closing, c)
+		if h.bars && c > 0 {
+			w.Printf(" %s", strings.Repeat("#", (c*histogramBarWidth+maxCount-1)/maxCount))
+		}
+		w.WriteString("\n")
+	}
+	if h.Underflow > 0 {
+		w.Printf("Histogram below %.6g: %d\n", h.Min, h.Underflow)
+	}
+	if h.Overflow > 0 {
+		w.Printf("Histogram above %.6g: %d\n", h.Max, h.Overflow)
+	}
+}
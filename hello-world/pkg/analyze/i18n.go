@@ -0,0 +1,152 @@
+package analyze
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Locale is the language and the number format of the notification text.
+type Locale struct {
+	// Name is the locale name, e.g. "ja".
+	Name string
+	// GroupSeparator separates the thousands of numbers, e.g. "," of "1,234.5".
+	GroupSeparator string
+	// DecimalSeparator separates the integer and the fraction of numbers, e.g. "." of "1,234.5".
+	DecimalSeparator string
+	// messages maps the English messages, which are fmt format strings, to the translated ones.
+	// The messages not in the map are written in English.
+	messages map[string]string
+}
+
+// Locales are the supported locales by name.
+var Locales = map[string]*Locale{
+	"en": {Name: "en", GroupSeparator: ",", DecimalSeparator: "."},
+	"ja": {Name: "ja", GroupSeparator: ",", DecimalSeparator: ".", messages: jaMessages},
+}
+
+// jaMessages are the Japanese translations. The timestamps are placed after the values,
+// because the SES notifier splits each line at the first colon.
+var jaMessages = map[string]string{
+	"Alert: %s\n":        "アラート: %s\n",
+	"Channel: %s (%s)\n": "チャネル: %s (%s)\n",
+	"Channel: %s\n":      "チャネル: %s\n",
+	"Link: %s\n":         "リンク: %s\n",
+	"Measurement: %s\n":  "計測: %s\n",
+	"Edge: %s\n":         "エッジ: %s\n",
+	"Basetime: %s\n":     "基準時刻: %s\n",
+	"Duration: %s\n":     "計測時間: %s\n",
+	"Tags: %s\n":         "タグ: %s\n",
+
+	"Partial: %d data points fetched before the error: %s\n": "部分結果: エラーまでに取得した %d 件のデータ (%s)\n",
+
+	"Count":              "件数",
+	"Average":            "平均",
+	"Unbiased Variance":  "不偏分散",
+	"Standard Deviation": "標準偏差",
+	"Min":                "最小",
+	"Max":                "最大",
+	"Median":             "中央値",
+	"%s: %f at %s\n":     "%s: %f (%s)\n",
+
+	"Valid Count: %d\n":   "有効件数: %d\n",
+	"Dropped Count: %d\n": "除外件数: %d\n",
+	"Dropped Breakdown: NaN %d, Inf %d, Sentinel %d\n": "除外内訳: NaN %d, Inf %d, センチネル値 %d\n",
+
+	"Outliers (|z-score| > %g): %d\n":                  "外れ値 (|zスコア| > %g): %d\n",
+	"Outlier #%d: %f (z-score %.2f) at %s\n":           "外れ値 #%d: %f (zスコア %.2f, %s)\n",
+	"... and %d more outliers\n":                       "... ほか %d 件の外れ値\n",
+	"Histogram [%.6g, %.6g%s: %d":                      "ヒストグラム [%.6g, %.6g%s: %d",
+	"Histogram below %.6g: %d\n":                       "ヒストグラム %.6g 未満: %d\n",
+	"Histogram above %.6g: %d\n":                       "ヒストグラム %.6g 超: %d\n",
+	"Dominant Frequency #%d: %.4g Hz (magnitude %f)\n": "卓越周波数 #%d: %.4g Hz (振幅 %f)\n",
+	"Crossings of %g: %d\n":                            "%g の交差: %d\n",
+	"Crossing %s of %g: %f at %s\n":                    "%[2]g の%[1]s交差: %[3]f (%[4]s)\n",
+	"up":                                               "上昇",
+	"down":                                             "下降",
+	"Gaps (expected interval %s): %d, %s in total\n":   "欠損 (想定間隔 %s): %d 件, 合計 %s\n",
+	"Gap #%d: %s from %s to %s\n":                      "欠損 #%d: %s (%s から %s)\n",
+}
+
+// TextWriter writes the notification text in a locale.
+type TextWriter struct {
+	strings.Builder
+	// Locale is the locale of the text. If nil, the text is in English and the numbers are not grouped.
+	Locale *Locale
+}
+
+// T returns the translation of the English message.
+func (w *TextWriter) T(msg string) string {
+	if w.Locale != nil {
+		if translated, ok := w.Locale.messages[msg]; ok {
+			return translated
+		}
+	}
+	return msg
+}
+
+// Printf writes the translation of the format, with the numbers formatted in the locale.
+func (w *TextWriter) Printf(format string, args ...interface{}) {
+	if w.Locale == nil {
+		fmt.Fprintf(w, format, args...)
+		return
+	}
+	for i, arg := range args {
+		switch arg.(type) {
+		case int, int64, float64:
+			args[i] = localNumber{v: arg, locale: w.Locale}
+		}
+	}
+	fmt.Fprintf(w, w.T(format), args...)
+}
+
+// localNumber is a number formatted in a locale.
+type localNumber struct {
+	v      interface{}
+	locale *Locale
+}
+
+// Format formats the number with the verb and the flags, and then applies the separators of the locale.
+func (n localNumber) Format(f fmt.State, verb rune) {
+	directive := "%"
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			directive += string(flag)
+		}
+	}
+	if width, ok := f.Width(); ok {
+		directive += fmt.Sprint(width)
+	}
+	if prec, ok := f.Precision(); ok {
+		directive += "." + fmt.Sprint(prec)
+	}
+	s := fmt.Sprintf(directive+string(verb), n.v)
+	fmt.Fprint(f, n.locale.formatNumber(s))
+}
+
+// formatNumber replaces the separators of a number formatted by fmt, e.g. "-1234.5" to "-1,234.5".
+// The integer part is the first run of digits, so exponents and NaN are left as they are.
+func (l *Locale) formatNumber(s string) string {
+	start := strings.IndexAny(s, "0123456789")
+	if start < 0 {
+		return s
+	}
+	end := start
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	var b strings.Builder
+	b.WriteString(s[:start])
+	digits := s[start:end]
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(l.GroupSeparator)
+		}
+		b.WriteByte(digits[i])
+	}
+	rest := s[end:]
+	if strings.HasPrefix(rest, ".") {
+		rest = l.DecimalSeparator + rest[1:]
+	}
+	b.WriteString(rest)
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"hello-world/pkg/intdash"
+)
+
+const (
+	// DefaultOutlierZScoreLimit is the default absolute z-score above which data points are outliers.
+	DefaultOutlierZScoreLimit = 3
+	// DefaultMaxOutlierPositions is the default maximum number of outliers listed in a notification.
+	DefaultMaxOutlierPositions = 10
+)
+
+// OutlierAnalyzer is an Analyzer that reports the data points whose absolute z-score exceeds the limit.
+type OutlierAnalyzer struct {
+	// ZScoreLimit defaults to DefaultOutlierZScoreLimit.
+	ZScoreLimit float64
+	// MaxPositions is the maximum number of outliers listed. Defaults to DefaultMaxOutlierPositions.
+	MaxPositions int
+}
+
+func newOutlierAnalyzer(cfg Config) (Analyzer, error) {
+	a := &OutlierAnalyzer{}
+	if v := cfg.Get("OUTLIER_ZSCORE_LIMIT"); v != "" {
+		limit, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse OUTLIER_ZSCORE_LIMIT: %w", err)
+		}
+		a.ZScoreLimit = limit
+	}
+	if v := cfg.Get("OUTLIER_MAX_POSITIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse OUTLIER_MAX_POSITIONS: %w", err)
+		}
+		a.MaxPositions = n
+	}
+	return a, nil
+}
+
+// Name returns "outliers".
+func (a *OutlierAnalyzer) Name() string {
+	return "outliers"
+}
+
+// Analyze detects the outliers of the values.
+func (a *OutlierAnalyzer) Analyze(ctx context.Context, points []intdash.DataPoint) (AnalyzerResult, error) {
+	limit := a.ZScoreLimit
+	if limit == 0 {
+		limit = DefaultOutlierZScoreLimit
+	}
+	maxPositions := a.MaxPositions
+	if maxPositions == 0 {
+		maxPositions = DefaultMaxOutlierPositions
+	}
+	return detectOutliers(points, CalculateStatistics(points, nil), limit, maxPositions), nil
+}
+
+// OutlierReport is the report of the data points whose z-score exceeds the limit.
+type OutlierReport struct {
+	ZScoreLimit float64 `json:"zscore_limit"`
+	// Count is the total number of the outliers.
+	Count int `json:"count"`
+	// Outliers are the first outliers, at most the configured maximum.
+	Outliers []Outlier `json:"outliers"`
+}
+
+// Outlier is a data point whose z-score exceeds the limit.
+type Outlier struct {
+	// Index is the position of the data point in the measurement, starting from 0.
+	Index  int       `json:"index"`
+	Time   time.Time `json:"time"`
+	Value  float64   `json:"value"`
+	ZScore float64   `json:"zscore"`
+}
+
+// detectOutliers returns the report of the data points whose absolute z-score exceeds limit.
+// The z-scores are calculated with the average and the standard deviation of stats.
+// At most maxPositions outliers are listed in the report.
+func detectOutliers(dataPoints []intdash.DataPoint, stats *Statistics, limit float64, maxPositions int) *OutlierReport {
+	report := &OutlierReport{ZScoreLimit: limit, Outliers: []Outlier{}}
+	if stats.StdDev == 0 || math.IsNaN(stats.StdDev) {
+		return report
+	}
+	for i, dp := range dataPoints {
+		z := (dp.Value - stats.Average) / stats.StdDev
+		if math.Abs(z) <= limit {
+			continue
+		}
+		report.Count++
+		if len(report.Outliers) < maxPositions {
+			report.Outliers = append(report.Outliers, Outlier{Index: i, Time: dp.Time, Value: dp.Value, ZScore: z})
+		}
+	}
+	return report
+}
+
+// WriteText writes the number of the outliers and a line for each of the listed outliers.
+func (r *OutlierReport) WriteText(w *TextWriter) {
+	w.Printf("Outliers (|z-score| > %g): %d\n", r.ZScoreLimit, r.Count)
+	for _, o := range r.Outliers {
+		w.Printf("Outlier #%d: %f (z-score %.2f) at %s\n", o.Index, o.Value, o.ZScore, o.Time.Format(time.RFC3339Nano))
+	}
+	if r.Count > len(r.Outliers) {
+		w.Printf("... and %d more outliers\n", r.Count-len(r.Outliers))
+	}
+}
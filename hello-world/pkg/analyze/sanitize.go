@@ -0,0 +1,105 @@
+package analyze
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"hello-world/pkg/intdash"
+)
+
+// DataQuality is the data-quality report of the fetched data points.
+type DataQuality struct {
+	// Total is the number of the fetched data points.
+	Total int `json:"total"`
+	// Valid is the number of the data points passed to the analyzers.
+	Valid int `json:"valid"`
+	// Dropped is the number of the invalid data points, which is NaN + Inf + Sentinel.
+	Dropped  int `json:"dropped"`
+	NaN      int `json:"nan"`
+	Inf      int `json:"inf"`
+	Sentinel int `json:"sentinel"`
+}
+
+// SanitizeDataPoints drops NaN, Inf and the sentinel values from the data points,
+// and returns the remaining data points and the data-quality report.
+// The given slice is not modified.
+func SanitizeDataPoints(dataPoints []intdash.DataPoint, sentinels []float64) ([]intdash.DataPoint, *DataQuality) {
+	s := &DataPointSanitizer{Sentinels: sentinels}
+	valid := make([]intdash.DataPoint, 0, len(dataPoints))
+	for _, dp := range dataPoints {
+		if s.Valid(dp) {
+			valid = append(valid, dp)
+		}
+	}
+	return valid, s.Quality()
+}
+
+// DataPointSanitizer checks the data points one by one as they are fetched, as SanitizeDataPoints does.
+// The zero value is ready to use.
+type DataPointSanitizer struct {
+	// Sentinels are the values dropped in addition to NaN and Inf.
+	Sentinels []float64
+
+	quality DataQuality
+}
+
+// Valid counts the data point, and reports whether it is to be analyzed.
+func (s *DataPointSanitizer) Valid(dp intdash.DataPoint) bool {
+	s.quality.Total++
+	switch {
+	case math.IsNaN(dp.Value):
+		s.quality.NaN++
+	case math.IsInf(dp.Value, 0):
+		s.quality.Inf++
+	case isSentinel(dp.Value, s.Sentinels):
+		s.quality.Sentinel++
+	default:
+		s.quality.Valid++
+		return true
+	}
+	s.quality.Dropped++
+	return false
+}
+
+// Quality returns the data-quality report of the checked data points.
+func (s *DataPointSanitizer) Quality() *DataQuality {
+	quality := s.quality
+	return &quality
+}
+
+func isSentinel(v float64, sentinels []float64) bool {
+	for _, s := range sentinels {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSentinelValues parses comma-separated sentinel values, e.g. "-9999,65535".
+func ParseSentinelValues(s string) ([]float64, error) {
+	var values []float64
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse sentinel value %q: %w", v, err)
+		}
+		values = append(values, f)
+	}
+	return values, nil
+}
+
+// WriteText writes the data-quality section. The breakdown is written only if any data point is dropped.
+func (q *DataQuality) WriteText(w *TextWriter) {
+	w.Printf("Valid Count: %d\n", q.Valid)
+	w.Printf("Dropped Count: %d\n", q.Dropped)
+	if q.Dropped > 0 {
+		w.Printf("Dropped Breakdown: NaN %d, Inf %d, Sentinel %d\n", q.NaN, q.Inf, q.Sentinel)
+	}
+}
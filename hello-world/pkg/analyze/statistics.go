@@ -0,0 +1,287 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hello-world/pkg/intdash"
+)
+
+// DefaultStatistics are the statistics notified when none are configured.
+var DefaultStatistics = []string{"average", "unbiased_variance"}
+
+// statisticLabels maps the statistic names to their labels in the notification body.
+// Percentiles ("p50", "p95", "p99.9", ...) are not listed here.
+var statisticLabels = map[string]string{
+	"count":             "Count",
+	"average":           "Average",
+	"unbiased_variance": "Unbiased Variance",
+	"stddev":            "Standard Deviation",
+	"min":               "Min",
+	"max":               "Max",
+	"median":            "Median",
+}
+
+// Statistics is the statistics of data points.
+type Statistics struct {
+	Count            int     `json:"count"`
+	Average          float64 `json:"average"`
+	UnbiasedVariance float64 `json:"unbiased_variance"`
+	// StdDev is the square root of the unbiased variance.
+	StdDev float64 `json:"stddev"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Median float64 `json:"median"`
+	// MinTime and MaxTime are the times of the first data points of the minimum and the maximum.
+	MinTime time.Time `json:"min_time"`
+	MaxTime time.Time `json:"max_time"`
+	// Percentiles maps the requested percentile names, e.g. "p95", to their values.
+	Percentiles map[string]float64 `json:"percentiles,omitempty"`
+
+	// names are the statistics written to the notification body.
+	names []string
+}
+
+// StatisticsAnalyzer is an Analyzer that calculates the Statistics of the values.
+type StatisticsAnalyzer struct {
+	// Names are the statistics in the notification, e.g. "average" or "p95". Defaults to DefaultStatistics.
+	Names []string
+	// Extra are the statistics calculated in addition to Names but not notified, e.g. for the alert rules.
+	Extra []string
+}
+
+func newStatisticsAnalyzer(cfg Config) (Analyzer, error) {
+	names, err := parseStatistics(cfg.Get("STATISTICS"))
+	if err != nil {
+		return nil, fmt.Errorf("parse STATISTICS: %w", err)
+	}
+	rules, err := ParseAlertRules(cfg.Get("ALERT_RULES"))
+	if err != nil {
+		return nil, fmt.Errorf("parse ALERT_RULES: %w", err)
+	}
+	return &StatisticsAnalyzer{Names: names, Extra: AlertStatistics(rules)}, nil
+}
+
+// Name returns "statistics".
+func (a *StatisticsAnalyzer) Name() string {
+	return "statistics"
+}
+
+// Analyze calculates the statistics of the values.
+func (a *StatisticsAnalyzer) Analyze(ctx context.Context, points []intdash.DataPoint) (AnalyzerResult, error) {
+	names := a.Names
+	if len(names) == 0 {
+		names = DefaultStatistics
+	}
+	stats := CalculateStatistics(points, append(append([]string(nil), names...), a.Extra...))
+	stats.names = names
+	return stats, nil
+}
+
+// NewAccumulator returns an Accumulator of the statistics, unless the median or a percentile is requested,
+// which needs all the values.
+func (a *StatisticsAnalyzer) NewAccumulator() (Accumulator, bool) {
+	names := a.Names
+	if len(names) == 0 {
+		names = DefaultStatistics
+	}
+	if needsSortedValues(names) || needsSortedValues(a.Extra) {
+		return nil, false
+	}
+	return &statisticsAccumulator{names: names}, true
+}
+
+type statisticsAccumulator struct {
+	StatisticsAccumulator
+	names []string
+}
+
+func (a *statisticsAccumulator) Result() AnalyzerResult {
+	stats := a.Statistics()
+	stats.names = a.names
+	return stats
+}
+
+// Names returns the names of the notified statistics.
+func (s *Statistics) Names() []string {
+	return s.names
+}
+
+// WriteText writes a line for each of the notified statistics.
+func (s *Statistics) WriteText(w *TextWriter) {
+	for _, name := range s.names {
+		switch name {
+		case "count":
+			w.Printf("%s: %d\n", w.T(statisticLabel(name)), s.Count)
+		case "min":
+			w.Printf("%s: %f at %s\n", w.T(statisticLabel(name)), s.Min, s.MinTime.Format(time.RFC3339Nano))
+		case "max":
+			w.Printf("%s: %f at %s\n", w.T(statisticLabel(name)), s.Max, s.MaxTime.Format(time.RFC3339Nano))
+		default:
+			w.Printf("%s: %f\n", w.T(statisticLabel(name)), s.Value(name))
+		}
+	}
+}
+
+// Value returns the statistic of the given name.
+func (s *Statistics) Value(name string) float64 {
+	switch name {
+	case "count":
+		return float64(s.Count)
+	case "average":
+		return s.Average
+	case "unbiased_variance":
+		return s.UnbiasedVariance
+	case "stddev":
+		return s.StdDev
+	case "min":
+		return s.Min
+	case "max":
+		return s.Max
+	case "median":
+		return s.Median
+	}
+	return s.Percentiles[name]
+}
+
+// parseStatistics parses a comma-separated list of statistic names, e.g. "average,max,p95".
+func parseStatistics(s string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := statisticLabels[name]; !ok {
+			if _, ok := parsePercentile(name); !ok {
+				return nil, fmt.Errorf("unknown statistic %q", name)
+			}
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// parsePercentile parses a percentile name such as "p95" and returns the percentile in [0, 100].
+func parsePercentile(name string) (float64, bool) {
+	if !strings.HasPrefix(name, "p") {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(name[1:], 64)
+	if err != nil || p < 0 || p > 100 {
+		return 0, false
+	}
+	return p, true
+}
+
+// statisticLabel returns the label of the statistic in the notification body.
+func statisticLabel(name string) string {
+	if label, ok := statisticLabels[name]; ok {
+		return label
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// StatisticsAccumulator calculates the statistics of data points in a single pass
+// with Welford's online algorithm, without holding the data points.
+// The median and the percentiles are not calculated because they need all the values.
+// The zero value is ready to use.
+type StatisticsAccumulator struct {
+	count int
+	mean  float64
+	m2    float64 // sum of squares of differences from the mean
+	min   intdash.DataPoint
+	max   intdash.DataPoint
+}
+
+// Add adds a data point.
+func (a *StatisticsAccumulator) Add(dp intdash.DataPoint) {
+	v := dp.Value
+	a.count++
+	if a.count == 1 || v < a.min.Value {
+		a.min = dp
+	}
+	if a.count == 1 || v > a.max.Value {
+		a.max = dp
+	}
+	delta := v - a.mean
+	a.mean += delta / float64(a.count)
+	a.m2 += delta * (v - a.mean)
+}
+
+// Statistics returns the statistics of the added values.
+// The average is NaN if no value has been added.
+func (a *StatisticsAccumulator) Statistics() *Statistics {
+	stats := &Statistics{
+		Count:   a.count,
+		Average: a.mean,
+		Min:     a.min.Value,
+		MinTime: a.min.Time,
+		Max:     a.max.Value,
+		MaxTime: a.max.Time,
+	}
+	if a.count == 0 {
+		stats.Average = math.NaN()
+	}
+	if a.count > 1 {
+		stats.UnbiasedVariance = a.m2 / float64(a.count-1)
+	}
+	stats.StdDev = math.Sqrt(stats.UnbiasedVariance)
+	return stats
+}
+
+// CalculateStatistics calculates the statistics of the given data points.
+// The median and the percentiles in names need the sorted values,
+// so they are calculated only if requested.
+func CalculateStatistics(dataPoints []intdash.DataPoint, names []string) *Statistics {
+	var acc StatisticsAccumulator
+	for _, dp := range dataPoints {
+		acc.Add(dp)
+	}
+	stats := acc.Statistics()
+	if len(dataPoints) == 0 || !needsSortedValues(names) {
+		return stats
+	}
+
+	sorted := make([]float64, len(dataPoints))
+	for i, dp := range dataPoints {
+		sorted[i] = dp.Value
+	}
+	sort.Float64s(sorted)
+	for _, name := range names {
+		if name == "median" {
+			stats.Median = percentile(sorted, 50)
+		}
+		if p, ok := parsePercentile(name); ok {
+			if stats.Percentiles == nil {
+				stats.Percentiles = map[string]float64{}
+			}
+			stats.Percentiles[name] = percentile(sorted, p)
+		}
+	}
+	return stats
+}
+
+// needsSortedValues reports whether any of the statistics needs the sorted values.
+func needsSortedValues(names []string) bool {
+	for _, name := range names {
+		if _, ok := parsePercentile(name); ok || name == "median" {
+			return true
+		}
+	}
+	return false
+}
+
+// percentile returns the p-th percentile of the sorted values,
+// linearly interpolated between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,103 @@
+// Package cache is an in-memory cache of values that expire, kept across warm Lambda invocations.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache is a map of values that expire after a TTL. It is safe for concurrent use.
+// The expired values are removed when they are got, and swept at most once per TTL when a value is set,
+// so that the cache of a long-lived process does not grow with every key ever set.
+type Cache[K comparable, V any] struct {
+	// TTL is the time a value is fresh, unless it is set with SetWithTTL.
+	TTL time.Duration
+	// KeepStale keeps the expired values until they are replaced or invalidated, so that a caller can fall back
+	// to them with GetStale if refreshing fails.
+	KeepStale bool
+	// Clock returns the current time. Defaults to time.Now.
+	Clock func() time.Time
+
+	mu      sync.Mutex
+	entries map[K]entry[V]
+	// sweepAt is the time the expired values are swept next.
+	sweepAt time.Time
+}
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// New returns a cache of the values fresh for ttl.
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{TTL: ttl}
+}
+
+// Get returns the value of the key if it is fresh.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expiresAt) {
+		if ok && !c.KeepStale {
+			delete(c.entries, key)
+		}
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// GetStale returns the value of the key even if it has expired. The expired values are kept only with KeepStale.
+func (c *Cache[K, V]) GetStale(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	return e.value, ok
+}
+
+// Set sets the value of the key, fresh for c.TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.TTL)
+}
+
+// SetWithTTL sets the value of the key, fresh for ttl, e.g. the lifetime of a token.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[K]entry[V]{}
+	}
+	now := c.now()
+	if !c.KeepStale && !now.Before(c.sweepAt) {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.sweepAt = now.Add(max(c.TTL, ttl))
+	}
+	c.entries[key] = entry[V]{value: value, expiresAt: now.Add(ttl)}
+}
+
+// Invalidate removes the value of the key, e.g. when it is known to have changed.
+func (c *Cache[K, V]) Invalidate(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// InvalidateAll removes all the values.
+func (c *Cache[K, V]) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+}
+
+func (c *Cache[K, V]) now() time.Time {
+	if c.Clock != nil {
+		return c.Clock()
+	}
+	return time.Now()
+}
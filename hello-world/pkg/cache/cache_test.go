@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheGet(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		keepStale bool
+		elapsed   time.Duration
+		wantOK    bool
+		// wantStaleOK reports whether GetStale returns the value after Get.
+		wantStaleOK bool
+	}{
+		{name: "fresh", elapsed: 59 * time.Second, wantOK: true, wantStaleOK: true},
+		{name: "expired", elapsed: time.Minute},
+		{name: "expired kept stale", keepStale: true, elapsed: time.Hour, wantStaleOK: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+			c := New[string, int](time.Minute)
+			c.KeepStale = tt.keepStale
+			c.Clock = func() time.Time { return now }
+			c.Set("key", 1)
+
+			now = now.Add(tt.elapsed)
+			if got, ok := c.Get("key"); ok != tt.wantOK || (ok && got != 1) {
+				t.Errorf("Get() = %d, %t, want 1, %t", got, ok, tt.wantOK)
+			}
+			if got, ok := c.GetStale("key"); ok != tt.wantStaleOK || (ok && got != 1) {
+				t.Errorf("GetStale() = %d, %t, want 1, %t", got, ok, tt.wantStaleOK)
+			}
+		})
+	}
+}
+
+func TestCacheSweep(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		keepStale bool
+		// elapsed is the time from setting the first values until setting the last one.
+		elapsed time.Duration
+		want    []string
+	}{
+		{name: "swept", elapsed: 2 * time.Minute, want: []string{"token", "last"}},
+		{name: "not swept before the TTL", elapsed: 30 * time.Second, want: []string{"short", "long", "token", "last"}},
+		{name: "kept stale", keepStale: true, elapsed: 2 * time.Minute, want: []string{"short", "long", "token", "last"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+			c := New[string, int](time.Minute)
+			c.KeepStale = tt.keepStale
+			c.Clock = func() time.Time { return now }
+			c.SetWithTTL("short", 1, 10*time.Second)
+			c.Set("long", 2)
+			c.SetWithTTL("token", 3, time.Hour)
+
+			now = now.Add(tt.elapsed)
+			c.Set("last", 4)
+			if len(c.entries) != len(tt.want) {
+				t.Errorf("%d entries after the sweep, want %v", len(c.entries), tt.want)
+			}
+			for _, key := range tt.want {
+				if _, ok := c.GetStale(key); !ok {
+					t.Errorf("GetStale(%q) not found, want kept", key)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	c := New[string, int](time.Minute)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	c.Invalidate("a")
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("Get(%q) found after Invalidate", "a")
+	}
+	if got, ok := c.Get("b"); !ok || got != 2 {
+		t.Errorf("Get(%q) = %d, %t, want 2, true", "b", got, ok)
+	}
+
+	c.InvalidateAll()
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("Get(%q) found after InvalidateAll", "b")
+	}
+	c.Set("c", 3)
+	if got, ok := c.Get("c"); !ok || got != 3 {
+		t.Errorf("Get(%q) = %d, %t after InvalidateAll, want 3, true", "c", got, ok)
+	}
+}
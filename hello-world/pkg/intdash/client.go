@@ -0,0 +1,328 @@
+// Package intdash is a client of the intdash REST API.
+package intdash
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TokenHeader is the name of the header that carries the intdash API token.
+	TokenHeader = "X-Intdash-Token"
+	// DefaultPageSize is the default maximum number of entries requested at once from the data points API.
+	DefaultPageSize = 100000
+)
+
+// Client calls the intdash REST API.
+type Client struct {
+	// BaseURL is the base URL of the intdash server, e.g. "https://example.intdash.jp".
+	BaseURL string
+	// APIToken is the intdash API token used to authenticate requests.
+	// It is ignored if TokenProvider is set.
+	APIToken string
+	// TokenProvider provides OAuth2 access tokens used to authenticate requests.
+	TokenProvider TokenProvider
+	// PageSize is the maximum number of entries requested at once. Defaults to DefaultPageSize.
+	PageSize int
+	// MaxDataPoints is the maximum number of data points of a data ID fetched for a measurement.
+	// A measurement with more data points fails instead of exhausting the memory. Zero means no limit.
+	MaxDataPoints int
+
+	HTTPClient *http.Client
+}
+
+// StatusError is the error of a response of the intdash API with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Path       string
+	// Body is the beginning of the response body.
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d from %s: %s", e.StatusCode, e.Path, e.Body)
+}
+
+// intdashDataPoint is a line of the JSON Lines response of the intdash data points API.
+type intdashDataPoint struct {
+	Time     string          `json:"time"`
+	DataType string          `json:"data_type"`
+	DataID   string          `json:"data_id"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// DataPoint is a float64 data point of a measurement.
+type DataPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Measurement is the metadata of an intdash measurement.
+type Measurement struct {
+	UUID     string `json:"uuid"`
+	Name     string `json:"name"`
+	EdgeUUID string `json:"edge_uuid"`
+	// Basetime is the start time of the measurement.
+	Basetime time.Time `json:"basetime"`
+	// Duration is the elapsed time of the last data point of the measurement.
+	Duration time.Duration     `json:"duration_ns"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// intdashMeasurement is the response of the intdash measurement API.
+type intdashMeasurement struct {
+	UUID     string `json:"uuid"`
+	Name     string `json:"name"`
+	EdgeUUID string `json:"edge_uuid"`
+	Basetime string `json:"basetime"`
+	// MaxElapsedTime is the elapsed time of the last data point in seconds.
+	MaxElapsedTime float64           `json:"max_elapsed_time"`
+	Tags           map[string]string `json:"tags"`
+}
+
+// FetchMeasurement fetches the metadata of the measurement from the intdash measurement API.
+func (c *Client) FetchMeasurement(ctx context.Context, measurementUUID string) (*Measurement, error) {
+	resp, err := c.get(ctx, "/api/v1/measurements/"+url.PathEscape(measurementUUID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var m intdashMeasurement
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, fmt.Errorf("decode measurement: %w", err)
+	}
+	basetime, err := time.Parse(time.RFC3339Nano, m.Basetime)
+	if err != nil {
+		return nil, fmt.Errorf("parse measurement basetime: %w", err)
+	}
+	return &Measurement{
+		UUID:     m.UUID,
+		Name:     m.Name,
+		EdgeUUID: m.EdgeUUID,
+		Basetime: basetime,
+		Duration: time.Duration(m.MaxElapsedTime * float64(time.Second)),
+		Tags:     m.Tags,
+	}, nil
+}
+
+// FetchFloat64DataPoints fetches the float64 data points of the given data ID of the measurement in the time range
+// from the intdash data points API.
+// If the fetch fails mid-way, e.g. on a timeout, the data points fetched so far are returned with the error.
+func (c *Client) FetchFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr TimeRange) ([]DataPoint, error) {
+	var res []DataPoint
+	err := c.StreamFloat64DataPoints(ctx, measurementUUID, dataID, tr, func(dp DataPoint) error {
+		res = append(res, dp)
+		return nil
+	})
+	return res, err
+}
+
+// StreamFloat64DataPoints calls fn with each float64 data point of the given data ID of the measurement in the time range as it is read from the response,
+// so that the data points can be processed, e.g. by a StatisticsAccumulator, without holding all of them.
+// The data points are requested in pages of c.PageSize entries, each page starting at the time of the last entry of the previous one.
+// If fn returns an error, the streaming stops and the error is returned.
+func (c *Client) StreamFloat64DataPoints(ctx context.Context, measurementUUID, dataID string, tr TimeRange, fn func(DataPoint) error) error {
+	pageSize := c.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	var (
+		cursor  intdashCursor
+		fetched int
+	)
+	if !tr.Start.IsZero() {
+		cursor.time = tr.Start.UTC().Format(time.RFC3339Nano)
+	}
+	for {
+		page, err := c.fetchPage(ctx, measurementUUID, dataID, pageSize, tr.End, &cursor, func(dp DataPoint) error {
+			fetched++
+			if c.MaxDataPoints > 0 && fetched > c.MaxDataPoints {
+				return fmt.Errorf("measurement %s has more than %d data points of %s", measurementUUID, c.MaxDataPoints, dataID)
+			}
+			return fn(dp)
+		})
+		if err != nil {
+			return err
+		}
+		if page.entries < pageSize {
+			return nil
+		}
+		if !page.advanced {
+			// The whole page has the time of the cursor, so the next page would be the same.
+			return fmt.Errorf("more than %d entries at %s, increase the page size", pageSize, cursor.time)
+		}
+	}
+}
+
+// intdashCursor is the position of the next page of the data points.
+// The next page starts at time, and the first skip entries at time are skipped
+// because they are already in the previous page.
+type intdashCursor struct {
+	time string
+	skip int
+}
+
+// intdashPage is the summary of a page of the data points.
+type intdashPage struct {
+	// entries is the number of the entries in the page, including the skipped and non-float64 ones.
+	entries int
+	// advanced reports whether the page has an entry after the time of the previous cursor.
+	advanced bool
+}
+
+// fetchPage fetches a page of at most limit entries from the cursor until end, calls fn with each new float64 data point
+// and advances the cursor to the last entry of the page. A zero end means the end of the measurement.
+func (c *Client) fetchPage(ctx context.Context, measurementUUID, dataID string, limit int, end time.Time, cursor *intdashCursor, fn func(DataPoint) error) (*intdashPage, error) {
+	query := url.Values{}
+	query.Set("name", measurementUUID)
+	query.Set("data_id_filter", dataID)
+	query.Set("time_format", "rfc3339")
+	query.Set("limit", strconv.Itoa(limit))
+	if cursor.time != "" {
+		query.Set("start", cursor.time)
+	}
+	if !end.IsZero() {
+		query.Set("end", end.UTC().Format(time.RFC3339Nano))
+	}
+
+	resp, err := c.get(ctx, "/api/v1/data", query)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	page := &intdashPage{}
+	start, skip := cursor.time, cursor.skip
+	// The JSON Lines are decoded from the body as they are read, so that only an entry is held at a time,
+	// whatever the size of the page.
+	dec := json.NewDecoder(bufio.NewReaderSize(resp.Body, 64*1024))
+	for {
+		var dp intdashDataPoint
+		if err := dec.Decode(&dp); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("decode data point: %w", err)
+		}
+		page.entries++
+		if dp.Time == cursor.time {
+			cursor.skip++
+		} else {
+			cursor.time, cursor.skip = dp.Time, 1
+		}
+		if dp.Time == start && skip > 0 {
+			skip--
+			continue
+		}
+		if dp.Time != start {
+			page.advanced = true
+		}
+		if dp.DataID != dataID {
+			// e.g. basetime entries
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, dp.Time)
+		if err != nil {
+			return nil, fmt.Errorf("parse data point time: %w", err)
+		}
+		var v float64
+		if err := json.Unmarshal(dp.Data, &v); err != nil {
+			return nil, fmt.Errorf("unmarshal data point value at %s: %w", dp.Time, err)
+		}
+		if err := fn(DataPoint{Time: t, Value: v}); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// Check checks that the intdash API is reachable and accepts the credentials of c,
+// by getting the user of the credentials.
+func (c *Client) Check(ctx context.Context) error {
+	resp, err := c.get(ctx, "/api/auth/users/me", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// get sends an authenticated GET request to the given path of the intdash API.
+// The caller must close the response body.
+func (c *Client) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	return c.send(ctx, http.MethodGet, path, query, nil)
+}
+
+// send sends an authenticated request to the given path of the intdash API, with body as JSON if not nil.
+// A response with a non-2xx status is an error. The caller must close the response body.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	u := strings.TrimSuffix(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := c.authorize(ctx, req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The cached token may have been revoked, so the next request gets a new one.
+		if invalidator, ok := c.TokenProvider.(interface{ Invalidate() }); ok {
+			invalidator.Invalidate()
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Path: path, Body: string(b)}
+	}
+	return resp, nil
+}
+
+// authorize sets the authentication header of the given request.
+func (c *Client) authorize(ctx context.Context, req *http.Request) error {
+	if c.TokenProvider == nil {
+		req.Header.Set(TokenHeader, c.APIToken)
+		return nil
+	}
+	token, err := c.TokenProvider.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("get access token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,97 @@
+package intdash
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newDataPointsServer serves the entries, in order of time, from the data points API
+// as intdash does: the entries from the start time inclusive, at most limit of them.
+func newDataPointsServer(t *testing.T, entries []intdashDataPoint, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		query := r.URL.Query()
+		limit, err := strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			t.Errorf("limit = %q, want a number", query.Get("limit"))
+		}
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if limit == 0 {
+				break
+			}
+			if start := query.Get("start"); start != "" && e.Time < start {
+				continue
+			}
+			enc.Encode(e)
+			limit--
+		}
+	}))
+}
+
+func TestClientStreamFloat64DataPointsPagination(t *testing.T) {
+	entry := func(second int, dataID string, value string) intdashDataPoint {
+		return intdashDataPoint{
+			Time:     time.Date(2024, 1, 15, 12, 0, second, 0, time.UTC).Format(time.RFC3339Nano),
+			DataType: "float",
+			DataID:   dataID,
+			Data:     json.RawMessage(value),
+		}
+	}
+	entries := []intdashDataPoint{
+		entry(0, "basetime", `"2024-01-15T12:00:00Z"`),
+		entry(0, "speed", "1"),
+		entry(1, "speed", "2"),
+		entry(1, "speed", "3"),
+		entry(1, "speed", "4"),
+		entry(2, "speed", "5"),
+	}
+
+	for _, tt := range []struct {
+		name          string
+		pageSize      int
+		maxDataPoints int
+		want          []float64
+		wantRequests  int
+		wantErr       bool
+	}{
+		{name: "single page", pageSize: 10, want: []float64{1, 2, 3, 4, 5}, wantRequests: 1},
+		{name: "exact page", pageSize: 6, want: []float64{1, 2, 3, 4, 5}, wantRequests: 2},
+		{name: "page boundary in same time", pageSize: 4, want: []float64{1, 2, 3, 4, 5}, wantRequests: 3},
+		{name: "as many entries at a time as a page", pageSize: 3, want: []float64{1, 2, 3, 4}, wantErr: true},
+		{name: "too many data points", pageSize: 10, maxDataPoints: 4, want: []float64{1, 2, 3, 4}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			server := newDataPointsServer(t, entries, &requests)
+			defer server.Close()
+
+			c := &Client{BaseURL: server.URL, APIToken: "token", PageSize: tt.pageSize, MaxDataPoints: tt.maxDataPoints}
+			got := []float64{}
+			err := c.StreamFloat64DataPoints(context.Background(), "measurement", "speed", TimeRange{}, func(dp DataPoint) error {
+				got = append(got, dp.Value)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StreamFloat64DataPoints() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("StreamFloat64DataPoints() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("StreamFloat64DataPoints() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+			if !tt.wantErr && requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
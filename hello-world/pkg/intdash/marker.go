@@ -0,0 +1,57 @@
+package intdash
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// MeasurementMarker is a span marker of a measurement.
+type MeasurementMarker struct {
+	Name        string
+	Description string
+	// Start and End are the elapsed times of the span from the basetime of the measurement.
+	Start time.Duration
+	End   time.Duration
+}
+
+// intdashMarker is the request body of the intdash marker API.
+type intdashMarker struct {
+	Type        string              `json:"type"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Detail      intdashMarkerDetail `json:"detail"`
+}
+
+// intdashMarkerDetail is the span of a marker in seconds.
+type intdashMarkerDetail struct {
+	StartElapsedTime float64 `json:"start_elapsed_time"`
+	EndElapsedTime   float64 `json:"end_elapsed_time"`
+}
+
+// UpdateMeasurementTags replaces the tags of the measurement with the intdash measurement API.
+func (c *Client) UpdateMeasurementTags(ctx context.Context, measurementUUID string, tags map[string]string) error {
+	resp, err := c.send(ctx, http.MethodPut, "/api/v1/measurements/"+url.PathEscape(measurementUUID), nil, map[string]interface{}{"tags": tags})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// CreateMeasurementMarker creates the span marker in the measurement with the intdash marker API.
+func (c *Client) CreateMeasurementMarker(ctx context.Context, measurementUUID string, marker *MeasurementMarker) error {
+	resp, err := c.send(ctx, http.MethodPost, "/api/v1/measurements/"+url.PathEscape(measurementUUID)+"/markers", nil, &intdashMarker{
+		Type:        "span",
+		Name:        marker.Name,
+		Description: marker.Description,
+		Detail: intdashMarkerDetail{
+			StartElapsedTime: marker.Start.Seconds(),
+			EndElapsedTime:   marker.End.Seconds(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
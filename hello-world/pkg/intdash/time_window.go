@@ -0,0 +1,81 @@
+package intdash
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeRange is a range of time of the data points to fetch, including Start and excluding End.
+// A zero Start or End means unbounded.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// TimeWindow is a segment of a measurement relative to the measurement itself,
+// used to analyze only a part of it, e.g. the last 30 seconds.
+type TimeWindow struct {
+	// Last, if positive, is the duration of the window at the end of the measurement. From and To are ignored.
+	Last time.Duration
+	// From and To are the elapsed times from the basetime of the measurement.
+	// A zero To means the end of the measurement.
+	From time.Duration
+	To   time.Duration
+}
+
+// Range returns the time range of the window in the given measurement.
+func (w *TimeWindow) Range(m *Measurement) TimeRange {
+	if w.Last > 0 {
+		return TimeRange{Start: m.Basetime.Add(m.Duration - w.Last)}
+	}
+	r := TimeRange{Start: m.Basetime.Add(w.From)}
+	if w.To > 0 {
+		r.End = m.Basetime.Add(w.To)
+	}
+	return r
+}
+
+// String returns the window as configured, e.g. "last 30s" or "10s-1m0s".
+func (w *TimeWindow) String() string {
+	if w.Last > 0 {
+		return "last " + w.Last.String()
+	}
+	if w.To > 0 {
+		return w.From.String() + "-" + w.To.String()
+	}
+	return w.From.String() + "-"
+}
+
+// ParseTimeWindow parses the FETCH_LAST and FETCH_OFFSET_RANGE values.
+// last is a duration such as "30s". offsetRange is "from,to" of elapsed times such as "10s,60s",
+// where to may be omitted, e.g. "10s,". It returns nil if both are empty.
+func ParseTimeWindow(last, offsetRange string) (*TimeWindow, error) {
+	switch {
+	case last != "" && offsetRange != "":
+		return nil, fmt.Errorf("both FETCH_LAST and FETCH_OFFSET_RANGE are set")
+	case last != "":
+		d, err := time.ParseDuration(last)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("FETCH_LAST must be a positive duration: %q", last)
+		}
+		return &TimeWindow{Last: d}, nil
+	case offsetRange != "":
+		parts := strings.Split(offsetRange, ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid FETCH_OFFSET_RANGE %q, want \"from,to\"", offsetRange)
+		}
+		w := &TimeWindow{}
+		var err error
+		if w.From, err = time.ParseDuration(strings.TrimSpace(parts[0])); err != nil || w.From < 0 {
+			return nil, fmt.Errorf("invalid FETCH_OFFSET_RANGE %q: from must be a non-negative duration", offsetRange)
+		}
+		if to := strings.TrimSpace(parts[1]); to != "" {
+			if w.To, err = time.ParseDuration(to); err != nil || w.To <= w.From {
+				return nil, fmt.Errorf("invalid FETCH_OFFSET_RANGE %q: to must be a duration after from", offsetRange)
+			}
+		}
+		return w, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,117 @@
+package intdash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"hello-world/pkg/cache"
+)
+
+const (
+	// intdashTokenRefreshMargin is how long before expiry a cached access token is refreshed,
+	// at most half of the lifetime of the token.
+	intdashTokenRefreshMargin = 60 * time.Second
+)
+
+// TokenProvider provides an access token for the intdash API.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// ClientCredentialsTokenProvider is a TokenProvider that performs the OAuth2 client credentials flow
+// against the intdash auth server.
+// The access token is cached in memory, so it is reused across warm Lambda invocations
+// and refreshed shortly before it expires.
+type ClientCredentialsTokenProvider struct {
+	// BaseURL is the base URL of the intdash server, e.g. "https://example.intdash.jp".
+	BaseURL      string
+	ClientID     string
+	ClientSecret string
+
+	HTTPClient *http.Client
+
+	// mu serializes the token requests, so that concurrent calls fetch a token once.
+	mu     sync.Mutex
+	tokens cache.Cache[string, string]
+}
+
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Token returns the cached access token, or fetches a new one if it is missing or about to expire.
+func (p *ClientCredentialsTokenProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if token, ok := p.tokens.Get(p.ClientID); ok {
+		return token, nil
+	}
+
+	token, err := p.fetchToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	// The token is refreshed shortly before it expires.
+	p.tokens.SetWithTTL(p.ClientID, token.AccessToken, tokenTTL(time.Duration(token.ExpiresIn)*time.Second))
+	return token.AccessToken, nil
+}
+
+// Invalidate removes the cached access token, so that a new one is fetched on the next call,
+// e.g. when the token is rejected before it expires.
+func (p *ClientCredentialsTokenProvider) Invalidate() {
+	p.tokens.Invalidate(p.ClientID)
+}
+
+// tokenTTL returns how long a token that expires in expiresIn is cached. The refresh margin is clamped
+// to half of the lifetime, so that a short-lived token is still reused instead of fetched on every call.
+func tokenTTL(expiresIn time.Duration) time.Duration {
+	return expiresIn - min(intdashTokenRefreshMargin, expiresIn/2)
+}
+
+// fetchToken requests a new access token with the client credentials grant.
+func (p *ClientCredentialsTokenProvider) fetchToken(ctx context.Context) (*oauth2TokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", p.ClientID)
+	form.Set("client_secret", p.ClientSecret)
+
+	u := strings.TrimSuffix(p.BaseURL, "/") + "/api/auth/oauth2/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send token request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %d from token endpoint: %s", resp.StatusCode, b)
+	}
+
+	var token oauth2TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+	return &token, nil
+}
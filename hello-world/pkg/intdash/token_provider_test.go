@@ -0,0 +1,53 @@
+package intdash
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenTTL(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		expiresIn time.Duration
+		want      time.Duration
+	}{
+		{name: "long-lived", expiresIn: time.Hour, want: time.Hour - intdashTokenRefreshMargin},
+		{name: "twice the margin", expiresIn: 2 * intdashTokenRefreshMargin, want: intdashTokenRefreshMargin},
+		{name: "shorter than the margin", expiresIn: 30 * time.Second, want: 15 * time.Second},
+		{name: "expired", expiresIn: 0, want: 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenTTL(tt.expiresIn); got != tt.want {
+				t.Errorf("tokenTTL(%s) = %s, want %s", tt.expiresIn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientCredentialsTokenProviderReusesShortLivedToken(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":30}`, requests)
+	}))
+	defer server.Close()
+
+	p := &ClientCredentialsTokenProvider{BaseURL: server.URL, ClientID: "client", ClientSecret: "secret"}
+	for i := 0; i < 3; i++ {
+		token, err := p.Token(context.Background())
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		if token != "token-1" {
+			t.Errorf("Token() = %q, want %q", token, "token-1")
+		}
+	}
+	if requests != 1 {
+		t.Errorf("token requests = %d, want 1", requests)
+	}
+}
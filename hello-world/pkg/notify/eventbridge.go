@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+const (
+	// EventBridgeSource is the source of the events put by EventBridgeNotifier.
+	EventBridgeSource = "intdash.webhook"
+)
+
+type EventBridgePutEventsAPI interface {
+	PutEvents(ctx context.Context, input *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridgeNotifier is a Notifier that puts the notification as an event to an EventBridge event bus.
+// The detail type is "<resource_type> <action>", e.g. "measurement finished",
+// so that rules can subscribe to each action separately. The detail is a NotificationPayload.
+type EventBridgeNotifier struct {
+	EventBridgePutEventsAPI EventBridgePutEventsAPI
+	// EventBusName is the name or ARN of the event bus. If empty, the default event bus is used.
+	EventBusName string
+}
+
+// Name returns "eventbridge".
+func (n *EventBridgeNotifier) Name() string {
+	return "eventbridge"
+}
+
+// Notify puts the notification event.
+func (n *EventBridgeNotifier) Notify(ctx context.Context, notification *Notification) error {
+	event := notification.Event
+	detail, err := json.Marshal(notification.Payload())
+	if err != nil {
+		return fmt.Errorf("marshal event detail: %w", err)
+	}
+
+	entry := types.PutEventsRequestEntry{
+		Source:     aws.String(EventBridgeSource),
+		DetailType: aws.String(event.ResourceType + " " + event.Action),
+		Detail:     aws.String(string(detail)),
+	}
+	if n.EventBusName != "" {
+		entry.EventBusName = aws.String(n.EventBusName)
+	}
+	out, err := n.EventBridgePutEventsAPI.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []types.PutEventsRequestEntry{entry},
+	})
+	if err != nil {
+		return fmt.Errorf("put events: %w", err)
+	}
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		return fmt.Errorf("put events: %s: %s", aws.ToString(out.Entries[0].ErrorCode), aws.ToString(out.Entries[0].ErrorMessage))
+	}
+	if len(out.Entries) > 0 {
+		slog.InfoContext(ctx, "Put EventBridge event", "event_id", aws.ToString(out.Entries[0].EventId))
+	}
+	return nil
+}
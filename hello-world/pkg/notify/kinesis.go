@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+)
+
+type KinesisPutRecordAPI interface {
+	PutRecord(ctx context.Context, input *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error)
+}
+
+// KinesisNotifier is a Notifier that writes the notification to a Kinesis data stream.
+// The record data is a NotificationPayload and the partition key is the measurement UUID,
+// so the records of a measurement are kept in order on the same shard.
+type KinesisNotifier struct {
+	KinesisPutRecordAPI KinesisPutRecordAPI
+	// Stream is the name or ARN of the data stream.
+	Stream string
+}
+
+// Name returns "kinesis".
+func (n *KinesisNotifier) Name() string {
+	return "kinesis"
+}
+
+// Notify puts the notification record.
+func (n *KinesisNotifier) Notify(ctx context.Context, notification *Notification) error {
+	data, err := json.Marshal(notification.Payload())
+	if err != nil {
+		return fmt.Errorf("marshal record data: %w", err)
+	}
+
+	input := &kinesis.PutRecordInput{
+		Data:         data,
+		PartitionKey: aws.String(notification.Event.MeasurementUUID),
+	}
+	if strings.HasPrefix(n.Stream, "arn:") {
+		input.StreamARN = aws.String(n.Stream)
+	} else {
+		input.StreamName = aws.String(n.Stream)
+	}
+	out, err := n.KinesisPutRecordAPI.PutRecord(ctx, input)
+	if err != nil {
+		return fmt.Errorf("put Kinesis record: %w", err)
+	}
+	slog.InfoContext(ctx, "Put Kinesis record", "shard_id", aws.ToString(out.ShardId), "sequence_number", aws.ToString(out.SequenceNumber))
+	return nil
+}
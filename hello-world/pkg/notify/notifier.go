@@ -0,0 +1,78 @@
+// Package notify sends the notifications of the analysis results to the destinations.
+package notify
+
+import (
+	"context"
+
+	"hello-world/pkg/intdash"
+	"hello-world/pkg/webhook"
+)
+
+// Notifier sends the notification of an analysis result to a destination.
+type Notifier interface {
+	// Name identifies the destination in logs and errors, e.g. "sns".
+	Name() string
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// Checker is implemented by the notifiers that can check the access to their destination without sending a notification.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Notification is the notification of an analysis result.
+type Notification struct {
+	// Event is the webhook event that triggered the notification.
+	Event *webhook.Body
+	// DeliveryID is the delivery ID of the event, if known.
+	DeliveryID string
+	// Body is the text of the notification.
+	Body string
+	// Subject is the subject of the notification, if rendered from a template.
+	// Otherwise, each notifier uses its default subject.
+	Subject string
+	// Alerts are the names of the fired alert rules, if alert rules are configured.
+	Alerts []string
+	// Link is the link to the measurement in the intdash console, if configured.
+	Link string
+	// Measurement is the metadata of the measurement, if configured.
+	Measurement *intdash.Measurement
+	// Partial is true if any of the results is partial because the fetch failed mid-way.
+	Partial bool
+}
+
+const (
+	// SeverityInfo is the severity of a notification without fired alert rules.
+	SeverityInfo = "info"
+	// SeverityAlert is the severity of a notification with fired alert rules.
+	SeverityAlert = "alert"
+)
+
+// Severity returns SeverityAlert if any alert rule fired, and SeverityInfo otherwise.
+func (n *Notification) Severity() string {
+	if len(n.Alerts) > 0 {
+		return SeverityAlert
+	}
+	return SeverityInfo
+}
+
+// NotificationPayload is the JSON representation of a Notification,
+// used by the notifiers that send structured messages.
+type NotificationPayload struct {
+	ResourceType    string   `json:"resource_type"`
+	Action          string   `json:"action"`
+	MeasurementUUID string   `json:"measurement_uuid"`
+	Body            string   `json:"body"`
+	Alerts          []string `json:"alerts,omitempty"`
+}
+
+// Payload returns the JSON representation of the notification.
+func (n *Notification) Payload() *NotificationPayload {
+	return &NotificationPayload{
+		ResourceType:    n.Event.ResourceType,
+		Action:          n.Event.Action,
+		MeasurementUUID: n.Event.MeasurementUUID,
+		Body:            n.Body,
+		Alerts:          n.Alerts,
+	}
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+
+	"hello-world/pkg/webhook"
+)
+
+type SESSendEmailAPI interface {
+	SendEmail(ctx context.Context, input *sesv2.SendEmailInput, optFns ...func(*sesv2.Options)) (*sesv2.SendEmailOutput, error)
+}
+
+// sesHTMLTemplate renders the notification as an HTML email.
+// The "Key: value" lines of the notification body are rendered as table rows.
+var sesHTMLTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<h2>intdash {{.Event.ResourceType}} {{.Event.Action}}</h2>
+<p>Measurement: <code>{{.Event.MeasurementUUID}}</code></p>
+<table style="border-collapse: collapse;">
+{{- range .Rows}}
+<tr><th style="text-align: left; padding: 4px 12px 4px 0;">{{.Key}}</th><td style="padding: 4px 0;">{{.Value}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// SESNotifier is a Notifier that sends the notification as an HTML email with Amazon SES.
+type SESNotifier struct {
+	SESSendEmailAPI SESSendEmailAPI
+	From            string
+	To              []string
+}
+
+type sesRow struct {
+	Key   string
+	Value string
+}
+
+// Name returns "ses".
+func (n *SESNotifier) Name() string {
+	return "ses"
+}
+
+// Notify sends the email.
+func (n *SESNotifier) Notify(ctx context.Context, notification *Notification) error {
+	event := notification.Event
+	html, err := renderSESHTML(notification)
+	if err != nil {
+		return err
+	}
+	subject := notification.Subject
+	if subject == "" {
+		subject = fmt.Sprintf("intdash %s %s: %s", event.ResourceType, event.Action, event.MeasurementUUID)
+	}
+
+	out, err := n.SESSendEmailAPI.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(n.From),
+		Destination:      &types.Destination{ToAddresses: n.To},
+		Content: &types.EmailContent{
+			Simple: &types.Message{
+				Subject: &types.Content{Data: aws.String(subject), Charset: aws.String("UTF-8")},
+				Body: &types.Body{
+					Html: &types.Content{Data: aws.String(html), Charset: aws.String("UTF-8")},
+					Text: &types.Content{Data: aws.String(notification.Body), Charset: aws.String("UTF-8")},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	slog.InfoContext(ctx, "Sent SES email", "message_id", aws.ToString(out.MessageId))
+	return nil
+}
+
+// renderSESHTML renders the HTML body of the email.
+func renderSESHTML(notification *Notification) (string, error) {
+	var rows []sesRow
+	for _, line := range strings.Split(strings.TrimSpace(notification.Body), "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) == 2 {
+			rows = append(rows, sesRow{Key: strings.TrimSpace(kv[0]), Value: strings.TrimSpace(kv[1])})
+		} else if line != "" {
+			rows = append(rows, sesRow{Value: line})
+		}
+	}
+
+	var buf bytes.Buffer
+	err := sesHTMLTemplate.Execute(&buf, struct {
+		Event *webhook.Body
+		Rows  []sesRow
+	}{notification.Event, rows})
+	if err != nil {
+		return "", fmt.Errorf("render HTML email: %w", err)
+	}
+	return buf.String(), nil
+}
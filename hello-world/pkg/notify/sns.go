@@ -0,0 +1,269 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+const (
+	// DefaultSNSMaxAttempts is the default maximum number of attempts to publish a notification.
+	DefaultSNSMaxAttempts = 3
+	// DefaultSNSRetryBaseDelay is the default delay before the first retry. It doubles on each retry.
+	DefaultSNSRetryBaseDelay = 200 * time.Millisecond
+	// SNSMaxMessageBytes is the maximum size of an SNS message.
+	SNSMaxMessageBytes = 256 * 1024
+)
+
+type SNSPublishAPI interface {
+	Publish(ctx context.Context, input *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SNSGetTopicAttributesAPI is implemented by the SNS clients that can check the access to the topic.
+type SNSGetTopicAttributesAPI interface {
+	GetTopicAttributes(ctx context.Context, input *sns.GetTopicAttributesInput, optFns ...func(*sns.Options)) (*sns.GetTopicAttributesOutput, error)
+}
+
+// SNSNotifier is a Notifier that publishes the notification to an SNS topic.
+// For a FIFO topic (ARN ending with ".fifo"), the message group ID is MessageGroupID,
+// or the measurement UUID if it is empty, and the deduplication ID is the delivery ID,
+// so that a retried delivery is not published twice.
+type SNSNotifier struct {
+	SNSPublishAPI  SNSPublishAPI
+	TopicArn       string
+	MessageGroupID string
+	// MaxAttempts is the maximum number of attempts to publish. Defaults to DefaultSNSMaxAttempts.
+	MaxAttempts int
+	// RetryBaseDelay is the delay before the first retry, doubled on each retry with jitter.
+	// Defaults to DefaultSNSRetryBaseDelay.
+	RetryBaseDelay time.Duration
+	// Undelivered, if set, persists the notification that could not be published after all the attempts,
+	// and the notification is then treated as delivered.
+	Undelivered UndeliveredStore
+	// PayloadBucket, if set, is the S3 bucket to store the body of a notification larger than SNSMaxMessageBytes.
+	// The published message is then the truncated body with the location of the full body.
+	// Otherwise, the body is just truncated.
+	PayloadBucket  string
+	S3PutObjectAPI S3PutObjectAPI
+}
+
+// Name returns "sns".
+func (n *SNSNotifier) Name() string {
+	return "sns"
+}
+
+// Check checks that the topic exists and is accessible, by getting its attributes.
+// It requires the sns:GetTopicAttributes permission.
+func (n *SNSNotifier) Check(ctx context.Context) error {
+	api, ok := n.SNSPublishAPI.(SNSGetTopicAttributesAPI)
+	if !ok {
+		return fmt.Errorf("SNS client cannot get topic attributes")
+	}
+	if _, err := api.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: aws.String(n.TopicArn)}); err != nil {
+		return fmt.Errorf("get attributes of topic %s: %w", n.TopicArn, err)
+	}
+	return nil
+}
+
+// Notify publishes the notification body to the topic, retrying with exponential backoff on failure.
+// The message has the attributes resource_type, action, measurement_uuid, project (if known), severity
+// and partial (if any result is partial), so that subscribers can filter the messages with filter policies.
+func (n *SNSNotifier) Notify(ctx context.Context, notification *Notification) error {
+	attributes := snsMessageAttributes(notification)
+	message, err := n.message(ctx, notification, SNSMaxMessageBytes-snsMessageAttributesSize(attributes))
+	if err != nil {
+		return err
+	}
+	input := &sns.PublishInput{
+		TopicArn:          aws.String(n.TopicArn),
+		Message:           aws.String(message),
+		MessageAttributes: attributes,
+	}
+	if notification.Subject != "" {
+		input.Subject = aws.String(snsSubject(notification.Subject))
+	}
+	if strings.HasSuffix(n.TopicArn, ".fifo") {
+		groupID := n.MessageGroupID
+		if groupID == "" {
+			groupID = notification.Event.MeasurementUUID
+		}
+		input.MessageGroupId = aws.String(groupID)
+		input.MessageDeduplicationId = aws.String(snsDeduplicationID(notification))
+	}
+	out, err := n.publish(ctx, input)
+	if err == nil {
+		slog.InfoContext(ctx, "Published SNS", "message_id", aws.ToString(out.MessageId))
+		return nil
+	}
+	if n.Undelivered == nil || ctx.Err() != nil {
+		return err
+	}
+
+	slog.ErrorContext(ctx, "Failed to publish SNS, saving the message for redelivery", "error", err)
+	if saveErr := n.Undelivered.SaveUndelivered(ctx, &UndeliveredMessage{
+		Notifier:        n.Name(),
+		Destination:     n.TopicArn,
+		MeasurementUUID: notification.Event.MeasurementUUID,
+		Message:         message,
+		Error:           err.Error(),
+		FailedAt:        time.Now().UTC(),
+	}); saveErr != nil {
+		return fmt.Errorf("%v; save undelivered message: %w", err, saveErr)
+	}
+	return nil
+}
+
+// publish publishes the input at most n.MaxAttempts times until it succeeds.
+func (n *SNSNotifier) publish(ctx context.Context, input *sns.PublishInput) (*sns.PublishOutput, error) {
+	maxAttempts := n.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultSNSMaxAttempts
+	}
+	delay := n.RetryBaseDelay
+	if delay <= 0 {
+		delay = DefaultSNSRetryBaseDelay
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var out *sns.PublishOutput
+		out, err = n.SNSPublishAPI.Publish(ctx, input)
+		if err == nil {
+			return out, nil
+		}
+		if attempt >= maxAttempts {
+			break
+		}
+		// Full jitter, so that concurrent invocations do not retry at once.
+		wait := time.Duration(rand.Int63n(int64(delay))) + 1
+		slog.WarnContext(ctx, "Failed to publish SNS, retrying", "attempt", attempt, "max_attempts", maxAttempts, "wait", wait, "error", err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("publish SNS: %w", ctx.Err())
+		}
+		delay *= 2
+	}
+	return nil, fmt.Errorf("publish SNS after %d attempts: %w", maxAttempts, err)
+}
+
+// message returns the message to publish for the notification, which fits in maxBytes.
+// A larger body is truncated, and stored to n.PayloadBucket if set (the claim-check pattern).
+func (n *SNSNotifier) message(ctx context.Context, notification *Notification, maxBytes int) (string, error) {
+	body := notification.Body
+	if len(body) <= maxBytes {
+		return body, nil
+	}
+	if n.PayloadBucket == "" {
+		slog.InfoContext(ctx, "Truncated SNS message", "bytes", len(body))
+		return truncateMessage(body, maxBytes, fmt.Sprintf("Truncated: %d bytes omitted\n", len(body))), nil
+	}
+
+	key := fmt.Sprintf("notifications/%s/%s.txt", notification.Event.MeasurementUUID, time.Now().UTC().Format("20060102T150405.000000000Z"))
+	_, err := n.S3PutObjectAPI.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(n.PayloadBucket),
+		Key:         aws.String(key),
+		Body:        strings.NewReader(body),
+		ContentType: aws.String("text/plain; charset=utf-8"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("put SNS payload: %w", err)
+	}
+	slog.InfoContext(ctx, "Stored SNS message", "bytes", len(body), "location", fmt.Sprintf("s3://%s/%s", n.PayloadBucket, key))
+	return truncateMessage(body, maxBytes, fmt.Sprintf("Full Notification: s3://%s/%s\n", n.PayloadBucket, key)), nil
+}
+
+// snsDeduplicationID returns the deduplication ID of the notification for a FIFO topic.
+// It is the delivery ID if it is valid as a deduplication ID, or its SHA-256 hash otherwise.
+// Without the delivery ID, it is derived from the content.
+func snsDeduplicationID(notification *Notification) string {
+	id := notification.DeliveryID
+	if id == "" {
+		id = notification.Event.MeasurementUUID + "\n" + notification.Body
+	} else if len(id) <= 128 && isPrintableASCII(id) {
+		return id
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// isPrintableASCII reports whether s consists of printable ASCII characters without spaces.
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] <= ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// snsSubject returns the subject usable for SNS, which is a line of less than 100 characters.
+func snsSubject(s string) string {
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		s = s[:i]
+	}
+	if r := []rune(s); len(r) > 99 {
+		s = string(r[:99])
+	}
+	return s
+}
+
+// snsMessageAttributes returns the message attributes of the notification.
+func snsMessageAttributes(notification *Notification) map[string]types.MessageAttributeValue {
+	attributes := map[string]types.MessageAttributeValue{}
+	set := func(name, value string) {
+		// SNS rejects empty attribute values.
+		if value != "" {
+			attributes[name] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+		}
+	}
+	set("resource_type", notification.Event.ResourceType)
+	set("action", notification.Event.Action)
+	set("measurement_uuid", notification.Event.MeasurementUUID)
+	set("project", notification.Event.ProjectUUID)
+	set("severity", notification.Severity())
+	if notification.Partial {
+		set("partial", "true")
+	}
+	return attributes
+}
+
+// snsMessageAttributesSize returns the size of the attributes counted toward SNSMaxMessageBytes.
+func snsMessageAttributesSize(attributes map[string]types.MessageAttributeValue) int {
+	size := 0
+	for name, v := range attributes {
+		size += len(name) + len(aws.ToString(v.DataType)) + len(aws.ToString(v.StringValue))
+	}
+	return size
+}
+
+// truncateMessage truncates s to at most maxBytes bytes including the marker appended to it.
+// s is cut at the last line break that fits, or at a rune boundary if there is none.
+func truncateMessage(s string, maxBytes int, marker string) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	limit := maxBytes - len(marker)
+	if limit <= 0 {
+		return marker
+	}
+	cut := strings.LastIndexByte(s[:limit], '\n') + 1
+	if cut == 0 {
+		cut = limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+	}
+	return s[:cut] + marker
+}
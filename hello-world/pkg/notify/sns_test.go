@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// fakeSNSPublishAPI records the published inputs, and fails the first failures attempts.
+type fakeSNSPublishAPI struct {
+	failures int
+	inputs   []*sns.PublishInput
+}
+
+func (f *fakeSNSPublishAPI) Publish(ctx context.Context, input *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	f.inputs = append(f.inputs, input)
+	if len(f.inputs) <= f.failures {
+		return nil, errors.New("throttled")
+	}
+	return &sns.PublishOutput{MessageId: aws.String("message-1")}, nil
+}
+
+// fakeS3PutObjectAPI records the bodies of the put objects.
+type fakeS3PutObjectAPI struct {
+	bodies map[string]string
+}
+
+func (f *fakeS3PutObjectAPI) PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	b, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	if f.bodies == nil {
+		f.bodies = map[string]string{}
+	}
+	f.bodies[aws.ToString(input.Bucket)+"/"+aws.ToString(input.Key)] = string(b)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestSNSNotifierNotify(t *testing.T) {
+	large := strings.Repeat("Average: 1.000000\n", SNSMaxMessageBytes/18+1)
+	for _, tt := range []struct {
+		name          string
+		topicArn      string
+		body          string
+		bucket        bool
+		failures      int
+		wantAttempts  int
+		wantMarker    string
+		wantGroupID   string
+		wantDedupID   string
+		wantStoredKey string
+	}{
+		{
+			name:         "small",
+			topicArn:     "arn:aws:sns:ap-northeast-1:123456789012:topic",
+			body:         "Average: 1.000000\n",
+			wantAttempts: 1,
+		},
+		{
+			name:         "retried",
+			topicArn:     "arn:aws:sns:ap-northeast-1:123456789012:topic",
+			body:         "Average: 1.000000\n",
+			failures:     2,
+			wantAttempts: 3,
+		},
+		{
+			name:         "fifo",
+			topicArn:     "arn:aws:sns:ap-northeast-1:123456789012:topic.fifo",
+			body:         "Average: 1.000000\n",
+			wantAttempts: 1,
+			wantGroupID:  "00000000-0000-0000-0000-000000000000",
+			wantDedupID:  "delivery-1",
+		},
+		{
+			name:         "truncated",
+			topicArn:     "arn:aws:sns:ap-northeast-1:123456789012:topic",
+			body:         large,
+			wantAttempts: 1,
+			wantMarker:   "Truncated: ",
+		},
+		{
+			name:          "claim check",
+			topicArn:      "arn:aws:sns:ap-northeast-1:123456789012:topic",
+			body:          large,
+			bucket:        true,
+			wantAttempts:  1,
+			wantMarker:    "Full Notification: s3://payloads/notifications/00000000-0000-0000-0000-000000000000/",
+			wantStoredKey: "payloads/notifications/00000000-0000-0000-0000-000000000000/",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeSNSPublishAPI{failures: tt.failures}
+			s3API := &fakeS3PutObjectAPI{}
+			n := &SNSNotifier{SNSPublishAPI: api, TopicArn: tt.topicArn, RetryBaseDelay: time.Millisecond}
+			if tt.bucket {
+				n.PayloadBucket, n.S3PutObjectAPI = "payloads", s3API
+			}
+			notification := testNotification()
+			notification.Body = tt.body
+			if err := n.Notify(context.Background(), notification); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+
+			if len(api.inputs) != tt.wantAttempts {
+				t.Fatalf("published %d times, want %d", len(api.inputs), tt.wantAttempts)
+			}
+			input := api.inputs[len(api.inputs)-1]
+			message := aws.ToString(input.Message)
+			if size := len(message) + snsMessageAttributesSize(input.MessageAttributes); size > SNSMaxMessageBytes {
+				t.Errorf("message is %d bytes with the attributes, more than %d", size, SNSMaxMessageBytes)
+			}
+			if tt.wantMarker == "" && message != tt.body {
+				t.Errorf("message = %q, want the body", message)
+			}
+			if tt.wantMarker != "" && !strings.Contains(message, "\n"+tt.wantMarker) {
+				t.Errorf("message does not end with %q: %q", tt.wantMarker, message[len(message)-200:])
+			}
+			if got := aws.ToString(input.MessageGroupId); got != tt.wantGroupID {
+				t.Errorf("message group ID = %q, want %q", got, tt.wantGroupID)
+			}
+			if got := aws.ToString(input.MessageDeduplicationId); got != tt.wantDedupID {
+				t.Errorf("deduplication ID = %q, want %q", got, tt.wantDedupID)
+			}
+			for key, body := range s3API.bodies {
+				if !strings.HasPrefix(key, tt.wantStoredKey) || body != tt.body {
+					t.Errorf("stored %s of %d bytes, want %s... of the body", key, len(body), tt.wantStoredKey)
+				}
+			}
+			if tt.wantStoredKey != "" && len(s3API.bodies) != 1 {
+				t.Errorf("stored %d payloads, want 1", len(s3API.bodies))
+			}
+		})
+	}
+}
+
+func TestSNSNotifierNotifyFailed(t *testing.T) {
+	api := &fakeSNSPublishAPI{failures: DefaultSNSMaxAttempts}
+	n := &SNSNotifier{SNSPublishAPI: api, TopicArn: "arn:aws:sns:ap-northeast-1:123456789012:topic", RetryBaseDelay: time.Millisecond}
+	if err := n.Notify(context.Background(), testNotification()); err == nil {
+		t.Errorf("Notify() succeeded after %d failed attempts", len(api.inputs))
+	}
+	if len(api.inputs) != DefaultSNSMaxAttempts {
+		t.Errorf("published %d times, want %d", len(api.inputs), DefaultSNSMaxAttempts)
+	}
+}
+
+func TestTruncateMessage(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		s        string
+		maxBytes int
+		want     string
+	}{
+		{name: "fits", s: "line 1\nline 2\n", maxBytes: 14, want: "line 1\nline 2\n"},
+		{name: "at line break", s: "line 1\nline 2\nline 3\n", maxBytes: 18, want: "line 1\n[cut]\n"},
+		{name: "at rune boundary", s: "速度速度速度", maxBytes: 13, want: "速度[cut]\n"},
+		{name: "marker only", s: "line 1\nline 2\n", maxBytes: 4, want: "[cut]\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateMessage(tt.s, tt.maxBytes, "[cut]\n")
+			if got != tt.want {
+				t.Errorf("truncateMessage() = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateMessage() = %q is not valid UTF-8", got)
+			}
+		})
+	}
+}
+
+func TestSNSDeduplicationID(t *testing.T) {
+	hash := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	long := strings.Repeat("x", 129)
+	for _, tt := range []struct {
+		name       string
+		deliveryID string
+		want       string
+	}{
+		{name: "delivery ID", deliveryID: "delivery-1", want: "delivery-1"},
+		{name: "with space", deliveryID: "delivery 1", want: hash("delivery 1")},
+		{name: "too long", deliveryID: long, want: hash(long)},
+		{name: "no delivery ID", want: hash("00000000-0000-0000-0000-000000000000\nAverage: 1.000000\n")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := testNotification()
+			notification.DeliveryID = tt.deliveryID
+			if got := snsDeduplicationID(notification); got != tt.want {
+				t.Errorf("snsDeduplicationID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+type SQSSendMessageAPI interface {
+	SendMessage(ctx context.Context, input *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+// SQSNotifier is a Notifier that sends the notification to an SQS queue.
+// For a FIFO queue (URL ending with ".fifo"), the message group ID is MessageGroupID,
+// or the measurement UUID if it is empty, and the deduplication ID is derived from the content.
+type SQSNotifier struct {
+	SQSSendMessageAPI SQSSendMessageAPI
+	QueueURL          string
+	MessageGroupID    string
+}
+
+// Name returns "sqs".
+func (n *SQSNotifier) Name() string {
+	return "sqs"
+}
+
+// Notify sends the notification body to the queue.
+func (n *SQSNotifier) Notify(ctx context.Context, notification *Notification) error {
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(n.QueueURL),
+		MessageBody: aws.String(notification.Body),
+	}
+	if strings.HasSuffix(n.QueueURL, ".fifo") {
+		groupID := n.MessageGroupID
+		if groupID == "" {
+			groupID = notification.Event.MeasurementUUID
+		}
+		sum := sha256.Sum256([]byte(notification.Event.MeasurementUUID + "\n" + notification.Body))
+		input.MessageGroupId = aws.String(groupID)
+		input.MessageDeduplicationId = aws.String(hex.EncodeToString(sum[:]))
+	}
+
+	out, err := n.SQSSendMessageAPI.SendMessage(ctx, input)
+	if err != nil {
+		return fmt.Errorf("send SQS message: %w", err)
+	}
+	slog.InfoContext(ctx, "Sent SQS message", "message_id", aws.ToString(out.MessageId))
+	return nil
+}
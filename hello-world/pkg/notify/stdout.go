@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// StdoutNotifier is a Notifier that prints the notification, for local development.
+type StdoutNotifier struct {
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+// Name returns "stdout".
+func (n *StdoutNotifier) Name() string {
+	return "stdout"
+}
+
+// Notify prints the notification with a header line of the severity, the event and the measurement UUID.
+func (n *StdoutNotifier) Notify(ctx context.Context, notification *Notification) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s: %s %s %s ---\n", notification.Severity(),
+		notification.Event.ResourceType, notification.Event.Action, notification.Event.MeasurementUUID)
+	if notification.Subject != "" {
+		fmt.Fprintf(&b, "Subject: %s\n", notification.Subject)
+	}
+	b.WriteString(notification.Body)
+	if !strings.HasSuffix(notification.Body, "\n") {
+		b.WriteString("\n")
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, err := io.WriteString(n.Writer, b.String()); err != nil {
+		return fmt.Errorf("print notification: %w", err)
+	}
+	return nil
+}
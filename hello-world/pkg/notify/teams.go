@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// TeamsNotifier is a Notifier that posts the notification as an Adaptive Card
+// to a Microsoft Teams incoming webhook.
+type TeamsNotifier struct {
+	WebhookURL string
+	HTTPClient *http.Client
+}
+
+type (
+	teamsMessage struct {
+		Type        string            `json:"type"`
+		Attachments []teamsAttachment `json:"attachments"`
+	}
+
+	teamsAttachment struct {
+		ContentType string            `json:"contentType"`
+		Content     teamsAdaptiveCard `json:"content"`
+	}
+
+	teamsAdaptiveCard struct {
+		Schema  string             `json:"$schema"`
+		Type    string             `json:"type"`
+		Version string             `json:"version"`
+		Body    []teamsCardElement `json:"body"`
+	}
+
+	teamsCardElement struct {
+		Type   string      `json:"type"`
+		Text   string      `json:"text,omitempty"`
+		Weight string      `json:"weight,omitempty"`
+		Size   string      `json:"size,omitempty"`
+		Wrap   bool        `json:"wrap,omitempty"`
+		Facts  []teamsFact `json:"facts,omitempty"`
+	}
+
+	teamsFact struct {
+		Title string `json:"title"`
+		Value string `json:"value"`
+	}
+)
+
+// Name returns "teams".
+func (n *TeamsNotifier) Name() string {
+	return "teams"
+}
+
+// Notify posts the notification card.
+func (n *TeamsNotifier) Notify(ctx context.Context, notification *Notification) error {
+	b, err := json.Marshal(n.makeMessage(notification))
+	if err != nil {
+		return fmt.Errorf("marshal Teams message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.WebhookURL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := n.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post Teams message: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d from Teams: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+func (n *TeamsNotifier) makeMessage(notification *Notification) *teamsMessage {
+	event := notification.Event
+	title := notification.Subject
+	if title == "" {
+		title = fmt.Sprintf("intdash %s %s", event.ResourceType, event.Action)
+	}
+	return &teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: teamsAdaptiveCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body: []teamsCardElement{
+					{
+						Type:   "TextBlock",
+						Text:   title,
+						Weight: "Bolder",
+						Size:   "Medium",
+					},
+					{
+						Type: "FactSet",
+						Facts: []teamsFact{
+							{Title: "Measurement", Value: event.MeasurementUUID},
+						},
+					},
+					{
+						Type: "TextBlock",
+						// Adaptive Cards need a blank line to break lines in a TextBlock.
+						Text: strings.ReplaceAll(strings.TrimSpace(notification.Body), "\n", "\n\n"),
+						Wrap: true,
+					},
+				},
+			},
+		}},
+	}
+}
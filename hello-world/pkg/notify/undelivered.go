@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+type (
+	// UndeliveredStore persists the messages that could not be delivered, for later redelivery.
+	UndeliveredStore interface {
+		SaveUndelivered(ctx context.Context, message *UndeliveredMessage) error
+	}
+
+	S3PutObjectAPI interface {
+		PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	}
+)
+
+// UndeliveredMessage is a message that could not be delivered to its destination.
+type UndeliveredMessage struct {
+	// Notifier is the name of the notifier, e.g. "sns".
+	Notifier string `json:"notifier"`
+	// Destination is the destination of the message, e.g. the topic ARN.
+	Destination     string    `json:"destination"`
+	MeasurementUUID string    `json:"measurement_uuid"`
+	Message         string    `json:"message"`
+	Error           string    `json:"error"`
+	FailedAt        time.Time `json:"failed_at"`
+}
+
+// S3UndeliveredStore is an UndeliveredStore that puts the messages as JSON objects to an S3 bucket,
+// with the key "undelivered/<notifier>/<measurement_uuid>/<failed_at>.json".
+type S3UndeliveredStore struct {
+	S3PutObjectAPI S3PutObjectAPI
+	Bucket         string
+}
+
+// SaveUndelivered puts the message to the bucket.
+func (s *S3UndeliveredStore) SaveUndelivered(ctx context.Context, message *UndeliveredMessage) error {
+	b, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("marshal undelivered message: %w", err)
+	}
+	key := fmt.Sprintf("undelivered/%s/%s/%s.json", message.Notifier, message.MeasurementUUID, message.FailedAt.UTC().Format("20060102T150405.000000000Z"))
+	_, err = s.S3PutObjectAPI.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(b),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("put undelivered message: %w", err)
+	}
+	slog.InfoContext(ctx, "Saved undelivered message", "location", fmt.Sprintf("s3://%s/%s", s.Bucket, key))
+	return nil
+}
+
+// SQSUndeliveredStore is an UndeliveredStore that sends the messages as JSON to an SQS queue.
+type SQSUndeliveredStore struct {
+	SQSSendMessageAPI SQSSendMessageAPI
+	QueueURL          string
+}
+
+// SaveUndelivered sends the message to the queue.
+func (s *SQSUndeliveredStore) SaveUndelivered(ctx context.Context, message *UndeliveredMessage) error {
+	b, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("marshal undelivered message: %w", err)
+	}
+	out, err := s.SQSSendMessageAPI.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(s.QueueURL),
+		MessageBody: aws.String(string(b)),
+	})
+	if err != nil {
+		return fmt.Errorf("send undelivered message: %w", err)
+	}
+	slog.InfoContext(ctx, "Saved undelivered message to SQS", "message_id", aws.ToString(out.MessageId))
+	return nil
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"hello-world/pkg/webhook"
+)
+
+// WebhookNotifier is a Notifier that posts the notification as JSON (NotificationPayload) to an arbitrary URL.
+// If Secret is set, the body is signed in the same way intdash signs its webhooks:
+// the base64-encoded HMAC of the body in the header of the algorithm, e.g. webhook.SignatureHeader.
+type WebhookNotifier struct {
+	URL    string
+	Secret []byte
+	// Algorithm is the HMAC algorithm of the signature. Defaults to webhook.SHA256.
+	Algorithm  *webhook.Algorithm
+	HTTPClient *http.Client
+}
+
+// Name returns "webhook".
+func (n *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Notify posts the notification payload.
+func (n *WebhookNotifier) Notify(ctx context.Context, notification *Notification) error {
+	b, err := json.Marshal(notification.Payload())
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.Secret) > 0 {
+		algorithm := n.Algorithm
+		if algorithm == nil {
+			algorithm = webhook.SHA256
+		}
+		req.Header.Set(algorithm.Header(), base64.StdEncoding.EncodeToString(algorithm.Sign(n.Secret, b)))
+	}
+
+	httpClient := n.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d from webhook: %s", resp.StatusCode, body)
+	}
+	return nil
+}
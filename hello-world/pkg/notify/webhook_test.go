@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hello-world/pkg/webhook"
+)
+
+func TestWebhookNotifierSignature(t *testing.T) {
+	secret := []byte("secret")
+	for _, tt := range []struct {
+		name      string
+		algorithm *webhook.Algorithm
+		want      *webhook.Algorithm
+	}{
+		{name: "default", want: webhook.SHA256},
+		{name: "sha256", algorithm: webhook.SHA256, want: webhook.SHA256},
+		{name: "sha512", algorithm: webhook.SHA512, want: webhook.SHA512},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				header http.Header
+				body   []byte
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			n := &WebhookNotifier{URL: server.URL, Secret: secret, Algorithm: tt.algorithm}
+			if err := n.Notify(context.Background(), testNotification()); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			signature := header.Get(tt.want.Header())
+			if err := tt.want.ValidateSignature(context.Background(), signature, body, webhook.StaticKeys{secret}); err != nil {
+				t.Errorf("ValidateSignature(%q) error = %v", signature, err)
+			}
+		})
+	}
+}
+
+// testNotification returns a notification of a finished measurement.
+func testNotification() *Notification {
+	return &Notification{
+		Event: &webhook.Body{
+			ResourceType:    webhook.ResourceTypeMeasurement,
+			Action:          webhook.ActionFinished,
+			MeasurementUUID: "00000000-0000-0000-0000-000000000000",
+		},
+		DeliveryID: "delivery-1",
+		Body:       "Average: 1.000000\n",
+	}
+}
@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// IsBatch reports whether the body is a batch of events, i.e. a JSON array, rather than a single event.
+func IsBatch(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '['
+}
+
+// SplitBatch splits the JSON array of events into the raw events, to be decoded one by one with DecodeVersionedBody.
+// An empty batch is an error.
+func SplitBatch(data []byte) ([]json.RawMessage, error) {
+	var events []json.RawMessage
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("unmarshal batch: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("batch is empty")
+	}
+	return events, nil
+}
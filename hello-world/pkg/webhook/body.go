@@ -0,0 +1,12 @@
+package webhook
+
+// Body is the body of the webhook request.
+type Body struct {
+	ResourceType    string `json:"resource_type"`
+	Action          string `json:"action"`
+	MeasurementUUID string `json:"measurement_uuid"`
+	// ProjectUUID is the UUID of the project of the measurement. It is empty for intdash servers without projects.
+	ProjectUUID string `json:"project_uuid,omitempty"`
+	// Challenge is the value to be echoed in the response to a ping, if any.
+	Challenge string `json:"challenge,omitempty"`
+}
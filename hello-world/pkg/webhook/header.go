@@ -0,0 +1,30 @@
+package webhook
+
+import (
+	"net/http"
+	"strings"
+)
+
+// LookupHeader returns the first value of the given header, matching the name case-insensitively.
+// Both single-value headers and multi-value headers are looked up, because
+// API Gateway fills one or both of them depending on the integration type.
+func LookupHeader(headers map[string]string, multiValueHeaders map[string][]string, name string) string {
+	if v, ok := headers[name]; ok {
+		return v
+	}
+	canonical := http.CanonicalHeaderKey(name)
+	if v, ok := headers[canonical]; ok {
+		return v
+	}
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	for k, vs := range multiValueHeaders {
+		if strings.EqualFold(k, name) && len(vs) > 0 {
+			return vs[0]
+		}
+	}
+	return ""
+}
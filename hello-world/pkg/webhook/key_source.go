@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	"hello-world/pkg/cache"
+)
+
+const (
+	// DefaultSecretRefreshInterval is the default interval to refresh a secret loaded from Secrets Manager.
+	DefaultSecretRefreshInterval = 5 * time.Minute
+)
+
+type (
+	// KeySource provides the HMAC keys used to validate webhook signatures.
+	KeySource interface {
+		Keys(ctx context.Context) ([][]byte, error)
+	}
+
+	// StaticKeys is a KeySource of fixed keys.
+	StaticKeys [][]byte
+
+	SecretsManagerAPI interface {
+		GetSecretValue(ctx context.Context, input *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+	}
+)
+
+// Keys returns the keys.
+func (k StaticKeys) Keys(ctx context.Context) ([][]byte, error) {
+	return k, nil
+}
+
+// SecretsManagerKeySource is a KeySource that loads the HMAC keys from AWS Secrets Manager.
+// The secret value is parsed with ParseSecretKeys.
+// The keys are cached and re-fetched on access once RefreshInterval has elapsed,
+// so a rotated secret is picked up without redeploying.
+// It is created with NewSecretsManagerKeySource.
+type SecretsManagerKeySource struct {
+	SecretsManagerAPI SecretsManagerAPI
+	SecretID          string
+	RefreshInterval   time.Duration
+
+	// mu serializes the refreshes, so that concurrent deliveries fetch the secret once.
+	mu    sync.Mutex
+	cache cache.Cache[string, [][]byte]
+}
+
+// NewSecretsManagerKeySource returns a SecretsManagerKeySource of the secret.
+// If refreshInterval is zero, DefaultSecretRefreshInterval is used.
+func NewSecretsManagerKeySource(api SecretsManagerAPI, secretID string, refreshInterval time.Duration) *SecretsManagerKeySource {
+	s := &SecretsManagerKeySource{
+		SecretsManagerAPI: api,
+		SecretID:          secretID,
+		RefreshInterval:   refreshInterval,
+	}
+	// The keys are kept after they expire, to fall back to them if a refresh fails.
+	s.cache.KeepStale = true
+	return s
+}
+
+// Keys returns the cached keys, refreshing them if the refresh interval has elapsed.
+// If a refresh fails, the previously cached keys are kept and returned.
+func (s *SecretsManagerKeySource) Keys(ctx context.Context) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if keys, ok := s.cache.Get(s.SecretID); ok {
+		return keys, nil
+	}
+
+	secret, err := s.fetch(ctx)
+	if err != nil {
+		if keys, ok := s.cache.GetStale(s.SecretID); ok {
+			slog.ErrorContext(ctx, "Failed to refresh webhook secret, using cached one", "error", err)
+			return keys, nil
+		}
+		return nil, err
+	}
+	keys := ParseSecretKeys(secret)
+	s.cache.SetWithTTL(s.SecretID, keys, s.refreshInterval())
+	return keys, nil
+}
+
+// Invalidate removes the cached keys, so that the secret is fetched on the next access,
+// e.g. right after it is rotated.
+func (s *SecretsManagerKeySource) Invalidate() {
+	s.cache.Invalidate(s.SecretID)
+}
+
+func (s *SecretsManagerKeySource) fetch(ctx context.Context) ([]byte, error) {
+	out, err := s.SecretsManagerAPI.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(s.SecretID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get secret value %q: %w", s.SecretID, err)
+	}
+	if out.SecretString != nil {
+		return []byte(*out.SecretString), nil
+	}
+	if out.SecretBinary != nil {
+		return out.SecretBinary, nil
+	}
+	return nil, fmt.Errorf("secret %q has no value", s.SecretID)
+}
+
+func (s *SecretsManagerKeySource) refreshInterval() time.Duration {
+	if s.RefreshInterval > 0 {
+		return s.RefreshInterval
+	}
+	return DefaultSecretRefreshInterval
+}
+
+// ParseSecretKeys parses a webhook secret into HMAC keys.
+// A JSON array of strings, e.g. `["new-secret", "old-secret"]`, is parsed into multiple keys
+// so the secret can be rotated without dropping deliveries. Any other value is used as a single key as-is.
+func ParseSecretKeys(secret []byte) [][]byte {
+	trimmed := bytes.TrimSpace(secret)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var list []string
+		if err := json.Unmarshal(trimmed, &list); err == nil && len(list) > 0 {
+			keys := make([][]byte, len(list))
+			for i, v := range list {
+				keys[i] = []byte(v)
+			}
+			return keys
+		}
+	}
+	return [][]byte{secret}
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// fakeSecretsManagerAPI returns the secret, or err if set.
+type fakeSecretsManagerAPI struct {
+	mu     sync.Mutex
+	secret string
+	err    error
+}
+
+func (f *fakeSecretsManagerAPI) GetSecretValue(ctx context.Context, input *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(f.secret)}, nil
+}
+
+func TestSecretsManagerKeySource(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		// refreshErr is the error of the refresh after the first fetch.
+		refreshErr error
+		want       [][]byte
+	}{
+		{name: "refreshed", want: [][]byte{[]byte("new-secret"), []byte("old-secret")}},
+		{name: "stale on refresh failure", refreshErr: errors.New("throttled"), want: [][]byte{[]byte("old-secret")}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeSecretsManagerAPI{secret: "old-secret"}
+			// The keys expire right away, so that every access refreshes them.
+			s := NewSecretsManagerKeySource(api, "webhook-secret", time.Nanosecond)
+			if _, err := s.Keys(context.Background()); err != nil {
+				t.Fatalf("Keys() error = %v", err)
+			}
+
+			api.secret, api.err = `["new-secret", "old-secret"]`, tt.refreshErr
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					keys, err := s.Keys(context.Background())
+					if err != nil {
+						t.Errorf("Keys() error = %v", err)
+					}
+					if !reflect.DeepEqual(keys, tt.want) {
+						t.Errorf("Keys() = %q, want %q", keys, tt.want)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestParseSecretKeys(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		secret string
+		want   []string
+	}{
+		{name: "single", secret: "secret", want: []string{"secret"}},
+		{name: "rotation", secret: ` ["new-secret", "old-secret"]` + "\n", want: []string{"new-secret", "old-secret"}},
+		{name: "empty array", secret: "[]", want: []string{"[]"}},
+		{name: "not an array of strings", secret: "[1, 2]", want: []string{"[1, 2]"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := ParseSecretKeys([]byte(tt.secret))
+			got := make([]string, len(keys))
+			for i, k := range keys {
+				got[i] = string(k)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ParseSecretKeys(%q) = %q, want %q", tt.secret, got, tt.want)
+			}
+		})
+	}
+}
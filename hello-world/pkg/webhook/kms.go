@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+type KMSDecryptAPI interface {
+	Decrypt(ctx context.Context, input *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// DecryptKMSSecret decrypts the base64-encoded KMS ciphertext of a webhook secret, e.g. an environment variable
+// encrypted with the Lambda console helper, and parses it into HMAC keys with ParseSecretKeys.
+// encryptionContext must be the one the secret was encrypted with, if any.
+func DecryptKMSSecret(ctx context.Context, api KMSDecryptAPI, ciphertext string, encryptionContext map[string]string) ([][]byte, error) {
+	blob, err := base64.StdEncoding.DecodeString(strings.TrimSpace(ciphertext))
+	if err != nil {
+		return nil, fmt.Errorf("decode ciphertext: %w", err)
+	}
+	out, err := api.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob:    blob,
+		EncryptionContext: encryptionContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decrypt secret: %w", err)
+	}
+	if len(out.Plaintext) == 0 {
+		return nil, fmt.Errorf("decrypted secret is empty")
+	}
+	return ParseSecretKeys(out.Plaintext), nil
+}
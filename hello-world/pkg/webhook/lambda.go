@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ValidateAPIGatewayProxyRequest validates the signature of a webhook delivered through an API Gateway REST API
+// with the Lambda proxy integration.
+func ValidateAPIGatewayProxyRequest(ctx context.Context, source KeySource, request events.APIGatewayProxyRequest) error {
+	return validateEvent(ctx, source, request.Headers, request.MultiValueHeaders, request.Body, request.IsBase64Encoded)
+}
+
+// ValidateAPIGatewayV2HTTPRequest validates the signature of a webhook delivered through an API Gateway HTTP API.
+func ValidateAPIGatewayV2HTTPRequest(ctx context.Context, source KeySource, request events.APIGatewayV2HTTPRequest) error {
+	return validateEvent(ctx, source, request.Headers, nil, request.Body, request.IsBase64Encoded)
+}
+
+// ValidateLambdaFunctionURLRequest validates the signature of a webhook delivered through a Lambda function URL.
+func ValidateLambdaFunctionURLRequest(ctx context.Context, source KeySource, request events.LambdaFunctionURLRequest) error {
+	return validateEvent(ctx, source, request.Headers, nil, request.Body, request.IsBase64Encoded)
+}
+
+// ValidateALBTargetGroupRequest validates the signature of a webhook delivered through an Application Load Balancer.
+func ValidateALBTargetGroupRequest(ctx context.Context, source KeySource, request events.ALBTargetGroupRequest) error {
+	return validateEvent(ctx, source, request.Headers, request.MultiValueHeaders, request.Body, request.IsBase64Encoded)
+}
+
+// validateEvent validates the signature of the body of a Lambda event, decoding the body if it is base64-encoded.
+// The signature header is looked up case-insensitively in both the single-value and the multi-value headers.
+func validateEvent(ctx context.Context, source KeySource, headers map[string]string, multiValueHeaders map[string][]string, body string, isBase64Encoded bool) error {
+	b := []byte(body)
+	if isBase64Encoded {
+		var err error
+		if b, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return fmt.Errorf("decode base64 body: %w", err)
+		}
+	}
+	return ValidateSignature(ctx, LookupHeader(headers, multiValueHeaders, SignatureHeader), b, source)
+}
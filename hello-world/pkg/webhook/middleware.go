@@ -0,0 +1,29 @@
+package webhook
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// Middleware returns an http.Handler that validates the signature of each request with the HMAC keys of source
+// before passing it to next, with the body restored. A request with an invalid signature is rejected
+// with 401 Unauthorized and is not passed to next.
+func Middleware(source KeySource, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to read request body", "error", err)
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := ValidateSignature(r.Context(), r.Header.Get(SignatureHeader), body, source); err != nil {
+			slog.ErrorContext(r.Context(), "Got invalid signature", "error", err)
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,154 @@
+// Package webhook validates and decodes the webhook deliveries of intdash.
+//
+// Services receiving intdash webhooks can validate the signatures with Middleware for net/http,
+// or with the Validate functions of the Lambda events, e.g. ValidateAPIGatewayProxyRequest:
+//
+//	keys := webhook.StaticKeys(webhook.ParseSecretKeys(secret))
+//	http.Handle("/webhook", webhook.Middleware(keys, handler))
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+const (
+	// SignatureHeader is the name of the header that contains the signature of SHA256.
+	// The signature is a SHA256 hash of the request body and base64 encoded.
+	// The "sha256=<hex>" and the plain hex formats are also accepted.
+	SignatureHeader = "x-intdash-signature-256"
+)
+
+// SignaturePrefix is the prefix of the GitHub-style signatures of SHA256, e.g. "sha256=<hex>".
+const SignaturePrefix = "sha256="
+
+// Algorithm is a HMAC algorithm of the signatures.
+type Algorithm struct {
+	// Name is the name of the algorithm, e.g. "sha256", which is the prefix of the GitHub-style signatures.
+	Name string
+	// Bits is the size of the hash in bits, which is the suffix of the header name, e.g. 256.
+	Bits int
+	New  func() hash.Hash
+}
+
+// The supported algorithms.
+var (
+	SHA256 = &Algorithm{Name: "sha256", Bits: 256, New: sha256.New}
+	SHA512 = &Algorithm{Name: "sha512", Bits: 512, New: sha512.New}
+)
+
+// Algorithms are the supported algorithms by name.
+var Algorithms = map[string]*Algorithm{
+	SHA256.Name: SHA256,
+	SHA512.Name: SHA512,
+}
+
+// ParseAlgorithm returns the algorithm of the name, e.g. "sha512".
+func ParseAlgorithm(name string) (*Algorithm, error) {
+	a, ok := Algorithms[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown signature algorithm %q", name)
+	}
+	return a, nil
+}
+
+// Header returns the name of the header that contains the signature, e.g. "x-intdash-signature-512".
+func (a *Algorithm) Header() string {
+	return fmt.Sprintf("x-intdash-signature-%d", a.Bits)
+}
+
+// ValidateSignature validates the signature of the body with SHA256. See (*Algorithm).ValidateSignature.
+func ValidateSignature(ctx context.Context, signature string, body []byte, source KeySource) error {
+	return SHA256.ValidateSignature(ctx, signature, body, source)
+}
+
+// ValidateSignature validates the signature of the body with the HMAC keys of the source, tried in order.
+// The format of the signature is detected by DecodeSignature. The signatures are compared in constant time.
+func (a *Algorithm) ValidateSignature(ctx context.Context, signature string, body []byte, source KeySource) error {
+	if signature == "" {
+		return fmt.Errorf("signature header %q is empty", a.Header())
+	}
+	wantSum, err := a.DecodeSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	keys, err := source.Keys(ctx)
+	if err != nil {
+		return fmt.Errorf("get HMAC keys: %w", err)
+	}
+	for _, key := range keys {
+		if hmac.Equal(wantSum, a.Sign(key, body)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("signature mismatch with all %d keys, want %x", len(keys), wantSum)
+}
+
+// DecodeSignature decodes a HMAC-SHA256 signature. See (*Algorithm).DecodeSignature.
+func DecodeSignature(signature string) ([]byte, error) {
+	return SHA256.DecodeSignature(signature)
+}
+
+// DecodeSignature decodes a signature in any of the formats: base64 as intdash sends,
+// the GitHub-style "<name>=<hex>", e.g. "sha256=<hex>", or plain hex.
+// The base64 of a hash is never of the length of its hex.
+func (a *Algorithm) DecodeSignature(signature string) ([]byte, error) {
+	var (
+		sum []byte
+		err error
+	)
+	size := a.Bits / 8
+	switch hexSum, prefixed := cutPrefixFold(signature, a.Name+"="); {
+	case prefixed:
+		sum, err = hex.DecodeString(hexSum)
+	case len(signature) == hex.EncodedLen(size):
+		sum, err = hex.DecodeString(signature)
+	default:
+		sum, err = base64.StdEncoding.DecodeString(signature)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+	if len(sum) != size {
+		return nil, fmt.Errorf("signature is %d bytes, not %d", len(sum), size)
+	}
+	return sum, nil
+}
+
+// cutPrefixFold is like strings.CutPrefix, but matches the prefix case-insensitively.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+// Sign returns the HMAC-SHA256 of the body with the given key.
+func Sign(key, body []byte) []byte {
+	return SHA256.Sign(key, body)
+}
+
+// Sign returns the HMAC of the body with the given key.
+func (a *Algorithm) Sign(key, body []byte) []byte {
+	hasher := hmac.New(a.New, key)
+	hasher.Write(body) // never returns an error
+	return hasher.Sum(nil)
+}
+
+// TimestampedPayload returns the payload of a signature that covers the delivery timestamp, "<timestamp>.<body>",
+// so that the timestamp of a captured delivery cannot be changed without invalidating its signature.
+func TimestampedPayload(timestamp string, body []byte) []byte {
+	payload := make([]byte, 0, len(timestamp)+1+len(body))
+	payload = append(payload, timestamp...)
+	payload = append(payload, '.')
+	return append(payload, body...)
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestAlgorithmValidateSignature(t *testing.T) {
+	const body = `{"resource_type":"measurement","action":"finished","measurement_uuid":"00000000-0000-0000-0000-000000000000"}`
+	keys := StaticKeys{[]byte("new-secret"), []byte("old-secret")}
+
+	for _, a := range []*Algorithm{SHA256, SHA512} {
+		sum := a.Sign([]byte("old-secret"), []byte(body))
+		for _, tt := range []struct {
+			name      string
+			signature string
+			body      string
+			wantErr   bool
+		}{
+			{name: "base64", signature: base64.StdEncoding.EncodeToString(sum)},
+			{name: "prefixed hex", signature: a.Name + "=" + hex.EncodeToString(sum)},
+			{name: "uppercase prefix", signature: strings.ToUpper(a.Name) + "=" + hex.EncodeToString(sum)},
+			{name: "plain hex", signature: hex.EncodeToString(sum)},
+			{name: "empty", signature: "", wantErr: true},
+			{name: "not base64", signature: "not base64!", wantErr: true},
+			{name: "truncated", signature: base64.StdEncoding.EncodeToString(sum[:len(sum)-1]), wantErr: true},
+			{name: "tampered body", signature: base64.StdEncoding.EncodeToString(sum), body: body + " ", wantErr: true},
+			{name: "unknown key", signature: base64.StdEncoding.EncodeToString(a.Sign([]byte("other"), []byte(body))), wantErr: true},
+		} {
+			t.Run(a.Name+"/"+tt.name, func(t *testing.T) {
+				b := body
+				if tt.body != "" {
+					b = tt.body
+				}
+				err := a.ValidateSignature(context.Background(), tt.signature, []byte(b), keys)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("ValidateSignature(%q) error = %v, wantErr %t", tt.signature, err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestAlgorithmDecodeSignatureOfOtherAlgorithm(t *testing.T) {
+	// A signature of SHA256 is never accepted as one of SHA512, and vice versa.
+	for _, tt := range []struct {
+		algorithm *Algorithm
+		other     *Algorithm
+	}{
+		{algorithm: SHA256, other: SHA512},
+		{algorithm: SHA512, other: SHA256},
+	} {
+		t.Run(tt.algorithm.Name, func(t *testing.T) {
+			sum := tt.other.Sign([]byte("secret"), []byte("body"))
+			for _, signature := range []string{
+				base64.StdEncoding.EncodeToString(sum),
+				tt.other.Name + "=" + hex.EncodeToString(sum),
+				hex.EncodeToString(sum),
+			} {
+				if _, err := tt.algorithm.DecodeSignature(signature); err == nil {
+					t.Errorf("DecodeSignature(%q) accepted the signature of %s", signature, tt.other.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		want       *Algorithm
+		wantHeader string
+		wantErr    bool
+	}{
+		{name: "sha256", want: SHA256, wantHeader: SignatureHeader},
+		{name: "SHA512", want: SHA512, wantHeader: "x-intdash-signature-512"},
+		{name: "md5", wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAlgorithm(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAlgorithm() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAlgorithm() = %v, want %v", got, tt.want)
+			}
+			if got != nil && got.Header() != tt.wantHeader {
+				t.Errorf("Header() = %q, want %q", got.Header(), tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestTimestampedPayload(t *testing.T) {
+	const body = `{"resource_type":"measurement"}`
+	key := []byte("secret")
+	signature := base64.StdEncoding.EncodeToString(Sign(key, TimestampedPayload("1705320000", []byte(body))))
+
+	for _, tt := range []struct {
+		name      string
+		timestamp string
+		body      string
+		wantErr   bool
+	}{
+		{name: "signed timestamp", timestamp: "1705320000", body: body},
+		{name: "changed timestamp", timestamp: "1705323600", body: body, wantErr: true},
+		{name: "changed body", timestamp: "1705320000", body: body + " ", wantErr: true},
+		{name: "timestamp moved into body", timestamp: "170532000", body: "0." + body, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSignature(context.Background(), signature, TimestampedPayload(tt.timestamp, []byte(tt.body)), StaticKeys{key})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSignature() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
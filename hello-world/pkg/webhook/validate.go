@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ResourceTypeMeasurement is the resource type of the measurement events.
+const ResourceTypeMeasurement = "measurement"
+
+// ResourceTypeWebhook is the resource type of the events of the webhook itself, e.g. the ping on registration.
+const ResourceTypeWebhook = "webhook"
+
+// ActionPing is the action of the test delivery of a webhook, e.g. on registration. It has no measurement UUID,
+// and may have a challenge to be echoed in the response.
+const ActionPing = "ping"
+
+// The actions of the measurement events.
+const (
+	ActionCreated  = "created"
+	ActionUpdated  = "updated"
+	ActionFinished = "finished"
+	ActionDeleted  = "deleted"
+)
+
+// Actions are the known actions of each known resource type.
+var Actions = map[string][]string{
+	ResourceTypeMeasurement: {ActionCreated, ActionUpdated, ActionFinished, ActionDeleted},
+	ResourceTypeWebhook:     {ActionPing},
+}
+
+// uuidPattern matches a UUID in the canonical form, e.g. "0f4c2c1e-8d5a-4b1e-9f0e-3a2b1c0d9e8f".
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// bodyFields are the JSON fields of Body, and the version field.
+var bodyFields = []string{"resource_type", "action", "measurement_uuid", "project_uuid", "challenge", VersionField}
+
+// FieldError is an invalid field of a webhook body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is the error of a webhook body with invalid fields.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "invalid fields: " + strings.Join(msgs, "; ")
+}
+
+// add adds the invalid field.
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// decodeV1 decodes and validates the JSON webhook body of schema version 1, whose fields are those of Body.
+// The fields must be strings, and the fields unknown to Body are rejected unless allowUnknownFields is true.
+// If any field is invalid, the error is a *ValidationError of all the invalid fields.
+func decodeV1(data []byte, allowUnknownFields bool) (*Body, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("unmarshal body: %w", err)
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("body is not a JSON object")
+	}
+
+	verr := &ValidationError{}
+	if !allowUnknownFields {
+		var unknown []string
+		for name := range fields {
+			if !slices.Contains(bodyFields, name) {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		for _, name := range unknown {
+			verr.add(name, "unknown field")
+		}
+	}
+
+	var body Body
+	for _, f := range []struct {
+		name string
+		v    *string
+	}{
+		{"resource_type", &body.ResourceType},
+		{"action", &body.Action},
+		{"measurement_uuid", &body.MeasurementUUID},
+		{"project_uuid", &body.ProjectUUID},
+		{"challenge", &body.Challenge},
+	} {
+		raw, ok := fields[f.name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, f.v); err != nil {
+			verr.add(f.name, "must be a string")
+		}
+	}
+	var invalid *ValidationError
+	if errors.As(body.Validate(), &invalid) {
+		for _, f := range invalid.Fields {
+			// A field that is not a string is already reported.
+			if !slices.ContainsFunc(verr.Fields, func(e FieldError) bool { return e.Field == f.Field }) {
+				verr.Fields = append(verr.Fields, f)
+			}
+		}
+	}
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+	return &body, nil
+}
+
+// IsPing reports whether the body is a ping, the test delivery of the webhook.
+func (b *Body) IsPing() bool {
+	return b.ResourceType == ResourceTypeWebhook && b.Action == ActionPing
+}
+
+// Validate validates the required fields, the UUIDs, and the resource type and the action against Actions.
+// The measurement UUID is not required for a ping.
+// If any field is invalid, the error is a *ValidationError of all the invalid fields.
+func (b *Body) Validate() error {
+	verr := &ValidationError{}
+	actions, knownType := Actions[b.ResourceType]
+	switch {
+	case b.ResourceType == "":
+		verr.add("resource_type", "required")
+	case !knownType:
+		verr.add("resource_type", "unknown resource type %q", b.ResourceType)
+	}
+	switch {
+	case b.Action == "":
+		verr.add("action", "required")
+	case knownType && !slices.Contains(actions, b.Action):
+		verr.add("action", "unknown action %q of %s", b.Action, b.ResourceType)
+	}
+	switch {
+	case b.MeasurementUUID == "" && b.IsPing():
+	case b.MeasurementUUID == "":
+		verr.add("measurement_uuid", "required")
+	case !uuidPattern.MatchString(b.MeasurementUUID):
+		verr.add("measurement_uuid", "must be a UUID")
+	}
+	if b.ProjectUUID != "" && !uuidPattern.MatchString(b.ProjectUUID) {
+		verr.add("project_uuid", "must be a UUID")
+	}
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
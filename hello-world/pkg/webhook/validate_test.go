@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeVersionedBody(t *testing.T) {
+	const measurementUUID = "0f4c2c1e-8d5a-4b1e-9f0e-3a2b1c0d9e8f"
+	for _, tt := range []struct {
+		name               string
+		data               string
+		version            string
+		allowUnknownFields bool
+		want               *Body
+		// wantFields are the invalid fields of the *ValidationError, if any.
+		wantFields []string
+		wantErr    bool
+	}{
+		{
+			name: "finished",
+			data: `{"resource_type":"measurement","action":"finished","measurement_uuid":"` + measurementUUID + `"}`,
+			want: &Body{ResourceType: ResourceTypeMeasurement, Action: ActionFinished, MeasurementUUID: measurementUUID},
+		},
+		{
+			name: "ping without measurement",
+			data: `{"resource_type":"webhook","action":"ping","challenge":"abc"}`,
+			want: &Body{ResourceType: ResourceTypeWebhook, Action: ActionPing, Challenge: "abc"},
+		},
+		{
+			name: "version field as integer",
+			data: `{"schema_version":1,"resource_type":"measurement","action":"created","measurement_uuid":"` + measurementUUID + `"}`,
+			want: &Body{ResourceType: ResourceTypeMeasurement, Action: ActionCreated, MeasurementUUID: measurementUUID},
+		},
+		{
+			name:       "unknown field",
+			data:       `{"resource_type":"measurement","action":"finished","measurement_uuid":"` + measurementUUID + `","extra":1}`,
+			wantFields: []string{"extra"},
+		},
+		{
+			name:               "unknown field allowed",
+			data:               `{"resource_type":"measurement","action":"finished","measurement_uuid":"` + measurementUUID + `","extra":1}`,
+			allowUnknownFields: true,
+			want:               &Body{ResourceType: ResourceTypeMeasurement, Action: ActionFinished, MeasurementUUID: measurementUUID},
+		},
+		{
+			name:       "all invalid fields",
+			data:       `{"resource_type":"measurement","action":"archived","measurement_uuid":"not-a-uuid","project_uuid":42}`,
+			wantFields: []string{"project_uuid", "action", "measurement_uuid"},
+		},
+		{
+			name:       "missing fields",
+			data:       `{}`,
+			wantFields: []string{"resource_type", "action", "measurement_uuid"},
+		},
+		{
+			name:       "unknown resource type",
+			data:       `{"resource_type":"edge","action":"finished","measurement_uuid":"` + measurementUUID + `"}`,
+			wantFields: []string{"resource_type"},
+		},
+		{
+			name:       "unsupported version field",
+			data:       `{"schema_version":"2","resource_type":"measurement"}`,
+			wantFields: []string{VersionField},
+		},
+		{
+			name:       "unsupported version header",
+			data:       `{"resource_type":"measurement","action":"finished","measurement_uuid":"` + measurementUUID + `"}`,
+			version:    "2",
+			wantFields: []string{VersionField},
+		},
+		{
+			name:       "invalid version field",
+			data:       `{"schema_version":1.5}`,
+			wantFields: []string{VersionField},
+		},
+		{
+			name:    "not an object",
+			data:    `"finished"`,
+			wantErr: true,
+		},
+		{
+			name:    "null",
+			data:    `null`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeVersionedBody([]byte(tt.data), tt.version, tt.allowUnknownFields)
+			var verr *ValidationError
+			switch {
+			case tt.wantFields != nil:
+				if !errors.As(err, &verr) {
+					t.Fatalf("DecodeVersionedBody() error = %v, want a *ValidationError", err)
+				}
+				var fields []string
+				for _, f := range verr.Fields {
+					fields = append(fields, f.Field)
+				}
+				if !reflect.DeepEqual(fields, tt.wantFields) {
+					t.Errorf("invalid fields = %v, want %v", fields, tt.wantFields)
+				}
+			case tt.wantErr:
+				if err == nil || errors.As(err, &verr) {
+					t.Errorf("DecodeVersionedBody() error = %v, want an error of the JSON", err)
+				}
+			case err != nil:
+				t.Fatalf("DecodeVersionedBody() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeVersionedBody() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitBatch(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		data      string
+		wantBatch bool
+		wantLen   int
+		wantErr   bool
+	}{
+		{name: "single event", data: `{"resource_type":"measurement"}`},
+		{name: "batch", data: " \n[{\"action\":\"finished\"}, {\"action\":\"created\"}]", wantBatch: true, wantLen: 2},
+		{name: "empty batch", data: `[]`, wantBatch: true, wantErr: true},
+		{name: "malformed batch", data: `[{"action":"finished"}`, wantBatch: true, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBatch([]byte(tt.data)); got != tt.wantBatch {
+				t.Fatalf("IsBatch() = %t, want %t", got, tt.wantBatch)
+			}
+			if !tt.wantBatch {
+				return
+			}
+			events, err := SplitBatch([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitBatch() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if len(events) != tt.wantLen {
+				t.Errorf("SplitBatch() = %d events, want %d", len(events), tt.wantLen)
+			}
+			for _, event := range events {
+				if _, err := DecodeBody(event, true); err == nil {
+					t.Errorf("DecodeBody(%s) accepted an event without the required fields", event)
+				}
+			}
+		})
+	}
+}
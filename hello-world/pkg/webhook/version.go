@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+const (
+	// VersionHeader is the name of the header that contains the schema version of the body.
+	// It takes precedence over the version field of the body.
+	VersionHeader = "x-intdash-webhook-version"
+	// VersionField is the JSON field of the body that contains the schema version.
+	VersionField = "schema_version"
+	// DefaultVersion is the schema version of the bodies without a version, i.e. the fields of Body.
+	DefaultVersion = "1"
+)
+
+// Decoder decodes and validates the webhook body of a schema version into Body.
+// If any field is invalid, the error is a *ValidationError of all the invalid fields.
+type Decoder func(data []byte, allowUnknownFields bool) (*Body, error)
+
+// Decoders are the decoders of the supported schema versions.
+// A new version of the payload format is supported by registering its decoder.
+var Decoders = map[string]Decoder{
+	DefaultVersion: decodeV1,
+}
+
+// DecodeBody decodes and validates the JSON webhook body with the decoder of its schema version,
+// which is the version field of the body, or DefaultVersion if it has none.
+// The fields unknown to the schema are rejected unless allowUnknownFields is true.
+func DecodeBody(data []byte, allowUnknownFields bool) (*Body, error) {
+	return DecodeVersionedBody(data, "", allowUnknownFields)
+}
+
+// DecodeVersionedBody is like DecodeBody, but with the schema version given by VersionHeader, if not empty.
+func DecodeVersionedBody(data []byte, version string, allowUnknownFields bool) (*Body, error) {
+	if version == "" {
+		v, err := bodyVersion(data)
+		if err != nil {
+			return nil, err
+		}
+		version = v
+	}
+	decode, ok := Decoders[version]
+	if !ok {
+		return nil, &ValidationError{Fields: []FieldError{{
+			Field:   VersionField,
+			Message: fmt.Sprintf("unsupported schema version %q, supported: %v", version, Versions()),
+		}}}
+	}
+	return decode(data, allowUnknownFields)
+}
+
+// Versions returns the supported schema versions in order.
+func Versions() []string {
+	versions := make([]string, 0, len(Decoders))
+	for v := range Decoders {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// bodyVersion returns the version field of the body, a string or an integer, or DefaultVersion if it has none.
+func bodyVersion(data []byte) (string, error) {
+	var fields struct {
+		Version json.RawMessage `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("unmarshal body: %w", err)
+	}
+	if fields.Version == nil {
+		return DefaultVersion, nil
+	}
+	var s string
+	if err := json.Unmarshal(fields.Version, &s); err == nil {
+		return s, nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(fields.Version, &n); err == nil {
+		if _, err := n.Int64(); err == nil {
+			return n.String(), nil
+		}
+	}
+	return "", &ValidationError{Fields: []FieldError{{Field: VersionField, Message: "must be a string or an integer"}}}
+}